@@ -0,0 +1,123 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"github.com/rickyson96/amartha-reconciliation-service/internal/store"
+)
+
+// decisionFlags parses the common flags of the decision commands
+type decisionFlags struct {
+	set     *flag.FlagSet
+	store   *string
+	user    *string
+	comment *string
+}
+
+func newDecisionFlags(name, args string) decisionFlags {
+	set := flag.NewFlagSet(name, flag.ExitOnError)
+	set.Usage = func() {
+		fmt.Fprintf(set.Output(), "Usage of %s %s [options] %s\n", os.Args[0], name, args)
+		fmt.Fprintf(set.Output(), "Options:\n")
+		set.PrintDefaults()
+	}
+
+	return decisionFlags{
+		set:     set,
+		store:   set.String("store", "", "store json file holding the open items (required)"),
+		user:    set.String("user", os.Getenv("USER"), "user making the decision, defaults to $USER"),
+		comment: set.String("comment", "", "reason of the decision"),
+	}
+}
+
+// decide records the decision into the store
+func (f decisionFlags) decide(d store.Decision) {
+	if *f.store == "" {
+		f.fatal("ERROR: -store is required")
+	}
+	if *f.user == "" {
+		f.fatal("ERROR: -user is required")
+	}
+
+	st, err := store.Open(*f.store)
+	if err != nil {
+		log.Fatalf("ERROR: open store: %v", err)
+	}
+
+	d.User = *f.user
+	d.Comment = *f.comment
+	d.At = time.Now()
+	if err := st.Decide(d); err != nil {
+		log.Fatalf("ERROR: %s: %v", d.Kind, err)
+	}
+
+	if err := st.Save(); err != nil {
+		log.Fatalf("ERROR: save store: %v", err)
+	}
+
+	fmt.Printf("Recorded %s on %s by %s\n", d.Kind, decisionItems(d), d.User)
+}
+
+func (f decisionFlags) fatal(format string, msg ...any) {
+	fmt.Printf(format+"\n\n", msg...)
+	f.set.Usage()
+	os.Exit(1)
+}
+
+// runMatch force-matches a transaction to a statement
+func runMatch(args []string) {
+	f := newDecisionFlags("match", "{trxID} {statement file} {unique identifier}")
+	f.set.Parse(args)
+
+	if f.set.NArg() != 3 {
+		f.fatal("ERROR: Need exactly 3 arguments!")
+	}
+
+	f.decide(store.Decision{
+		Kind:             store.DecisionMatch,
+		TrxID:            f.set.Arg(0),
+		Source:           f.set.Arg(1),
+		UniqueIdentifier: f.set.Arg(2),
+	})
+}
+
+// runResolve marks a transaction or a statement as written off or being
+// investigated
+func runResolve(args []string) {
+	f := newDecisionFlags("resolve", "{trxID} | {statement file} {unique identifier}")
+	status := f.set.String("status", store.DecisionInvestigating, "either written-off or investigating")
+	f.set.Parse(args)
+
+	d := store.Decision{Kind: *status}
+	switch f.set.NArg() {
+	case 1:
+		d.TrxID = f.set.Arg(0)
+	case 2:
+		d.Source = f.set.Arg(0)
+		d.UniqueIdentifier = f.set.Arg(1)
+	default:
+		f.fatal("ERROR: Need either 1 or 2 arguments!")
+	}
+
+	if d.Kind != store.DecisionWriteOff && d.Kind != store.DecisionInvestigating {
+		f.fatal("ERROR: unknown status %q", d.Kind)
+	}
+
+	f.decide(d)
+}
+
+// decisionItems formats the items of the decision
+func decisionItems(d store.Decision) string {
+	switch {
+	case d.TrxID != "" && d.Source != "":
+		return fmt.Sprintf("transaction %s and statement %s/%s", d.TrxID, d.Source, d.UniqueIdentifier)
+	case d.TrxID != "":
+		return fmt.Sprintf("transaction %s", d.TrxID)
+	default:
+		return fmt.Sprintf("statement %s/%s", d.Source, d.UniqueIdentifier)
+	}
+}
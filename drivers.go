@@ -0,0 +1,8 @@
+package main
+
+// database/sql drivers of the transaction database
+import (
+	_ "github.com/go-sql-driver/mysql"
+	_ "github.com/lib/pq"
+	_ "github.com/mattn/go-sqlite3"
+)
@@ -3,7 +3,12 @@ module github.com/rickyson96/amartha-reconciliation-service
 go 1.24.1
 
 require (
+	github.com/go-sql-driver/mysql v1.9.3
 	github.com/google/go-cmp v0.7.0
 	github.com/govalues/decimal v0.1.36
+	github.com/lib/pq v1.10.9
+	github.com/mattn/go-sqlite3 v1.14.32
 	golang.org/x/sync v0.12.0
 )
+
+require filippo.io/edwards25519 v1.1.0 // indirect
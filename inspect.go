@@ -0,0 +1,189 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/rickyson96/amartha-reconciliation-service/internal/config"
+	"github.com/rickyson96/amartha-reconciliation-service/internal/input"
+	"github.com/rickyson96/amartha-reconciliation-service/internal/parsers/statements"
+	"github.com/rickyson96/amartha-reconciliation-service/internal/parsers/transactions"
+	"github.com/rickyson96/amartha-reconciliation-service/internal/processes/inspect"
+)
+
+// runInspect prints the statistics of the input files
+func runInspect(args []string) {
+	set := flag.NewFlagSet("inspect", flag.ExitOnError)
+	set.Usage = func() {
+		fmt.Fprintf(set.Output(), "Usage of %s inspect [options] {transaction file} [statement files]\n", os.Args[0])
+		fmt.Fprintf(set.Output(), "Options:\n")
+		set.PrintDefaults()
+	}
+	profilePath := set.String("profile", "", "reconciliation profile json file, configuring the file formats")
+	format := set.String("format", "text", "output format, either text or json")
+	top := set.Int("top", 5, "number of the largest amounts to show")
+	start := set.String("start", "", "only inspect rows from the date")
+	end := set.String("end", "", "only inspect rows until the date")
+	set.Parse(args)
+
+	fatal := func(format string, msg ...any) {
+		fmt.Printf(format+"\n\n", msg...)
+		set.Usage()
+		os.Exit(1)
+	}
+
+	if set.NArg() < 1 || set.NArg() > 2 {
+		fatal("ERROR: Need either 1 or 2 arguments!")
+	}
+	if *format != "text" && *format != "json" {
+		fatal("ERROR: unknown output format %q", *format)
+	}
+	if *top < 0 {
+		fatal("ERROR: top should not be negative")
+	}
+
+	// inspect all rows unless the range is given
+	startDate, endDate := time.Time{}, time.Date(9999, 12, 30, 0, 0, 0, 0, time.UTC)
+	var err error
+	if *start != "" {
+		if startDate, err = time.Parse(time.DateOnly, *start); err != nil {
+			fatal("ERROR: start date wrong format: %v", err)
+		}
+	}
+	if *end != "" {
+		if endDate, err = time.Parse(time.DateOnly, *end); err != nil {
+			fatal("ERROR: end date wrong format: %v", err)
+		}
+	}
+
+	profile, err := config.Load(*profilePath)
+	if err != nil {
+		fatal("ERROR: load profile: %v", err)
+	}
+
+	var statementFiles []string
+	if set.NArg() == 2 {
+		statementFiles = strings.Split(set.Arg(1), ",")
+	}
+
+	stats, err := inspectFiles(profile, set.Arg(0), statementFiles, *top, startDate, endDate)
+	if err != nil {
+		log.Fatalf("ERROR: inspect: %v", err)
+	}
+
+	if *format == "json" {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(stats); err != nil {
+			log.Fatalf("ERROR: encode: %v", err)
+		}
+		return
+	}
+
+	printInspect(os.Stdout, stats)
+}
+
+func inspectFiles(profile config.Profile, transactionFile string, statementFiles []string, top int, startDate, endDate time.Time) ([]inspect.Stats, error) {
+	statementFiles, err := input.Expand(statementFiles...)
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := input.Open(transactionFile)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	parser, err := transactions.NewReader(file, startDate, endDate, profile.TransactionFormat.Format)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", transactionFile, err)
+	}
+	stat, err := inspect.Transactions(transactionFile, parser.ReadRow, top)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", transactionFile, err)
+	}
+	stats := []inspect.Stats{stat}
+
+	for _, fileName := range statementFiles {
+		file, err := input.Open(fileName)
+		if err != nil {
+			return nil, err
+		}
+
+		parser, err := statements.NewReader(fileName, file, startDate, endDate, profile.StatementFormat(fileName))
+		if err != nil {
+			file.Close()
+			return nil, fmt.Errorf("%s: %w", fileName, err)
+		}
+		stat, err := inspect.Statements(fileName, parser.ReadRow, top)
+		file.Close()
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", fileName, err)
+		}
+		stats = append(stats, stat)
+	}
+
+	return stats, nil
+}
+
+func printInspect(out io.Writer, stats []inspect.Stats) {
+	for i, s := range stats {
+		if i > 0 {
+			fmt.Fprintln(out, "------------------")
+		}
+
+		fmt.Fprintf(out, "%s:\n", s.File)
+		fmt.Fprintf(out, "Rows: %d\n", s.Rows)
+		fmt.Fprintf(out, "Date Range: %v - %v\n", formatDate(s.First), formatDate(s.Last))
+		fmt.Fprintf(out, "Distinct Keys: %d\n", s.DistinctKeys)
+
+		w := tabwriter.NewWriter(out, 4, 0, 2, ' ', 0)
+		fmt.Fprintf(w, "\nBy Type:\n\n")
+		fmt.Fprintln(w, "\tType\tCount\tSum")
+		for _, trxType := range s.SortedTypes() {
+			t := s.ByType[trxType]
+			fmt.Fprintf(w, "\t%v\t%d\t%v\n", trxType, t.Count, t.Sum)
+		}
+		w.Flush()
+
+		busiest := 0
+		for _, d := range s.PerDay {
+			busiest = max(busiest, d.Count)
+		}
+		w = tabwriter.NewWriter(out, 4, 0, 2, ' ', 0)
+		fmt.Fprintf(w, "\nPer Day:\n\n")
+		fmt.Fprintln(w, "\tDate\tCount\t")
+		for _, d := range s.PerDay {
+			fmt.Fprintf(w, "\t%v\t%d\t%s\n", d.Date.Format(time.DateOnly), d.Count, histogramBar(d.Count, busiest))
+		}
+		w.Flush()
+
+		w = tabwriter.NewWriter(out, 4, 0, 2, ' ', 0)
+		fmt.Fprintf(w, "\nLargest Amounts:\n\n")
+		fmt.Fprintln(w, "\tLine\tID\tType\tAmount\tDate")
+		for _, r := range s.Largest {
+			fmt.Fprintf(w, "\t%d\t%v\t%v\t%v\t%v\n", r.Line, r.ID, r.Type, r.Amount, r.Date.Format(time.DateOnly))
+		}
+		w.Flush()
+	}
+}
+
+// histogramWidth is the width of the bar of the busiest day
+const histogramWidth = 40
+
+// histogramBar scales the count against the busiest day, where any
+// count shows at least a single mark
+func histogramBar(count, busiest int) string {
+	if count <= 0 || busiest <= 0 {
+		return ""
+	}
+	return strings.Repeat("#", max(count*histogramWidth/busiest, 1))
+}
@@ -0,0 +1,336 @@
+package config
+
+import (
+	"cmp"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/govalues/decimal"
+	"github.com/rickyson96/amartha-reconciliation-service/internal/parsers/statements"
+	"github.com/rickyson96/amartha-reconciliation-service/internal/parsers/transactions"
+	"github.com/rickyson96/amartha-reconciliation-service/internal/processes/reconciliation"
+)
+
+// Profile is the reconciliation configuration, loaded from a json file.
+type Profile struct {
+	// ReferencePattern is the regex used to extract transaction
+	// reference out of the statement description. The first capture
+	// group is treated as the reference.
+	ReferencePattern  string            `json:"referencePattern"`
+	TransactionFormat TransactionFormat `json:"transactionFormat"`
+	StatementFormats  []StatementFormat `json:"statementFormats"`
+	// Matchers is the ordered matching pipeline. Defaults to reference
+	// matching (when ReferencePattern is set) followed by key matching.
+	Matchers []Matcher `json:"matchers"`
+	// Netting enables netting out reversed transactions before matching
+	Netting *Netting `json:"netting"`
+	// Balances declares the statement files balance, keyed by the file
+	// base name. Files without declared balance may provide a sidecar,
+	// see [BalanceSidecar].
+	Balances map[string]statements.Balance `json:"balances"`
+	// TransactionDatabase queries the transactions out of the database
+	// instead of reading the transaction file
+	TransactionDatabase *TransactionDatabase `json:"transactionDatabase"`
+	// ResultDatabase receives the reconciliation results besides the
+	// report, either on SQLite or Postgres
+	ResultDatabase *Database `json:"resultDatabase"`
+}
+
+// Database is the connection of a database/sql driver, e.g. "sqlite3",
+// "postgres" or "mysql"
+type Database struct {
+	Driver string `json:"driver"`
+	// DSN is the data source name of the driver, where the environment
+	// variables are expanded, e.g. "postgres://recon:${PGPASSWORD}@db/ledger"
+	DSN string `json:"dsn"`
+}
+
+// TransactionDatabase is the query of the transactions, whose selected
+// columns follow the transaction format
+type TransactionDatabase struct {
+	Database
+	// Query takes the start date and the day after the end date as its
+	// parameters, "$1" and "$2" for postgres or "?" for mysql and
+	// sqlite3, see [transactions.NewDatabaseReader]
+	Query string `json:"query"`
+}
+
+// Open opens the database, the driver should be registered by the caller
+func (d Database) Open() (*sql.DB, error) {
+	return sql.Open(d.Driver, os.ExpandEnv(d.DSN))
+}
+
+// Netting configures the reversal netting stage
+type Netting struct {
+	ByReference bool `json:"byReference"`
+	// Window is the duration (e.g. "2h") for netting transactions of the
+	// same amount and opposite type. Empty disables it.
+	Window string `json:"window"`
+}
+
+// Matcher configures a matching strategy of the pipeline
+type Matcher struct {
+	// Strategy is one of the reconciliation rule name, e.g. "key",
+	// "reference", "tolerance", "date-window" or "grouping"
+	Strategy string `json:"strategy"`
+	// Tolerance is the allowed amount difference for "tolerance"
+	Tolerance decimal.Decimal `json:"tolerance"`
+	// Days is the allowed date difference for "date-window"
+	Days int `json:"days"`
+	// MaxGroupSize is the most transactions summed up by "grouping",
+	// defaulting to [reconciliation.DefaultMaxGroupSize]
+	MaxGroupSize int `json:"maxGroupSize"`
+}
+
+var (
+	ErrUnknownStrategy         = errors.New("unknown matching strategy")
+	ErrMissingReferencePattern = errors.New("reference strategy requires referencePattern, unless the statements carry their reference")
+)
+
+// TransactionFormat is the layout of the transaction file
+type TransactionFormat struct {
+	transactions.Format
+}
+
+// UnmarshalJSON fills unset columns with [transactions.DefaultFormat]
+func (f *TransactionFormat) UnmarshalJSON(data []byte) error {
+	format := transactions.DefaultFormat
+	if err := json.Unmarshal(data, &format); err != nil {
+		return err
+	}
+
+	f.Format = format
+	return nil
+}
+
+// StatementFormat assigns the statement layout to statement files
+// whose base name matches the pattern (see [filepath.Match]).
+type StatementFormat struct {
+	Name    string `json:"name"`
+	Pattern string `json:"pattern"`
+	// Account is the bank account of the files, lines of the same
+	// UniqueIdentifier across files of the same account are read once.
+	// Defaults to the name.
+	Account string `json:"account"`
+	statements.Format
+}
+
+// UnmarshalJSON fills unset columns with [statements.DefaultFormat]
+func (f *StatementFormat) UnmarshalJSON(data []byte) error {
+	type statementFormat StatementFormat
+	format := statementFormat{Format: statements.DefaultFormat}
+	if err := json.Unmarshal(data, &format); err != nil {
+		return err
+	}
+
+	*f = StatementFormat(format)
+	return nil
+}
+
+// Load reads profile from the json file. Empty path returns the default
+// profile.
+func Load(path string) (Profile, error) {
+	if path == "" {
+		return Default(), nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Profile{}, err
+	}
+	return Parse(data)
+}
+
+// Parse parses the profile json, filling what it leaves unset with
+// [Default]
+func Parse(data []byte) (Profile, error) {
+	profile := Default()
+	if err := json.Unmarshal(data, &profile); err != nil {
+		return Profile{}, err
+	}
+
+	// the charset is otherwise only checked once the files are read
+	if err := profile.TransactionFormat.Dialect.CheckCharset(); err != nil {
+		return Profile{}, fmt.Errorf("transactionFormat: %w", err)
+	}
+	for _, f := range profile.StatementFormats {
+		if err := f.Dialect.CheckCharset(); err != nil {
+			return Profile{}, fmt.Errorf("statement format %s: %w", cmp.Or(f.Name, f.Pattern), err)
+		}
+	}
+
+	return profile, nil
+}
+
+// BalanceSidecar is the balance json file accompanying the statement
+// file, e.g. bank1.csv.balance.json
+func BalanceSidecar(fileName string) string {
+	return fileName + ".balance.json"
+}
+
+// StatementBalance returns the balance declared for the statement file,
+// falling back to its sidecar. It returns false when none is available.
+func (p Profile) StatementBalance(fileName string) (statements.Balance, bool, error) {
+	if balance, ok := p.Balances[filepath.Base(fileName)]; ok {
+		return balance, true, nil
+	}
+
+	// zip members (archive.zip/member.csv) can't have sidecar, as their
+	// directory is the archive file
+	if info, err := os.Stat(filepath.Dir(fileName)); err == nil && !info.IsDir() {
+		return statements.Balance{}, false, nil
+	}
+
+	file, err := os.Open(BalanceSidecar(fileName))
+	if errors.Is(err, fs.ErrNotExist) {
+		return statements.Balance{}, false, nil
+	}
+	if err != nil {
+		return statements.Balance{}, false, err
+	}
+	defer file.Close()
+
+	balance, err := statements.ReadBalance(file)
+	if err != nil {
+		return statements.Balance{}, false, fmt.Errorf("%s: %w", BalanceSidecar(fileName), err)
+	}
+
+	return balance, true, nil
+}
+
+// Default is the profile used without a profile file
+func Default() Profile {
+	return Profile{
+		TransactionFormat: TransactionFormat{transactions.DefaultFormat},
+	}
+}
+
+// StatementFormat returns the first format matching the file name,
+// falling back to [statements.DefaultFormat].
+func (p Profile) StatementFormat(fileName string) statements.Format {
+	base := filepath.Base(fileName)
+	for _, f := range p.StatementFormats {
+		if ok, _ := filepath.Match(f.Pattern, base); ok {
+			return f.Format
+		}
+	}
+
+	return statements.DefaultFormat
+}
+
+// StatementAccount returns the account of the first format matching the
+// file name. Files without account return empty string.
+func (p Profile) StatementAccount(fileName string) string {
+	base := filepath.Base(fileName)
+	for _, f := range p.StatementFormats {
+		if ok, _ := filepath.Match(f.Pattern, base); ok {
+			return cmp.Or(f.Account, f.Name)
+		}
+	}
+
+	return ""
+}
+
+// StatementAccounts maps the statement sources into their account, see
+// [Profile.StatementAccount]. Sources without account are left out.
+func (p Profile) StatementAccounts(sources []string) map[string]string {
+	accounts := make(map[string]string)
+	for _, source := range sources {
+		if account := p.StatementAccount(source); account != "" {
+			accounts[source] = account
+		}
+	}
+	return accounts
+}
+
+// ReconciliationOptions builds the options for reconciliation processes
+// of the statement sources
+func (p Profile) ReconciliationOptions(sources []string) (reconciliation.Options, error) {
+	var rule *reconciliation.ReferenceRule
+	if p.ReferencePattern != "" {
+		var err error
+		rule, err = reconciliation.NewReferenceRule(p.ReferencePattern)
+		if err != nil {
+			return reconciliation.Options{}, err
+		}
+	}
+
+	matcherConfigs := p.Matchers
+	if len(matcherConfigs) == 0 {
+		if rule != nil {
+			matcherConfigs = append(matcherConfigs, Matcher{Strategy: reconciliation.RuleReference})
+		}
+		matcherConfigs = append(matcherConfigs, Matcher{Strategy: reconciliation.RuleKey})
+	}
+
+	var opts reconciliation.Options
+	if p.Netting != nil {
+		netting, err := p.Netting.build()
+		if err != nil {
+			return reconciliation.Options{}, err
+		}
+		opts.Netting = netting
+	}
+
+	for _, m := range matcherConfigs {
+		if m.Strategy == reconciliation.RuleReference && rule == nil && !p.statementReferences(sources) {
+			return reconciliation.Options{}, ErrMissingReferencePattern
+		}
+
+		matcher, err := m.build(rule)
+		if err != nil {
+			return reconciliation.Options{}, err
+		}
+		opts.Matchers = append(opts.Matchers, matcher)
+	}
+
+	return opts, nil
+}
+
+// statementReferences reports whether any of the statement sources
+// carries the reference itself, e.g. the end to end id of camt
+func (p Profile) statementReferences(sources []string) bool {
+	for _, source := range sources {
+		switch p.StatementFormat(source).FileType(source) {
+		case statements.FileTypeCamt, statements.FileTypeMT940:
+			return true
+		}
+	}
+	return false
+}
+
+func (n Netting) build() (*reconciliation.Netting, error) {
+	netting := reconciliation.Netting{ByReference: n.ByReference}
+	if n.Window != "" {
+		window, err := time.ParseDuration(n.Window)
+		if err != nil {
+			return nil, err
+		}
+		netting.Window = window
+	}
+
+	return &netting, nil
+}
+
+func (m Matcher) build(rule *reconciliation.ReferenceRule) (reconciliation.Matcher, error) {
+	switch m.Strategy {
+	case reconciliation.RuleKey:
+		return reconciliation.NewKeyMatcher(), nil
+	case reconciliation.RuleReference:
+		return reconciliation.NewReferenceMatcher(rule), nil
+	case reconciliation.RuleTolerance:
+		return reconciliation.NewToleranceMatcher(m.Tolerance), nil
+	case reconciliation.RuleDateWindow:
+		return reconciliation.NewDateWindowMatcher(m.Days), nil
+	case reconciliation.RuleGrouping:
+		return reconciliation.NewGroupingMatcher(m.MaxGroupSize), nil
+	default:
+		return nil, fmt.Errorf("%q is %w", m.Strategy, ErrUnknownStrategy)
+	}
+}
@@ -0,0 +1,329 @@
+package config_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/rickyson96/amartha-reconciliation-service/internal/config"
+	csvparser "github.com/rickyson96/amartha-reconciliation-service/internal/csv_parser"
+	"github.com/rickyson96/amartha-reconciliation-service/internal/parsers/statements"
+	"github.com/rickyson96/amartha-reconciliation-service/internal/parsers/transactions"
+	"github.com/rickyson96/amartha-reconciliation-service/internal/processes/reconciliation"
+	"github.com/rickyson96/amartha-reconciliation-service/internal/testutils"
+)
+
+func writeProfile(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "profile.json")
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("failed writing profile: %v", err)
+	}
+	return path
+}
+
+func TestLoad(t *testing.T) {
+	path := writeProfile(t, `{
+		"referencePattern": "REF:(\\w+)",
+		"statementFormats": [
+			{"name": "bank3", "pattern": "bank3*.csv", "descriptionColumn": 3, "fieldPerRow": 4, "delimiter": ";", "charset": "windows-1252"}
+		]
+	}`)
+
+	profile, err := config.Load(path)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+
+	want := statements.Format{
+		UniqueIdentifierColumn: 0,
+		AmountColumn:           1,
+		DateColumn:             2,
+		DescriptionColumn:      3,
+		FieldPerRow:            4,
+		TypeColumn:             -1,
+		DebitAmountColumn:      -1,
+		CreditAmountColumn:     -1,
+		Dialect:                csvparser.Dialect{Delimiter: ";", Charset: csvparser.CharsetWindows1252},
+	}
+	if diff := cmp.Diff(want, profile.StatementFormat("data/bank3_march.csv")); diff != "" {
+		t.Errorf("StatementFormat mismatch, (-want,+got):\n%s", diff)
+	}
+
+	if diff := cmp.Diff(statements.DefaultFormat, profile.StatementFormat("bank1.csv")); diff != "" {
+		t.Errorf("StatementFormat fallback mismatch, (-want,+got):\n%s", diff)
+	}
+
+	opts, err := profile.ReconciliationOptions(nil)
+	if err != nil {
+		t.Fatalf("ReconciliationOptions failed: %v", err)
+	}
+	if diff := cmp.Diff([]string{"reference", "key"}, matcherNames(opts)); diff != "" {
+		t.Errorf("default matchers mismatch, (-want,+got):\n%s", diff)
+	}
+}
+
+func matcherNames(opts reconciliation.Options) []string {
+	var names []string
+	for _, m := range opts.Matchers {
+		names = append(names, m.Name())
+	}
+	return names
+}
+
+func TestReconciliationOptions(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    []string
+		wantErr bool
+	}{
+		{"default", `{}`, []string{"key"}, false},
+		{
+			name: "configured pipeline",
+			content: `{"referencePattern": "REF:(\\w+)", "matchers": [
+				{"strategy": "key"},
+				{"strategy": "reference"},
+				{"strategy": "tolerance", "tolerance": "0.50"},
+				{"strategy": "date-window", "days": 2},
+				{"strategy": "grouping", "maxGroupSize": 3}
+			]}`,
+			want:    []string{"key", "reference", "tolerance", "date-window", "grouping"},
+			wantErr: false,
+		},
+		{"reference without pattern", `{"matchers": [{"strategy": "reference"}]}`, nil, true},
+		{"unknown strategy", `{"matchers": [{"strategy": "fuzzy"}]}`, nil, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			profile, err := config.Load(writeProfile(t, test.content))
+			if err != nil {
+				t.Fatalf("Load failed: %v", err)
+			}
+
+			opts, err := profile.ReconciliationOptions(nil)
+			if test.wantErr != (err != nil) {
+				t.Errorf("wantErr is %t, but err is %v", test.wantErr, err)
+			}
+			if diff := cmp.Diff(test.want, matcherNames(opts)); diff != "" {
+				t.Errorf("matchers mismatch, (-want,+got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestReconciliationOptionsStatementReference(t *testing.T) {
+	profile, err := config.Load(writeProfile(t, `{"matchers": [{"strategy": "reference"}]}`))
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+
+	// camt and mt940 statements carry their reference
+	opts, err := profile.ReconciliationOptions([]string{"bank1.csv", "bank4.xml"})
+	if err != nil {
+		t.Fatalf("ReconciliationOptions failed: %v", err)
+	}
+	if diff := cmp.Diff([]string{"reference"}, matcherNames(opts)); diff != "" {
+		t.Errorf("matchers mismatch, (-want,+got):\n%s", diff)
+	}
+
+	if _, err := profile.ReconciliationOptions([]string{"bank1.csv"}); !errors.Is(err, config.ErrMissingReferencePattern) {
+		t.Errorf("want ErrMissingReferencePattern, got %v", err)
+	}
+}
+
+func TestLoadError(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+	}{
+		{"invalid json", `{`},
+		{"invalid format", `{"statementFormats": [{"amountColumn": "a"}]}`},
+		{"unknown transaction charset", `{"transactionFormat": {"charset": "latin-2"}}`},
+		{"unknown statement charset", `{"statementFormats": [{"name": "bank1", "charset": "latin-2"}]}`},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if _, err := config.Load(writeProfile(t, test.content)); err == nil {
+				t.Errorf("Load should fail")
+			}
+		})
+	}
+
+	profile, err := config.Load(writeProfile(t, `{"referencePattern": "REF:\\w+"}`))
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if _, err := profile.ReconciliationOptions(nil); err == nil {
+		t.Errorf("ReconciliationOptions should fail without capture group")
+	}
+}
+
+func TestStatementFormatDefaults(t *testing.T) {
+	profile, err := config.Load(writeProfile(t, `{"statementFormats": [
+		{"pattern": "bank4*.csv", "amountColumn": 2, "dateColumn": 1, "typeColumn": 3, "fieldPerRow": 4}
+	]}`))
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+
+	want := statements.Format{
+		UniqueIdentifierColumn: 0,
+		AmountColumn:           2,
+		DateColumn:             1,
+		DescriptionColumn:      -1,
+		FieldPerRow:            4,
+		TypeColumn:             3,
+		DebitAmountColumn:      -1,
+		CreditAmountColumn:     -1,
+	}
+	if diff := cmp.Diff(want, profile.StatementFormat("bank4.csv")); diff != "" {
+		t.Errorf("StatementFormat mismatch, (-want,+got):\n%s", diff)
+	}
+}
+
+func TestStatementAccount(t *testing.T) {
+	profile, err := config.Load(writeProfile(t, `{"statementFormats": [
+		{"name": "bank1", "pattern": "bank1*.csv"},
+		{"name": "bank2 savings", "pattern": "bank2_savings*.csv", "account": "bank2-001"},
+		{"name": "bank2 current", "pattern": "bank2_current*.csv", "account": "bank2-002"}
+	]}`))
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+
+	tests := map[string]string{
+		"data/bank1_week1.csv": "bank1",
+		"bank1_month.csv":      "bank1",
+		"bank2_savings_03.csv": "bank2-001",
+		"bank2_current_03.csv": "bank2-002",
+		"bank3.csv":            "",
+	}
+	for fileName, want := range tests {
+		if got := profile.StatementAccount(fileName); got != want {
+			t.Errorf("StatementAccount(%q) mismatch, want %q, got %q", fileName, want, got)
+		}
+	}
+}
+
+func TestTransactionFormat(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    transactions.Format
+	}{
+		{"default", `{}`, transactions.DefaultFormat},
+		{
+			name:    "aliases",
+			content: `{"transactionFormat": {"typeColumn": 3, "transactionTimeColumn": 2, "typeAliases": {"CR": "CREDIT", "rev": "reversal"}}}`,
+			want: transactions.Format{
+				TrxIDColumn:           0,
+				AmountColumn:          1,
+				TypeColumn:            3,
+				TransactionTimeColumn: 2,
+				ReferenceColumn:       -1,
+				FieldPerRow:           4,
+				TypeAliases: map[string]transactions.TransactionType{
+					"CR":  transactions.TransactionTypeCredit,
+					"rev": transactions.TransactionTypeReversal,
+				},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			profile, err := config.Load(writeProfile(t, test.content))
+			if err != nil {
+				t.Fatalf("Load failed: %v", err)
+			}
+			if diff := cmp.Diff(test.want, profile.TransactionFormat.Format); diff != "" {
+				t.Errorf("TransactionFormat mismatch, (-want,+got):\n%s", diff)
+			}
+		})
+	}
+
+	if _, err := config.Load(writeProfile(t, `{"transactionFormat": {"typeAliases": {"X": "UNKNOWN"}}}`)); err == nil {
+		t.Errorf("Load should fail on unknown alias target")
+	}
+}
+
+func TestNetting(t *testing.T) {
+	profile, err := config.Load(writeProfile(t, `{"netting": {"byReference": true, "window": "2h"}}`))
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+
+	opts, err := profile.ReconciliationOptions(nil)
+	if err != nil {
+		t.Fatalf("ReconciliationOptions failed: %v", err)
+	}
+
+	want := &reconciliation.Netting{ByReference: true, Window: 2 * time.Hour}
+	if diff := cmp.Diff(want, opts.Netting); diff != "" {
+		t.Errorf("Netting mismatch, (-want,+got):\n%s", diff)
+	}
+
+	profile, err = config.Load(writeProfile(t, `{"netting": {"window": "2 hours"}}`))
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if _, err := profile.ReconciliationOptions(nil); err == nil {
+		t.Errorf("ReconciliationOptions should fail on invalid window")
+	}
+}
+
+func TestStatementBalance(t *testing.T) {
+	dir := t.TempDir()
+	sidecar := filepath.Join(dir, "bank2.csv")
+	if err := os.WriteFile(config.BalanceSidecar(sidecar), []byte(`{"opening": "5", "closing": "6"}`), 0o600); err != nil {
+		t.Fatalf("failed writing sidecar: %v", err)
+	}
+	broken := filepath.Join(dir, "bank3.csv")
+	if err := os.WriteFile(config.BalanceSidecar(broken), []byte(`{`), 0o600); err != nil {
+		t.Fatalf("failed writing sidecar: %v", err)
+	}
+	archive := filepath.Join(dir, "archive.zip")
+	if err := os.WriteFile(archive, nil, 0o600); err != nil {
+		t.Fatalf("failed writing archive: %v", err)
+	}
+
+	profile, err := config.Load(writeProfile(t, `{"balances": {"bank1.csv": {"opening": "1", "closing": "2"}}}`))
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+
+	tests := []struct {
+		fileName string
+		want     statements.Balance
+		wantOk   bool
+		wantErr  bool
+	}{
+		{filepath.Join(dir, "bank1.csv"), statements.Balance{Opening: testutils.NewDecimal(t, 1, 0), Closing: testutils.NewDecimal(t, 2, 0)}, true, false},
+		{sidecar, statements.Balance{Opening: testutils.NewDecimal(t, 5, 0), Closing: testutils.NewDecimal(t, 6, 0)}, true, false},
+		{broken, statements.Balance{}, false, true},
+		{filepath.Join(dir, "bank4.csv"), statements.Balance{}, false, false},
+		// zip member
+		{filepath.Join(archive, "bank5.csv"), statements.Balance{}, false, false},
+	}
+
+	for _, test := range tests {
+		t.Run(filepath.Base(test.fileName), func(t *testing.T) {
+			got, ok, err := profile.StatementBalance(test.fileName)
+			if test.wantErr != (err != nil) {
+				t.Errorf("wantErr is %t, but err is %v", test.wantErr, err)
+			}
+			if ok != test.wantOk {
+				t.Errorf("ok is %t, want %t", ok, test.wantOk)
+			}
+			if diff := cmp.Diff(test.want, got); diff != "" {
+				t.Errorf("StatementBalance mismatch, (-want,+got):\n%s", diff)
+			}
+		})
+	}
+}
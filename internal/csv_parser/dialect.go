@@ -0,0 +1,202 @@
+package csvparser
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	// CharsetUTF8 reads the file as is, which is the default
+	CharsetUTF8 = "utf-8"
+	// CharsetWindows1252 decodes the file from Windows-1252, e.g.
+	// exported by Excel on Windows
+	CharsetWindows1252 = "windows-1252"
+	// CharsetAuto reads the valid UTF-8 as is, decoding the invalid
+	// bytes from Windows-1252
+	CharsetAuto = "auto"
+
+	// DelimiterAuto detects the delimiter out of the first line
+	DelimiterAuto = "auto"
+)
+
+var ErrUnknownCharset = errors.New("unknown charset")
+
+var (
+	utf8BOM = []byte{0xEF, 0xBB, 0xBF}
+
+	// delimiters are the candidates of [DelimiterAuto]
+	delimiters = []rune{',', ';', '\t', '|'}
+)
+
+// Dialect is the flavour of the csv file. The zero value is the
+// standard comma separated UTF-8 csv.
+type Dialect struct {
+	// Delimiter is the field delimiter, defaults to comma.
+	// [DelimiterAuto] picks the most frequent of comma, semicolon, tab
+	// and pipe on the first line.
+	Delimiter string `json:"delimiter"`
+	// Comment is the character starting the comment lines, if any
+	Comment    string `json:"comment"`
+	LazyQuotes bool   `json:"lazyQuotes"`
+	// TrimSpace trims the spaces surrounding the fields
+	TrimSpace bool `json:"trimSpace"`
+	// Charset is one of [CharsetUTF8] (default), [CharsetWindows1252]
+	// or [CharsetAuto]. UTF-8 BOM is stripped unless it's Windows-1252.
+	Charset string `json:"charset"`
+}
+
+// CheckCharset returns [ErrUnknownCharset] when the charset isn't
+// supported, so the dialect can be checked before reading any file
+func (d Dialect) CheckCharset() error {
+	switch strings.ToLower(d.Charset) {
+	case "", CharsetUTF8, CharsetAuto, CharsetWindows1252:
+		return nil
+	default:
+		return fmt.Errorf("%q is %w", d.Charset, ErrUnknownCharset)
+	}
+}
+
+// newReader creates the csv reader of the file following the dialect
+func (d Dialect) newReader(file io.Reader) *csv.Reader {
+	reader := bufio.NewReader(file)
+
+	switch strings.ToLower(d.Charset) {
+	case "", CharsetUTF8:
+		stripBOM(reader)
+	case CharsetAuto:
+		stripBOM(reader)
+		reader = bufio.NewReader(&decoder{r: reader, auto: true})
+	case CharsetWindows1252:
+		reader = bufio.NewReader(&decoder{r: reader})
+	default:
+		reader = bufio.NewReader(errReader{d.CheckCharset()})
+	}
+
+	csvReader := csv.NewReader(reader)
+	csvReader.LazyQuotes = d.LazyQuotes
+	csvReader.TrimLeadingSpace = d.TrimSpace
+	csvReader.Comment = toRune(d.Comment)
+	switch d.Delimiter {
+	case "":
+	case DelimiterAuto:
+		csvReader.Comma = detectDelimiter(reader)
+	default:
+		// invalid delimiter is reported by the csv reader
+		csvReader.Comma = toRune(d.Delimiter)
+	}
+
+	return csvReader
+}
+
+// toRune returns the only rune of s, or zero otherwise
+func toRune(s string) rune {
+	if s == "" || utf8.RuneCountInString(s) != 1 {
+		return 0
+	}
+	r, _ := utf8.DecodeRuneInString(s)
+	return r
+}
+
+func stripBOM(reader *bufio.Reader) {
+	if prefix, _ := reader.Peek(len(utf8BOM)); bytes.Equal(prefix, utf8BOM) {
+		reader.Discard(len(utf8BOM))
+	}
+}
+
+// detectDelimiter picks the most frequent delimiter on the first line,
+// defaulting to comma
+func detectDelimiter(reader *bufio.Reader) rune {
+	line, _ := reader.Peek(reader.Size())
+	if i := bytes.IndexByte(line, '\n'); i >= 0 {
+		line = line[:i]
+	}
+
+	delimiter, most := ',', 0
+	for _, d := range delimiters {
+		if count := bytes.Count(line, []byte(string(d))); count > most {
+			delimiter, most = d, count
+		}
+	}
+	return delimiter
+}
+
+// decoder decodes Windows-1252 into UTF-8. On auto, valid UTF-8 is kept
+// as is.
+type decoder struct {
+	r       *bufio.Reader
+	auto    bool
+	pending []byte
+}
+
+func (d *decoder) Read(p []byte) (int, error) {
+	n := 0
+	for n < len(p) {
+		if len(d.pending) == 0 {
+			// don't block on the source when there's already something
+			if n > 0 && d.r.Buffered() == 0 {
+				break
+			}
+
+			r, err := d.next()
+			if err != nil {
+				if n > 0 && err == io.EOF {
+					break
+				}
+				return n, err
+			}
+			d.pending = utf8.AppendRune(d.pending[:0], r)
+		}
+
+		c := copy(p[n:], d.pending)
+		d.pending = d.pending[c:]
+		n += c
+	}
+
+	return n, nil
+}
+
+func (d *decoder) next() (rune, error) {
+	if d.auto {
+		r, size, err := d.r.ReadRune()
+		if err != nil {
+			return 0, err
+		}
+		if r != utf8.RuneError || size > 1 {
+			return r, nil
+		}
+		d.r.UnreadRune()
+	}
+
+	b, err := d.r.ReadByte()
+	if err != nil {
+		return 0, err
+	}
+	return windows1252(b), nil
+}
+
+// windows1252Table is 0x80-0x9F, where Windows-1252 differs from Latin-1
+var windows1252Table = [32]rune{
+	'€', utf8.RuneError, '‚', 'ƒ', '„', '…', '†', '‡', 'ˆ', '‰', 'Š', '‹', 'Œ', utf8.RuneError, 'Ž', utf8.RuneError,
+	utf8.RuneError, '‘', '’', '“', '”', '•', '–', '—', '˜', '™', 'š', '›', 'œ', utf8.RuneError, 'ž', 'Ÿ',
+}
+
+func windows1252(b byte) rune {
+	if b >= 0x80 && b < 0xA0 {
+		return windows1252Table[b-0x80]
+	}
+	return rune(b)
+}
+
+type errReader struct {
+	err error
+}
+
+func (r errReader) Read([]byte) (int, error) {
+	return 0, r.err
+}
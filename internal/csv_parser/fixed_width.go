@@ -0,0 +1,290 @@
+package csvparser
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/govalues/decimal"
+)
+
+const (
+	// FieldTypeString is the text of the field, with the padding trimmed
+	FieldTypeString = "string"
+	// FieldTypeNumber is the number with the implied decimals, e.g.
+	// "0000012345" of 2 decimals is "123.45"
+	FieldTypeNumber = "number"
+	// FieldTypeDate is the date following the layout of the field,
+	// converted into "2006-01-02"
+	FieldTypeDate = "date"
+	// FieldTypeDateTime is the time following the layout of the field,
+	// converted into "2006-01-02 15:04:05"
+	FieldTypeDateTime = "datetime"
+)
+
+var (
+	ErrUnknownRecordType = errors.New("unknown record type")
+	ErrInvalidField      = errors.New("invalid fixed-width field")
+	ErrMissingTrailer    = errors.New("missing trailer record")
+	ErrRecordCount       = errors.New("record count mismatch")
+	ErrControlTotal      = errors.New("control total mismatch")
+)
+
+// FixedWidthField is the field of a fixed-width record
+type FixedWidthField struct {
+	Name string `json:"name"`
+	// Start is the one based position of the first character
+	Start  int `json:"start"`
+	Length int `json:"length"`
+	// Type is one of [FieldTypeString] (default), [FieldTypeNumber],
+	// [FieldTypeDate] or [FieldTypeDateTime]
+	Type string `json:"type"`
+	// Decimals is the implied decimals of the number
+	Decimals int `json:"decimals"`
+	// Layout is the time layout of the dates, e.g. "20060102"
+	Layout string `json:"layout"`
+}
+
+// FixedWidthRecord is the record type, identified by its code
+type FixedWidthRecord struct {
+	Code   string            `json:"code"`
+	Fields []FixedWidthField `json:"fields"`
+}
+
+// FixedWidthLayout describes the records of a fixed-width file. The
+// detail records are read as the fields in order, while the header and
+// trailer records are only read to validate the file.
+type FixedWidthLayout struct {
+	// CodeStart and CodeLength locate the record type code. Without
+	// them, every line is a detail record.
+	CodeStart  int `json:"codeStart"`
+	CodeLength int `json:"codeLength"`
+
+	Header  FixedWidthRecord `json:"header"`
+	Detail  FixedWidthRecord `json:"detail"`
+	Trailer FixedWidthRecord `json:"trailer"`
+
+	// CountField is the trailer field holding the number of detail
+	// records
+	CountField string `json:"countField"`
+	// TotalField is the trailer field holding the control total, which
+	// is the sum of the AmountField of the detail records
+	TotalField  string `json:"totalField"`
+	AmountField string `json:"amountField"`
+}
+
+// IsZero reports whether the layout doesn't describe any detail field
+func (l FixedWidthLayout) IsZero() bool {
+	return len(l.Detail.Fields) == 0
+}
+
+// FixedWidthReader is the [RowReader] of a fixed-width file, returning
+// the detail records. The record count and the control total of the
+// trailer are validated at the end of the file.
+type FixedWidthReader struct {
+	scanner *bufio.Scanner
+	layout  FixedWidthLayout
+	line    int
+
+	count   int
+	total   decimal.Decimal
+	trailer bool
+	done    bool
+}
+
+// NewFixedWidthReader reads the fixed-width file following the layout
+func NewFixedWidthReader(file io.Reader, layout FixedWidthLayout) *FixedWidthReader {
+	return &FixedWidthReader{
+		scanner: bufio.NewScanner(file),
+		layout:  layout,
+	}
+}
+
+// Read returns the fields of the next detail record. Invalid records
+// are returned as [RowError], so the reading can continue.
+func (r *FixedWidthReader) Read() ([]string, error) {
+	for !r.done && r.scanner.Scan() {
+		r.line++
+		text := strings.TrimRight(r.scanner.Text(), "\r")
+		if strings.TrimSpace(text) == "" {
+			continue
+		}
+
+		line := []rune(text)
+		switch code := r.code(line); {
+		case r.layout.CodeLength == 0 || code == r.layout.Detail.Code:
+			return r.detail(line)
+		case r.layout.Header.Code != "" && code == r.layout.Header.Code:
+			if _, err := r.fields(line, r.layout.Header.Fields); err != nil {
+				return nil, &RowError{Line: r.line, Err: err}
+			}
+		case r.layout.Trailer.Code != "" && code == r.layout.Trailer.Code:
+			r.trailer, r.done = true, true
+			if err := r.validate(line); err != nil {
+				return nil, &RowError{Line: r.line, Err: err}
+			}
+		default:
+			return nil, &RowError{Line: r.line, Err: fmt.Errorf("%q is %w", code, ErrUnknownRecordType)}
+		}
+	}
+
+	if err := r.scanner.Err(); err != nil {
+		return nil, err
+	}
+	if !r.trailer && r.layout.Trailer.Code != "" {
+		r.trailer = true
+		return nil, &RowError{Line: r.line, Err: ErrMissingTrailer}
+	}
+	return nil, io.EOF
+}
+
+func (r *FixedWidthReader) Line() int {
+	return r.line
+}
+
+// code returns the record type code of the line
+func (r *FixedWidthReader) code(line []rune) string {
+	if r.layout.CodeLength == 0 {
+		return ""
+	}
+	code, _ := substring(line, r.layout.CodeStart, r.layout.CodeLength)
+	return code
+}
+
+func (r *FixedWidthReader) detail(line []rune) ([]string, error) {
+	r.count++
+	record, err := r.fields(line, r.layout.Detail.Fields)
+	if err != nil {
+		return nil, &RowError{Line: r.line, Err: err}
+	}
+
+	for i, field := range r.layout.Detail.Fields {
+		if field.Name != r.layout.AmountField || r.layout.AmountField == "" {
+			continue
+		}
+		amount, err := decimal.Parse(record[i])
+		if err != nil {
+			return nil, &RowError{Line: r.line, Err: fmt.Errorf("%s: %w", field.Name, err)}
+		}
+		if r.total, err = r.total.Add(amount); err != nil {
+			return nil, &RowError{Line: r.line, Err: err}
+		}
+	}
+
+	return record, nil
+}
+
+// validate validates the record count and the control total of the
+// trailer against the detail records read
+func (r *FixedWidthReader) validate(line []rune) error {
+	record, err := r.fields(line, r.layout.Trailer.Fields)
+	if err != nil {
+		return err
+	}
+
+	for i, field := range r.layout.Trailer.Fields {
+		switch field.Name {
+		case "":
+		case r.layout.CountField:
+			count, err := strconv.Atoi(record[i])
+			if err != nil {
+				return fmt.Errorf("%s: %w", field.Name, err)
+			}
+			if count != r.count {
+				return fmt.Errorf("%w: trailer has %d, file has %d", ErrRecordCount, count, r.count)
+			}
+		case r.layout.TotalField:
+			total, err := decimal.Parse(record[i])
+			if err != nil {
+				return fmt.Errorf("%s: %w", field.Name, err)
+			}
+			if total.Cmp(r.total) != 0 {
+				return fmt.Errorf("%w: trailer has %v, file has %v", ErrControlTotal, total, r.total)
+			}
+		}
+	}
+
+	return nil
+}
+
+func (r *FixedWidthReader) fields(line []rune, fields []FixedWidthField) ([]string, error) {
+	record := make([]string, len(fields))
+	for i, field := range fields {
+		value, ok := substring(line, field.Start, field.Length)
+		if !ok {
+			return nil, fmt.Errorf("%s: %w position %d-%d", field.Name, ErrInvalidField, field.Start, field.Start+field.Length-1)
+		}
+
+		var err error
+		if record[i], err = field.convert(value); err != nil {
+			return nil, fmt.Errorf("%s: %w", field.Name, err)
+		}
+	}
+	return record, nil
+}
+
+// substring returns the characters at the one based start, where the
+// trailing padding may be missing, e.g. trimmed by the editors
+func substring(line []rune, start, length int) (string, bool) {
+	if start < 1 || length < 0 {
+		return "", false
+	}
+	begin, end := min(start-1, len(line)), min(start-1+length, len(line))
+	return string(line[begin:end]), true
+}
+
+func (f FixedWidthField) convert(value string) (string, error) {
+	value = strings.TrimSpace(value)
+
+	switch f.Type {
+	case "", FieldTypeString:
+		return value, nil
+	case FieldTypeNumber:
+		return impliedDecimal(value, f.Decimals)
+	case FieldTypeDate, FieldTypeDateTime:
+		date, err := time.Parse(f.Layout, value)
+		if err != nil {
+			return "", err
+		}
+		if f.Type == FieldTypeDate {
+			return date.Format(time.DateOnly), nil
+		}
+		return date.Format(time.DateTime), nil
+	default:
+		return "", fmt.Errorf("type %q is %w", f.Type, ErrInvalidField)
+	}
+}
+
+// impliedDecimal places the decimal point of the number, where the sign
+// may lead or trail, e.g. "0000012345-" of 2 decimals is "-123.45"
+func impliedDecimal(value string, decimals int) (string, error) {
+	sign := ""
+	switch {
+	case strings.HasPrefix(value, "-"), strings.HasPrefix(value, "+"):
+		sign, value = value[:1], strings.TrimSpace(value[1:])
+	case strings.HasSuffix(value, "-"), strings.HasSuffix(value, "+"):
+		sign, value = value[len(value)-1:], strings.TrimSpace(value[:len(value)-1])
+	}
+	if sign == "+" {
+		sign = ""
+	}
+
+	if value == "" || strings.IndexFunc(value, func(r rune) bool { return r < '0' || r > '9' }) >= 0 {
+		return "", fmt.Errorf("number %q is %w", value, ErrInvalidField)
+	}
+
+	if decimals > 0 {
+		value = strings.Repeat("0", max(decimals+1-len(value), 0)) + value
+		value = value[:len(value)-decimals] + "." + value[len(value)-decimals:]
+	}
+
+	amount, err := decimal.Parse(sign + value)
+	if err != nil {
+		return "", err
+	}
+	return amount.String(), nil
+}
@@ -0,0 +1,118 @@
+package csvparser_test
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	csvparser "github.com/rickyson96/amartha-reconciliation-service/internal/csv_parser"
+)
+
+var fixedWidthLayout = csvparser.FixedWidthLayout{
+	CodeStart:  1,
+	CodeLength: 1,
+	Header: csvparser.FixedWidthRecord{Code: "H", Fields: []csvparser.FixedWidthField{
+		{Name: "date", Start: 2, Length: 8, Type: csvparser.FieldTypeDate, Layout: "20060102"},
+	}},
+	Detail: csvparser.FixedWidthRecord{Code: "D", Fields: []csvparser.FixedWidthField{
+		{Name: "id", Start: 2, Length: 6},
+		{Name: "amount", Start: 8, Length: 11, Type: csvparser.FieldTypeNumber, Decimals: 2},
+		{Name: "date", Start: 19, Length: 6, Type: csvparser.FieldTypeDate, Layout: "060102"},
+		{Name: "description", Start: 25, Length: 10},
+	}},
+	Trailer: csvparser.FixedWidthRecord{Code: "T", Fields: []csvparser.FixedWidthField{
+		{Name: "count", Start: 2, Length: 4},
+		{Name: "total", Start: 6, Length: 11, Type: csvparser.FieldTypeNumber, Decimals: 2},
+	}},
+	CountField:  "count",
+	TotalField:  "total",
+	AmountField: "amount",
+}
+
+const fixedWidth = `H20250310
+DB001  00000010000250310TRANSFER
+DB002  0000000150-250312ADMIN FEE
+
+T000200000009850
+`
+
+func TestFixedWidthReader(t *testing.T) {
+	reader := csvparser.NewFixedWidthReader(strings.NewReader(fixedWidth), fixedWidthLayout)
+
+	var got [][]string
+	var lines []int
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("unwanted error: %v", err)
+		}
+		got = append(got, record)
+		lines = append(lines, reader.Line())
+	}
+
+	want := [][]string{
+		{"B001", "100.00", "2025-03-10", "TRANSFER"},
+		{"B002", "-1.50", "2025-03-12", "ADMIN FEE"},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Read() mismatch, (-want,+got):\n%s", diff)
+	}
+	if diff := cmp.Diff([]int{2, 3}, lines); diff != "" {
+		t.Errorf("lines mismatch, (-want,+got):\n%s", diff)
+	}
+}
+
+func TestFixedWidthReader_Invalid(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		wantErr error
+	}{
+		{
+			name:    "record count",
+			input:   "DB001  00000010000250310\nT000200000010000\n",
+			wantErr: csvparser.ErrRecordCount,
+		},
+		{
+			name:    "control total",
+			input:   "DB001  00000010000250310\nT000100000009999\n",
+			wantErr: csvparser.ErrControlTotal,
+		},
+		{
+			name:    "missing trailer",
+			input:   "DB001  00000010000250310\n",
+			wantErr: csvparser.ErrMissingTrailer,
+		},
+		{
+			name:    "unknown record type",
+			input:   "XB001\n",
+			wantErr: csvparser.ErrUnknownRecordType,
+		},
+		{
+			name:    "invalid number",
+			input:   "DB001  000000100X0250310\n",
+			wantErr: csvparser.ErrInvalidField,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			reader := csvparser.NewFixedWidthReader(strings.NewReader(test.input), fixedWidthLayout)
+
+			var err error
+			for err == nil {
+				_, err = reader.Read()
+			}
+
+			var rowErr *csvparser.RowError
+			if !errors.As(err, &rowErr) || !errors.Is(err, test.wantErr) {
+				t.Errorf("Read() should fail with RowError of %v, got %v", test.wantErr, err)
+			}
+		})
+	}
+}
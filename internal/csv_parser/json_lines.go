@@ -0,0 +1,150 @@
+package csvparser
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+var ErrInvalidPath = errors.New("invalid json path")
+
+// JSONLinesReader is the [RowReader] of newline delimited json, where
+// the record is the values at the paths of each line, e.g. "$.id" and
+// "$.amount.value". Missing and null values are empty.
+type JSONLinesReader struct {
+	scanner *bufio.Scanner
+	paths   [][]string
+	line    int
+}
+
+// NewJSONLinesReader reads the json lines, picking the values at the
+// paths. The paths are dot separated keys and array indexes, e.g.
+// "$.entries[0].amount".
+func NewJSONLinesReader(file io.Reader, paths []string) (*JSONLinesReader, error) {
+	parsed := make([][]string, len(paths))
+	for i, path := range paths {
+		var err error
+		if parsed[i], err = parsePath(path); err != nil {
+			return nil, err
+		}
+	}
+
+	scanner := bufio.NewScanner(file)
+	// api exports may have long lines, e.g. with metadata
+	scanner.Buffer(nil, 16*1024*1024)
+
+	return &JSONLinesReader{scanner: scanner, paths: parsed}, nil
+}
+
+// parsePath splits the path into the keys and indexes, e.g.
+// ["entries", "0", "amount"] for "$.entries[0].amount"
+func parsePath(path string) ([]string, error) {
+	rest, ok := strings.CutPrefix(path, "$")
+	if !ok {
+		return nil, fmt.Errorf("%q is %w, should start with $", path, ErrInvalidPath)
+	}
+
+	var keys []string
+	for rest != "" {
+		switch rest[0] {
+		case '.':
+			end := strings.IndexAny(rest[1:], ".[") + 1
+			if end == 0 {
+				end = len(rest)
+			}
+			if end == 1 {
+				return nil, fmt.Errorf("%q is %w, empty key", path, ErrInvalidPath)
+			}
+			keys, rest = append(keys, rest[1:end]), rest[end:]
+		case '[':
+			end := strings.IndexByte(rest, ']')
+			if end < 0 {
+				return nil, fmt.Errorf("%q is %w, unclosed index", path, ErrInvalidPath)
+			}
+			if _, err := strconv.Atoi(rest[1:end]); err != nil {
+				return nil, fmt.Errorf("%q is %w, index should be a number", path, ErrInvalidPath)
+			}
+			keys, rest = append(keys, rest[1:end]), rest[end+1:]
+		default:
+			return nil, fmt.Errorf("%q is %w", path, ErrInvalidPath)
+		}
+	}
+	return keys, nil
+}
+
+// Read returns the values of the next non-empty line. Invalid json is
+// returned as [RowError], so the reading can continue.
+func (r *JSONLinesReader) Read() ([]string, error) {
+	for r.scanner.Scan() {
+		r.line++
+		line := bytes.TrimSpace(r.scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+
+		decoder := json.NewDecoder(bytes.NewReader(line))
+		// numbers are kept as is, e.g. the amount decimals
+		decoder.UseNumber()
+		var value any
+		if err := decoder.Decode(&value); err != nil {
+			return nil, &RowError{Line: r.line, Err: err}
+		}
+
+		record := make([]string, len(r.paths))
+		for i, path := range r.paths {
+			record[i] = jsonString(lookup(value, path))
+		}
+		return record, nil
+	}
+
+	if err := r.scanner.Err(); err != nil {
+		return nil, err
+	}
+	return nil, io.EOF
+}
+
+func (r *JSONLinesReader) Line() int {
+	return r.line
+}
+
+// lookup returns the value at the path, or nil when it's missing
+func lookup(value any, path []string) any {
+	for _, key := range path {
+		switch v := value.(type) {
+		case map[string]any:
+			value = v[key]
+		case []any:
+			i, _ := strconv.Atoi(key)
+			if i < 0 || i >= len(v) {
+				return nil
+			}
+			value = v[i]
+		default:
+			return nil
+		}
+	}
+	return value
+}
+
+// jsonString returns the text of the value, where objects and arrays are
+// kept as json
+func jsonString(value any) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case json.Number:
+		return v.String()
+	case bool:
+		return strconv.FormatBool(v)
+	default:
+		data, _ := json.Marshal(v)
+		return string(data)
+	}
+}
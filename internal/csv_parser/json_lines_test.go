@@ -0,0 +1,63 @@
+package csvparser_test
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	csvparser "github.com/rickyson96/amartha-reconciliation-service/internal/csv_parser"
+)
+
+func TestJSONLinesReader(t *testing.T) {
+	input := `{"id": 1, "amount": {"value": 10.50}, "tags": ["a", "b"], "paid": true}
+{"id": "2", "amount": null}
+{"id": 
+{"id": "4", "amount": {"value": "7"}, "tags": []}
+`
+	reader, err := csvparser.NewJSONLinesReader(strings.NewReader(input), []string{"$.id", "$.amount.value", "$.tags[1]", "$.paid", "$.amount"})
+	if err != nil {
+		t.Fatalf("NewJSONLinesReader failed: %v", err)
+	}
+
+	var got [][]string
+	var errLines []int
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		var rowErr *csvparser.RowError
+		if errors.As(err, &rowErr) {
+			errLines = append(errLines, rowErr.Line)
+			continue
+		}
+		if err != nil {
+			t.Fatalf("unwanted error: %v", err)
+		}
+		got = append(got, record)
+	}
+
+	want := [][]string{
+		{"1", "10.50", "b", "true", `{"value":10.50}`},
+		{"2", "", "", "", ""},
+		{"4", "7", "", "", `{"value":"7"}`},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Read() mismatch, (-want,+got):\n%s", diff)
+	}
+	if diff := cmp.Diff([]int{3}, errLines); diff != "" {
+		t.Errorf("error lines mismatch, (-want,+got):\n%s", diff)
+	}
+}
+
+func TestJSONLinesReader_InvalidPath(t *testing.T) {
+	for _, path := range []string{"id", "$..id", "$.tags[x]", "$.tags[0"} {
+		t.Run(path, func(t *testing.T) {
+			if _, err := csvparser.NewJSONLinesReader(strings.NewReader(""), []string{path}); !errors.Is(err, csvparser.ErrInvalidPath) {
+				t.Errorf("NewJSONLinesReader should fail with ErrInvalidPath, got %v", err)
+			}
+		})
+	}
+}
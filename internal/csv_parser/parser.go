@@ -2,20 +2,56 @@ package csvparser
 
 import (
 	"encoding/csv"
+	"fmt"
 	"io"
+	"strings"
 )
 
 type CSVParser[T any] struct {
-	csvReader     *csv.Reader
+	rows          RowReader
 	parser        func(data []string) (T, error)
 	filter        func(data T) bool
 	hasHeader     bool
 	hasReadHeader bool
+	trimSpace     bool
+}
+
+// RowError is the error of parsing a row into the data
+type RowError struct {
+	Line int
+	Err  error
+}
+
+func (e *RowError) Error() string {
+	return fmt.Sprintf("line %d: %v", e.Line, e.Err)
+}
+
+func (e *RowError) Unwrap() error {
+	return e.Err
+}
+
+// RowReader reads the raw records, e.g. csv lines or spreadsheet rows
+type RowReader interface {
+	// Read returns the next record, or [io.EOF] at the end
+	Read() ([]string, error)
+	// Line returns the line number of the record last read
+	Line() int
+}
+
+// csvRows is the [RowReader] of a csv file
+type csvRows struct {
+	*csv.Reader
+}
+
+func (r csvRows) Line() int {
+	line, _ := r.FieldPos(0)
+	return line
 }
 
 type CSVParserOptions struct {
 	ContainsHeader bool
 	FieldPerRow    int
+	Dialect
 }
 
 // NewCSVParser creates new csv_parser that helps parses into struct
@@ -25,31 +61,56 @@ func NewCSVParser[T any](csvFile io.Reader,
 	filter func(data T) bool,
 	options CSVParserOptions,
 ) *CSVParser[T] {
-	reader := csv.NewReader(csvFile)
+	reader := options.Dialect.newReader(csvFile)
 	reader.FieldsPerRecord = options.FieldPerRow
 
 	csvParser := &CSVParser[T]{
-		csvReader: reader,
+		rows:      csvRows{reader},
 		parser:    parser,
 		filter:    filter,
 		hasHeader: options.ContainsHeader,
+		trimSpace: options.TrimSpace,
 	}
 
 	return csvParser
 }
 
+// NewRowParser is [NewCSVParser] that reads the records out of the rows,
+// e.g. spreadsheet rows, so the same parser functions can be used
+func NewRowParser[T any](rows RowReader,
+	parser func(data []string) (T, error),
+	filter func(data T) bool,
+	containsHeader bool,
+) *CSVParser[T] {
+	return &CSVParser[T]{
+		rows:      rows,
+		parser:    parser,
+		filter:    filter,
+		hasHeader: containsHeader,
+	}
+}
+
+// Close closes the rows when they hold resources, e.g. [SQLRows], which
+// are left open when the reading stops early
+func (p *CSVParser[T]) Close() error {
+	if closer, ok := p.rows.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
+
 // Parse generates output based on the CSV being read.
 func (p *CSVParser[T]) Parse() ([]T, error) {
 	var result []T
 
 	if p.hasHeader {
 		// Read off the header
-		p.csvReader.Read()
+		p.rows.Read()
 		p.hasReadHeader = true
 	}
 
 	for {
-		data, err := p.csvReader.Read()
+		data, err := p.read()
 		if err == io.EOF {
 			break
 		}
@@ -60,7 +121,8 @@ func (p *CSVParser[T]) Parse() ([]T, error) {
 
 		parsedData, err := p.parser(data)
 		if err != nil {
-			return nil, err
+			line := p.rows.Line()
+			return nil, &RowError{Line: line, Err: err}
 		}
 
 		if p.filter(parsedData) {
@@ -71,6 +133,13 @@ func (p *CSVParser[T]) Parse() ([]T, error) {
 	return result, nil
 }
 
+// Row is the parsed data along with its raw record and line number
+type Row[T any] struct {
+	Line   int
+	Record []string
+	Data   T
+}
+
 // Read is used to read through the csv file one line at a time.
 //
 // This mainly utilizes underlying [csv.Reader.Read] method, while
@@ -82,23 +151,45 @@ func (p *CSVParser[T]) Parse() ([]T, error) {
 // It'll also skip the header according to [CSVParser.hasHeader].
 // It'll return [io.EOF] error when it reaches the last input
 func (p *CSVParser[T]) Read() (T, error) {
+	row, err := p.ReadRow()
+	return row.Data, err
+}
+
+// ReadRow is [CSVParser.Read] that also returns the raw record along
+// with its line number, e.g. to report problems of the file.
+func (p *CSVParser[T]) ReadRow() (Row[T], error) {
 	if p.hasHeader && !p.hasReadHeader {
-		p.csvReader.Read()
+		p.rows.Read()
 		p.hasReadHeader = true
 	}
 
-	data, err := p.csvReader.Read()
+	data, err := p.read()
 	if err != nil {
-		return *new(T), err
+		return Row[T]{}, err
 	}
 
+	line := p.rows.Line()
 	parsed, err := p.parser(data)
 	if err != nil {
-		return *new(T), err
+		return Row[T]{}, &RowError{Line: line, Err: err}
 	}
 
 	if !p.filter(parsed) {
-		return p.Read()
+		return p.ReadRow()
+	}
+
+	return Row[T]{Line: line, Record: data, Data: parsed}, nil
+}
+
+// read reads the next record, trimming the fields when asked
+func (p *CSVParser[T]) read() ([]string, error) {
+	data, err := p.rows.Read()
+	if err != nil || !p.trimSpace {
+		return data, err
+	}
+
+	for i := range data {
+		data[i] = strings.TrimSpace(data[i])
 	}
-	return parsed, nil
+	return data, nil
 }
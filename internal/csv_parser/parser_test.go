@@ -200,3 +200,150 @@ func TestCSVParser_Read(t *testing.T) {
 		})
 	}
 }
+
+func TestCSVParser_ReadRow(t *testing.T) {
+	buffer := bytes.NewBufferString("a,b\nc,d\ne,f\n\"g\nh\",i\nj,k")
+	p := csvparser.NewCSVParser(buffer, func(data []string) (TestModel, error) {
+		return TestModel{data[0], data[1]}, nil
+	}, func(data TestModel) bool {
+		return data.A != "e"
+	}, csvparser.CSVParserOptions{
+		ContainsHeader: true,
+		FieldPerRow:    2,
+	})
+
+	var got []csvparser.Row[TestModel]
+	for {
+		row, err := p.ReadRow()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("unwanted error: %v", err)
+		}
+		got = append(got, row)
+	}
+
+	want := []csvparser.Row[TestModel]{
+		{Line: 2, Record: []string{"c", "d"}, Data: TestModel{"c", "d"}},
+		{Line: 4, Record: []string{"g\nh", "i"}, Data: TestModel{"g\nh", "i"}},
+		{Line: 6, Record: []string{"j", "k"}, Data: TestModel{"j", "k"}},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("ReadRow() mismatch, (-want,+got):\n%s", diff)
+	}
+}
+
+func TestCSVParser_RowError(t *testing.T) {
+	errParse := errors.New("parse error")
+	buffer := bytes.NewBufferString("a,b\nc,d\ne,f\ng,h")
+	p := csvparser.NewCSVParser(buffer, func(data []string) (TestModel, error) {
+		if data[0] == "e" {
+			return TestModel{}, errParse
+		}
+		return TestModel{data[0], data[1]}, nil
+	}, func(data TestModel) bool {
+		return true
+	}, csvparser.CSVParserOptions{
+		ContainsHeader: true,
+		FieldPerRow:    2,
+	})
+
+	var lines []int
+	var rowErr *csvparser.RowError
+	for {
+		row, err := p.ReadRow()
+		if err == io.EOF {
+			break
+		}
+		if errors.As(err, &rowErr) {
+			if !errors.Is(err, errParse) {
+				t.Errorf("RowError should wrap the parse error, got %v", err)
+			}
+			lines = append(lines, -rowErr.Line)
+			continue
+		}
+		if err != nil {
+			t.Fatalf("unwanted error: %v", err)
+		}
+		lines = append(lines, row.Line)
+	}
+
+	// reading continues after the failing row
+	if diff := cmp.Diff([]int{2, -3, 4}, lines); diff != "" {
+		t.Errorf("lines mismatch, (-want,+got):\n%s", diff)
+	}
+}
+
+func TestCSVParser_Dialect(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		dialect csvparser.Dialect
+		want    [][]string
+		wantErr error
+	}{
+		{
+			name:  "strips utf-8 bom",
+			input: "\xEF\xBB\xBFa,b\nc,d",
+			want:  [][]string{{"a", "b"}, {"c", "d"}},
+		},
+		{
+			name:    "semicolon delimiter with comment",
+			input:   "# exported\na;b\nc;d",
+			dialect: csvparser.Dialect{Delimiter: ";", Comment: "#"},
+			want:    [][]string{{"a", "b"}, {"c", "d"}},
+		},
+		{
+			name:    "detects delimiter",
+			input:   "a;b\nc,d;e",
+			dialect: csvparser.Dialect{Delimiter: csvparser.DelimiterAuto},
+			want:    [][]string{{"a", "b"}, {"c,d", "e"}},
+		},
+		{
+			name:    "lazy quotes and trimming",
+			input:   ` a "b" , c ` + "\n" + `d,e`,
+			dialect: csvparser.Dialect{LazyQuotes: true, TrimSpace: true},
+			want:    [][]string{{`a "b"`, "c"}, {"d", "e"}},
+		},
+		{
+			name:    "windows-1252",
+			input:   "caf\xE9,\x80 5\n\xEF\xBB\xBF,b",
+			dialect: csvparser.Dialect{Charset: csvparser.CharsetWindows1252},
+			want:    [][]string{{"café", "€ 5"}, {"ï»¿", "b"}},
+		},
+		{
+			name:    "auto charset keeps valid utf-8",
+			input:   "\xEF\xBB\xBFcafé,caf\xE9\n\x93q\x94,b",
+			dialect: csvparser.Dialect{Charset: csvparser.CharsetAuto},
+			want:    [][]string{{"café", "café"}, {"“q”", "b"}},
+		},
+		{
+			name:    "unknown charset",
+			input:   "a,b",
+			dialect: csvparser.Dialect{Charset: "ebcdic"},
+			wantErr: csvparser.ErrUnknownCharset,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			p := csvparser.NewCSVParser(bytes.NewBufferString(test.input), func(data []string) ([]string, error) {
+				return data, nil
+			}, func(data []string) bool {
+				return true
+			}, csvparser.CSVParserOptions{
+				FieldPerRow: 2,
+				Dialect:     test.dialect,
+			})
+
+			got, err := p.Parse()
+			if diff := cmp.Diff(test.wantErr, err, cmpopts.EquateErrors()); diff != "" {
+				t.Errorf("err mismatch, (-want,+got):\n%s", diff)
+			}
+			if diff := cmp.Diff(test.want, got); diff != "" {
+				t.Errorf("Parse() mismatch, (-want,+got):\n%s", diff)
+			}
+		})
+	}
+}
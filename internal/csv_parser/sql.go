@@ -0,0 +1,91 @@
+package csvparser
+
+import (
+	"database/sql"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+)
+
+// SQLRows is the [RowReader] of a query result, where the record is the
+// selected columns and the line is the row number
+type SQLRows struct {
+	rows   *sql.Rows
+	values []any
+	line   int
+}
+
+// NewSQLRows reads the rows of the query. The rows are closed once all of
+// them are read, otherwise they should be closed by the caller.
+func NewSQLRows(rows *sql.Rows) (*SQLRows, error) {
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+
+	return &SQLRows{rows: rows, values: make([]any, len(columns))}, nil
+}
+
+// Read returns the columns of the next row as text, see [sqlString]
+func (r *SQLRows) Read() ([]string, error) {
+	if !r.rows.Next() {
+		if err := r.rows.Err(); err != nil {
+			return nil, err
+		}
+		return nil, io.EOF
+	}
+	r.line++
+
+	pointers := make([]any, len(r.values))
+	for i := range r.values {
+		pointers[i] = &r.values[i]
+	}
+	if err := r.rows.Scan(pointers...); err != nil {
+		return nil, &RowError{Line: r.line, Err: err}
+	}
+
+	record := make([]string, len(r.values))
+	for i, v := range r.values {
+		record[i] = sqlString(v)
+	}
+	return record, nil
+}
+
+// Columns returns the number of the selected columns
+func (r *SQLRows) Columns() int {
+	return len(r.values)
+}
+
+func (r *SQLRows) Line() int {
+	return r.line
+}
+
+// Close closes the rows, e.g. when the reading stops early
+func (r *SQLRows) Close() error {
+	return r.rows.Close()
+}
+
+// sqlString returns the text of the column value. Times are kept at
+// their wall clock as "2006-01-02 15:04:05", as the csv times, and null
+// is empty.
+func sqlString(value any) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case []byte:
+		return string(v)
+	case int64:
+		return strconv.FormatInt(v, 10)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(v)
+	case time.Time:
+		return v.Format(time.DateTime)
+	default:
+		return fmt.Sprint(v)
+	}
+}
@@ -0,0 +1,354 @@
+package csvparser
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	ErrSheetNotFound = errors.New("sheet not found")
+	ErrInvalidCell   = errors.New("invalid cell reference")
+)
+
+// XLSXOptions selects the rows of the spreadsheet
+type XLSXOptions struct {
+	// Sheet is the sheet name, defaults to the first sheet
+	Sheet string `json:"sheet"`
+	// SkipRows is the number of spreadsheet rows above the header, e.g.
+	// the bank name and account number
+	SkipRows int `json:"skipRows"`
+	// FooterRows is the number of non-empty rows to be skipped at the
+	// end, e.g. the summary of the statement
+	FooterRows int `json:"footerRows"`
+	// FieldPerRow pads the rows with empty cells, as the trailing empty
+	// cells aren't stored
+	FieldPerRow int `json:"-"`
+	// DateColumns are the zero based columns whose numbers are Excel
+	// serial dates, converted into "2006-01-02", or "2006-01-02 15:04:05"
+	// when they have time.
+	DateColumns []int `json:"-"`
+}
+
+// XLSXReader is the [RowReader] of a spreadsheet sheet. Empty rows are
+// skipped, and the line is the spreadsheet row number.
+type XLSXReader struct {
+	decoder *xml.Decoder
+	strings []string
+	epoch   time.Time
+	options XLSXOptions
+	// pending is the rows read ahead to skip the footer
+	pending []xlsxRecord
+	line    int
+	// index is the row number of the row last decoded
+	index int
+}
+
+type xlsxRecord struct {
+	line   int
+	record []string
+}
+
+type (
+	xlsxWorkbook struct {
+		Properties struct {
+			Date1904 bool `xml:"date1904,attr"`
+		} `xml:"workbookPr"`
+		Sheets []xlsxSheet `xml:"sheets>sheet"`
+	}
+
+	xlsxSheet struct {
+		Name string `xml:"name,attr"`
+		// ID is the relationship id of the sheet part
+		ID string `xml:"http://schemas.openxmlformats.org/officeDocument/2006/relationships id,attr"`
+	}
+
+	xlsxRelationships struct {
+		Relationships []struct {
+			ID     string `xml:"Id,attr"`
+			Target string `xml:"Target,attr"`
+		} `xml:"Relationship"`
+	}
+
+	// xlsxText is the plain or rich text, which is split into runs
+	xlsxText struct {
+		Text string `xml:"t"`
+		Runs []struct {
+			Text string `xml:"t"`
+		} `xml:"r"`
+	}
+
+	xlsxRow struct {
+		Index int `xml:"r,attr"`
+		Cells []struct {
+			Ref    string   `xml:"r,attr"`
+			Type   string   `xml:"t,attr"`
+			Value  string   `xml:"v"`
+			Inline xlsxText `xml:"is"`
+		} `xml:"c"`
+	}
+)
+
+func (t xlsxText) String() string {
+	s := t.Text
+	for _, r := range t.Runs {
+		s += r.Text
+	}
+	return s
+}
+
+// NewXLSXReader reads the sheet of the spreadsheet. The file is read
+// into the memory as the spreadsheet is a zip archive, while the sheet
+// rows are streamed.
+func NewXLSXReader(file io.Reader, options XLSXOptions) (*XLSXReader, error) {
+	data, err := io.ReadAll(file)
+	if err != nil {
+		return nil, err
+	}
+
+	archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, fmt.Errorf("xlsx: %w", err)
+	}
+
+	var workbook xlsxWorkbook
+	if err := decodeXLSXPart(archive, "xl/workbook.xml", &workbook); err != nil {
+		return nil, err
+	}
+	var rels xlsxRelationships
+	if err := decodeXLSXPart(archive, "xl/_rels/workbook.xml.rels", &rels); err != nil {
+		return nil, err
+	}
+
+	sheetPath, err := workbook.sheetPath(rels, options.Sheet)
+	if err != nil {
+		return nil, err
+	}
+
+	sharedStrings, err := readSharedStrings(archive)
+	if err != nil {
+		return nil, err
+	}
+
+	sheet, err := archive.Open(sheetPath)
+	if err != nil {
+		return nil, fmt.Errorf("xlsx: %w", err)
+	}
+
+	epoch := time.Date(1899, 12, 30, 0, 0, 0, 0, time.UTC)
+	if workbook.Properties.Date1904 {
+		epoch = time.Date(1904, 1, 1, 0, 0, 0, 0, time.UTC)
+	}
+
+	return &XLSXReader{
+		decoder: xml.NewDecoder(sheet),
+		strings: sharedStrings,
+		epoch:   epoch,
+		options: options,
+	}, nil
+}
+
+func decodeXLSXPart(archive *zip.Reader, name string, v any) error {
+	part, err := archive.Open(name)
+	if err != nil {
+		return fmt.Errorf("xlsx: %w", err)
+	}
+	defer part.Close()
+
+	if err := xml.NewDecoder(part).Decode(v); err != nil {
+		return fmt.Errorf("xlsx: %s: %w", name, err)
+	}
+	return nil
+}
+
+// sheetPath returns the path of the sheet within the archive, defaulting
+// to the first sheet
+func (w xlsxWorkbook) sheetPath(rels xlsxRelationships, name string) (string, error) {
+	i := 0
+	if name != "" {
+		i = slices.IndexFunc(w.Sheets, func(s xlsxSheet) bool { return s.Name == name })
+	}
+	if i < 0 || i >= len(w.Sheets) {
+		return "", fmt.Errorf("xlsx: %q is %w", name, ErrSheetNotFound)
+	}
+
+	for _, rel := range rels.Relationships {
+		if rel.ID != w.Sheets[i].ID {
+			continue
+		}
+
+		// targets are relative to the workbook, unless they're absolute
+		if strings.HasPrefix(rel.Target, "/") {
+			return strings.TrimPrefix(rel.Target, "/"), nil
+		}
+		return path.Join("xl", rel.Target), nil
+	}
+
+	return "", fmt.Errorf("xlsx: %q is %w", w.Sheets[i].Name, ErrSheetNotFound)
+}
+
+func readSharedStrings(archive *zip.Reader) ([]string, error) {
+	var sst struct {
+		Items []xlsxText `xml:"si"`
+	}
+	err := decodeXLSXPart(archive, "xl/sharedStrings.xml", &sst)
+	if errors.Is(err, fs.ErrNotExist) {
+		// spreadsheets without any text
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	texts := make([]string, len(sst.Items))
+	for i, item := range sst.Items {
+		texts[i] = item.String()
+	}
+	return texts, nil
+}
+
+// Read returns the next non-empty row, skipping the rows above the
+// header and the footer rows
+func (r *XLSXReader) Read() ([]string, error) {
+	for len(r.pending) <= r.options.FooterRows {
+		record, err := r.readRow()
+		if err == io.EOF {
+			// the pending rows are the footer
+			return nil, io.EOF
+		}
+		if err != nil {
+			return nil, err
+		}
+		r.pending = append(r.pending, record)
+	}
+
+	record := r.pending[0]
+	r.pending = r.pending[1:]
+	r.line = record.line
+	return record.record, nil
+}
+
+func (r *XLSXReader) Line() int {
+	return r.line
+}
+
+// readRow reads the next non-empty row of the sheet
+func (r *XLSXReader) readRow() (xlsxRecord, error) {
+	for {
+		token, err := r.decoder.Token()
+		if err != nil {
+			return xlsxRecord{}, err
+		}
+
+		start, ok := token.(xml.StartElement)
+		if !ok || start.Name.Local != "row" {
+			continue
+		}
+
+		var row xlsxRow
+		if err := r.decoder.DecodeElement(&row, &start); err != nil {
+			return xlsxRecord{}, err
+		}
+		// the row number is optional, following the previous row
+		if row.Index == 0 {
+			row.Index = r.index + 1
+		}
+		r.index = row.Index
+		if row.Index <= r.options.SkipRows {
+			continue
+		}
+
+		record, err := r.record(row)
+		if err != nil {
+			return xlsxRecord{}, fmt.Errorf("row %d: %w", row.Index, err)
+		}
+		if !slices.ContainsFunc(record, func(s string) bool { return s != "" }) {
+			continue
+		}
+
+		return xlsxRecord{line: row.Index, record: record}, nil
+	}
+}
+
+func (r *XLSXReader) record(row xlsxRow) ([]string, error) {
+	record := make([]string, 0, r.options.FieldPerRow)
+	for _, cell := range row.Cells {
+		column := len(record)
+		if cell.Ref != "" {
+			var err error
+			if column, err = cellColumn(cell.Ref); err != nil {
+				return nil, err
+			}
+		}
+		for len(record) <= column {
+			record = append(record, "")
+		}
+
+		switch cell.Type {
+		case "s":
+			i, err := strconv.Atoi(cell.Value)
+			if err != nil || i < 0 || i >= len(r.strings) {
+				return nil, fmt.Errorf("%s: invalid shared string %q", cell.Ref, cell.Value)
+			}
+			record[column] = r.strings[i]
+		case "inlineStr":
+			record[column] = cell.Inline.String()
+		case "", "n":
+			record[column] = r.number(column, cell.Value)
+		default:
+			// str, b and e are stored as is
+			record[column] = cell.Value
+		}
+	}
+
+	for len(record) < r.options.FieldPerRow {
+		record = append(record, "")
+	}
+
+	return record, nil
+}
+
+// number converts the serial date of the date columns, and rounds the
+// other numbers to 15 significant digits like Excel does, as the floating
+// point text, e.g. "1234.5599999999999", is stored for 1234.56
+func (r *XLSXReader) number(column int, value string) string {
+	serial, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return value
+	}
+
+	if !slices.Contains(r.options.DateColumns, column) {
+		rounded, _ := strconv.ParseFloat(strconv.FormatFloat(serial, 'g', 15, 64), 64)
+		return strconv.FormatFloat(rounded, 'f', -1, 64)
+	}
+
+	// rounded to the second, as the time is stored as fraction of a day
+	date := r.epoch.Add(time.Duration(serial * float64(24*time.Hour))).Round(time.Second)
+	if date.Equal(date.Truncate(24 * time.Hour)) {
+		return date.Format(time.DateOnly)
+	}
+	return date.Format(time.DateTime)
+}
+
+// cellColumn returns the zero based column of the cell reference, e.g.
+// 2 for "C5"
+func cellColumn(ref string) (int, error) {
+	column := 0
+	i := 0
+	for ; i < len(ref) && ref[i] >= 'A' && ref[i] <= 'Z'; i++ {
+		column = column*26 + int(ref[i]-'A'+1)
+	}
+	if i == 0 {
+		return 0, fmt.Errorf("%q is %w", ref, ErrInvalidCell)
+	}
+	return column - 1, nil
+}
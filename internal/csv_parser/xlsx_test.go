@@ -0,0 +1,134 @@
+package csvparser_test
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	csvparser "github.com/rickyson96/amartha-reconciliation-service/internal/csv_parser"
+)
+
+// newXLSX builds the spreadsheet out of the sheets xml, keyed by the
+// sheet name
+func newXLSX(t *testing.T, sharedStrings string, sheets ...[2]string) *bytes.Buffer {
+	t.Helper()
+
+	workbook := `<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships"><sheets>`
+	rels := `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">`
+	parts := map[string]string{}
+	for i, sheet := range sheets {
+		id := string(rune('1' + i))
+		workbook += `<sheet name="` + sheet[0] + `" sheetId="` + id + `" r:id="rId` + id + `"/>`
+		rels += `<Relationship Id="rId` + id + `" Target="worksheets/sheet` + id + `.xml"/>`
+		parts["xl/worksheets/sheet"+id+".xml"] = `<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>` + sheet[1] + `</sheetData></worksheet>`
+	}
+	parts["xl/workbook.xml"] = workbook + `</sheets></workbook>`
+	parts["xl/_rels/workbook.xml.rels"] = rels + `</Relationships>`
+	parts["xl/sharedStrings.xml"] = `<sst xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">` + sharedStrings + `</sst>`
+
+	buffer := &bytes.Buffer{}
+	w := zip.NewWriter(buffer)
+	for name, content := range parts {
+		f, err := w.Create(name)
+		if err != nil {
+			t.Fatalf("failed creating %s: %v", name, err)
+		}
+		io.WriteString(f, content)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("failed writing xlsx: %v", err)
+	}
+	return buffer
+}
+
+func TestXLSXReader(t *testing.T) {
+	sharedStrings := `<si><t>Bank Statement</t></si><si><t>id</t></si><si><t>amount</t></si><si><t>date</t></si>` +
+		`<si><r><t>Total</t></r><r><t> Debit</t></r></si>`
+	statement := `<row r="1"><c r="A1" t="s"><v>0</v></c></row>` +
+		`<row r="3"><c r="A3" t="s"><v>1</v></c><c r="B3" t="s"><v>2</v></c><c r="C3" t="s"><v>3</v></c></row>` +
+		`<row r="4"><c r="A4" t="inlineStr"><is><t>a1</t></is></c><c r="B4"><v>-10.5</v></c><c r="C4"><v>45726</v></c></row>` +
+		`<row r="5"></row>` +
+		`<row r="6"><c r="A6" t="str"><v>a2</v></c><c r="C6"><v>45727.5</v></c></row>` +
+		`<row r="8"><c r="A8" t="s"><v>4</v></c><c r="B8"><v>-10.5</v></c></row>`
+	file := newXLSX(t, sharedStrings, [2]string{"Summary", `<row r="1"><c r="A1"><v>1</v></c></row>`}, [2]string{"Statement", statement})
+
+	rows, err := csvparser.NewXLSXReader(file, csvparser.XLSXOptions{
+		Sheet:       "Statement",
+		SkipRows:    2,
+		FooterRows:  1,
+		FieldPerRow: 3,
+		DateColumns: []int{2},
+	})
+	if err != nil {
+		t.Fatalf("NewXLSXReader failed: %v", err)
+	}
+
+	p := csvparser.NewRowParser(rows, func(data []string) ([]string, error) {
+		return data, nil
+	}, func(data []string) bool {
+		return true
+	}, true)
+
+	var got []csvparser.Row[[]string]
+	for {
+		row, err := p.ReadRow()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("unwanted error: %v", err)
+		}
+		got = append(got, row)
+	}
+
+	want := []csvparser.Row[[]string]{
+		{Line: 4, Record: []string{"a1", "-10.5", "2025-03-10"}, Data: []string{"a1", "-10.5", "2025-03-10"}},
+		{Line: 6, Record: []string{"a2", "", "2025-03-11 12:00:00"}, Data: []string{"a2", "", "2025-03-11 12:00:00"}},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("ReadRow() mismatch, (-want,+got):\n%s", diff)
+	}
+}
+
+func TestXLSXReader_Numbers(t *testing.T) {
+	tests := []struct {
+		value string
+		want  string
+	}{
+		{"1234.5599999999999", "1234.56"},
+		{"0.30000000000000004", "0.3"},
+		{"-10.5", "-10.5"},
+		{"1000", "1000"},
+		{"1.5E-3", "0.0015"},
+		{"123456789012", "123456789012"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.value, func(t *testing.T) {
+			file := newXLSX(t, "", [2]string{"Sheet1", `<row r="1"><c r="A1"><v>` + test.value + `</v></c></row>`})
+			rows, err := csvparser.NewXLSXReader(file, csvparser.XLSXOptions{})
+			if err != nil {
+				t.Fatalf("NewXLSXReader failed: %v", err)
+			}
+
+			record, err := rows.Read()
+			if err != nil {
+				t.Fatalf("unwanted error: %v", err)
+			}
+			if diff := cmp.Diff([]string{test.want}, record); diff != "" {
+				t.Errorf("Read() mismatch, (-want,+got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestXLSXReader_SheetNotFound(t *testing.T) {
+	file := newXLSX(t, "", [2]string{"Sheet1", ""})
+	_, err := csvparser.NewXLSXReader(file, csvparser.XLSXOptions{Sheet: "Statement"})
+	if !errors.Is(err, csvparser.ErrSheetNotFound) {
+		t.Errorf("want ErrSheetNotFound, got %v", err)
+	}
+}
@@ -0,0 +1,118 @@
+package duplicates
+
+import (
+	"slices"
+
+	csvparser "github.com/rickyson96/amartha-reconciliation-service/internal/csv_parser"
+)
+
+// Duplicate is the rows of a file sharing the same identifier
+type Duplicate struct {
+	ID string
+	// Lines is the line number of the rows, the first one is the row
+	// kept on deduplication
+	Lines []int
+	// Exact tells whether all of the rows are exactly the same, otherwise
+	// they're different rows with conflicting identifier
+	Exact bool
+	// Copies is the line number of the rows exactly the same as the first
+	// one, which are the rows dropped on deduplication
+	Copies []int
+}
+
+// Report is the duplicates found on the files, keyed by the file
+type Report map[string][]Duplicate
+
+// Count counts the duplicate rows, excluding the first row of each
+// identifier
+func (r Report) Count() int {
+	count := 0
+	for _, dups := range r {
+		for _, d := range dups {
+			count += len(d.Lines) - 1
+		}
+	}
+	return count
+}
+
+// CopyCount counts the rows exactly the same as the first row of their
+// identifier, see [Duplicate.Copies]
+func (r Report) CopyCount() int {
+	count := 0
+	for _, dups := range r {
+		for _, d := range dups {
+			count += len(d.Copies)
+		}
+	}
+	return count
+}
+
+// Detector detects the rows of a file sharing the same identifier
+type Detector[T any] struct {
+	id     func(T) string
+	first  map[string][]string
+	groups map[string]*Duplicate
+	order  []string
+}
+
+func NewDetector[T any](id func(T) string) *Detector[T] {
+	return &Detector[T]{
+		id:     id,
+		first:  make(map[string][]string),
+		groups: make(map[string]*Duplicate),
+	}
+}
+
+// Add records the row, and reports whether it's exactly the same as the
+// first row of its identifier. Rows with the identifier of a previous row
+// but different content are recorded as conflicting instead.
+func (d *Detector[T]) Add(row csvparser.Row[T]) bool {
+	id := d.id(row.Data)
+
+	first, ok := d.first[id]
+	if !ok {
+		d.first[id] = row.Record
+		d.groups[id] = &Duplicate{ID: id, Lines: []int{row.Line}, Exact: true}
+		return false
+	}
+
+	group := d.groups[id]
+	if len(group.Lines) == 1 {
+		d.order = append(d.order, id)
+	}
+	group.Lines = append(group.Lines, row.Line)
+	copied := slices.Equal(first, row.Record)
+	if copied {
+		group.Copies = append(group.Copies, row.Line)
+	}
+	group.Exact = group.Exact && copied
+	return copied
+}
+
+// Duplicates returns the duplicates in the order they're found
+func (d *Detector[T]) Duplicates() []Duplicate {
+	var dups []Duplicate
+	for _, id := range d.order {
+		dups = append(dups, *d.groups[id])
+	}
+	return dups
+}
+
+// Reader wraps the row reader to detect duplicates while reading, and
+// skips the rows exactly the same as a previous row when dedupe is set.
+// Conflicting rows are always read, so they go through the matching.
+func (d *Detector[T]) Reader(read func() (csvparser.Row[T], error), dedupe bool) func() (T, error) {
+	return func() (T, error) {
+		for {
+			row, err := read()
+			if err != nil {
+				return row.Data, err
+			}
+
+			if d.Add(row) && dedupe {
+				continue
+			}
+			return row.Data, nil
+		}
+	}
+}
@@ -0,0 +1,85 @@
+package duplicates_test
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	csvparser "github.com/rickyson96/amartha-reconciliation-service/internal/csv_parser"
+	"github.com/rickyson96/amartha-reconciliation-service/internal/duplicates"
+)
+
+func TestDetector(t *testing.T) {
+	input := `id,amount
+1,10
+2,20
+1,10
+3,30
+2,21
+1,10
+2,20
+`
+
+	tests := []struct {
+		name   string
+		dedupe bool
+		want   []string
+	}{
+		{
+			name: "detect only",
+			want: []string{"1", "2", "1", "3", "2", "1", "2"},
+		},
+		{
+			// the conflicting row of 2 is kept, only the copies are dropped
+			name:   "dedupe",
+			dedupe: true,
+			want:   []string{"1", "2", "3", "2"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			p := csvparser.NewCSVParser(bytes.NewBufferString(input), func(data []string) ([]string, error) {
+				return data, nil
+			}, func(data []string) bool {
+				return true
+			}, csvparser.CSVParserOptions{ContainsHeader: true, FieldPerRow: 2})
+
+			d := duplicates.NewDetector(func(data []string) string { return data[0] })
+			read := d.Reader(p.ReadRow, test.dedupe)
+
+			var got []string
+			for {
+				data, err := read()
+				if err == io.EOF {
+					break
+				}
+				if err != nil {
+					t.Fatalf("unwanted error: %v", err)
+				}
+				got = append(got, data[0])
+			}
+
+			if diff := cmp.Diff(test.want, got); diff != "" {
+				t.Errorf("read mismatch, (-want,+got):\n%s", diff)
+			}
+
+			wantDups := []duplicates.Duplicate{
+				{ID: "1", Lines: []int{2, 4, 7}, Exact: true, Copies: []int{4, 7}},
+				{ID: "2", Lines: []int{3, 6, 8}, Exact: false, Copies: []int{8}},
+			}
+			if diff := cmp.Diff(wantDups, d.Duplicates()); diff != "" {
+				t.Errorf("Duplicates mismatch, (-want,+got):\n%s", diff)
+			}
+
+			report := duplicates.Report{"file.csv": d.Duplicates()}
+			if report.Count() != 4 {
+				t.Errorf("Count mismatch, want 4, got %d", report.Count())
+			}
+			if report.CopyCount() != 3 {
+				t.Errorf("CopyCount mismatch, want 3, got %d", report.CopyCount())
+			}
+		})
+	}
+}
@@ -0,0 +1,160 @@
+package input
+
+import (
+	"archive/zip"
+	"compress/bzip2"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strings"
+)
+
+// Stdin is the name reading from the standard input
+const Stdin = "-"
+
+var (
+	ErrNotSingleMember = errors.New("zip archive should contain exactly one file")
+	ErrMemberNotFound  = errors.New("member not found in zip archive")
+)
+
+// Open opens the input file, decompressing it while being read:
+//   - [Stdin] reads the standard input as is
+//   - .gz and .bz2 files are decompressed by their extension
+//   - "archive.zip/member.csv" opens the member of the archive
+//   - .zip opens its only member, see [Expand] for archives of several files
+//
+// Nothing is extracted to the disk.
+func Open(name string) (io.ReadCloser, error) {
+	if name == Stdin {
+		return io.NopCloser(os.Stdin), nil
+	}
+
+	if archive, member, ok := splitMember(name); ok {
+		return openMember(archive, member)
+	}
+
+	if isZip(name) {
+		members, err := Expand(name)
+		if err != nil {
+			return nil, err
+		}
+		if len(members) != 1 {
+			return nil, fmt.Errorf("%s: %w", name, ErrNotSingleMember)
+		}
+		return Open(members[0])
+	}
+
+	file, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+
+	return decompress(name, file)
+}
+
+// Expand expands the zip archives into their members, named
+// "archive.zip/member.csv" following the archive order. Other files are
+// kept as is.
+func Expand(names ...string) ([]string, error) {
+	var expanded []string
+	for _, name := range names {
+		if !isZip(name) {
+			expanded = append(expanded, name)
+			continue
+		}
+
+		r, err := zip.OpenReader(name)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		for _, f := range r.File {
+			if f.FileInfo().IsDir() {
+				continue
+			}
+			expanded = append(expanded, name+"/"+f.Name)
+		}
+		r.Close()
+	}
+
+	return expanded, nil
+}
+
+func isZip(name string) bool {
+	return strings.EqualFold(path.Ext(name), ".zip")
+}
+
+// splitMember splits the name into the zip archive and its member, when
+// the name points into an archive existing on the disk
+func splitMember(name string) (string, string, bool) {
+	lower := strings.ToLower(name)
+	for i := 0; ; {
+		j := strings.Index(lower[i:], ".zip/")
+		if j < 0 {
+			return "", "", false
+		}
+
+		end := i + j + len(".zip")
+		if info, err := os.Stat(name[:end]); err == nil && info.Mode().IsRegular() {
+			return name[:end], name[end+1:], true
+		}
+		i = end
+	}
+}
+
+func openMember(archive, member string) (io.ReadCloser, error) {
+	r, err := zip.OpenReader(archive)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", archive, err)
+	}
+
+	for _, f := range r.File {
+		if f.Name != member {
+			continue
+		}
+
+		file, err := f.Open()
+		if err != nil {
+			r.Close()
+			return nil, fmt.Errorf("%s/%s: %w", archive, member, err)
+		}
+
+		return decompress(member, readCloser{file, []io.Closer{file, r}})
+	}
+
+	r.Close()
+	return nil, fmt.Errorf("%s/%s: %w", archive, member, ErrMemberNotFound)
+}
+
+// decompress wraps the file with the decompressor of its extension
+func decompress(name string, file io.ReadCloser) (io.ReadCloser, error) {
+	switch strings.ToLower(path.Ext(name)) {
+	case ".gz":
+		gz, err := gzip.NewReader(file)
+		if err != nil {
+			file.Close()
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		return readCloser{gz, []io.Closer{gz, file}}, nil
+	case ".bz2":
+		return readCloser{bzip2.NewReader(file), []io.Closer{file}}, nil
+	default:
+		return file, nil
+	}
+}
+
+// readCloser reads through the reader, closing all the closers
+type readCloser struct {
+	io.Reader
+	closers []io.Closer
+}
+
+func (r readCloser) Close() error {
+	var errs []error
+	for _, c := range r.closers {
+		errs = append(errs, c.Close())
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,108 @@
+package input_test
+
+import (
+	"archive/zip"
+	"compress/gzip"
+	"encoding/hex"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/rickyson96/amartha-reconciliation-service/internal/input"
+)
+
+const content = "a,b\n1,2\n"
+
+func writeFile(t *testing.T, path string, write func(w io.Writer)) {
+	t.Helper()
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatalf("failed creating %s: %v", path, err)
+	}
+	defer f.Close()
+	write(f)
+}
+
+func writeZip(t *testing.T, path string, members ...string) {
+	t.Helper()
+	writeFile(t, path, func(w io.Writer) {
+		zw := zip.NewWriter(w)
+		for _, m := range members {
+			mw, err := zw.Create(m)
+			if err != nil {
+				t.Fatalf("failed creating member: %v", err)
+			}
+			if filepath.Ext(m) == ".gz" {
+				gz := gzip.NewWriter(mw)
+				io.WriteString(gz, content)
+				gz.Close()
+				continue
+			}
+			io.WriteString(mw, content)
+		}
+		zw.Close()
+	})
+}
+
+func TestOpen(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "plain.csv"), func(w io.Writer) {
+		io.WriteString(w, content)
+	})
+	writeFile(t, filepath.Join(dir, "trx.csv.gz"), func(w io.Writer) {
+		gz := gzip.NewWriter(w)
+		io.WriteString(gz, content)
+		gz.Close()
+	})
+	writeFile(t, filepath.Join(dir, "trx.csv.bz2"), func(w io.Writer) {
+		// bzip2 of content, as the standard library can't compress bzip2
+		data, _ := hex.DecodeString("425a6839314159265359bf87407f00000359000010000430003000200030c00869b28823278bb9229c28485fc3a03f80")
+		w.Write(data)
+	})
+	writeZip(t, filepath.Join(dir, "single.zip"), "trx.csv")
+	writeZip(t, filepath.Join(dir, "bank.zip"), "bank1.csv", "nested/bank2.csv.gz")
+
+	for _, name := range []string{"plain.csv", "trx.csv.gz", "trx.csv.bz2", "single.zip", "bank.zip/bank1.csv", "bank.zip/nested/bank2.csv.gz"} {
+		t.Run(name, func(t *testing.T) {
+			f, err := input.Open(filepath.Join(dir, name))
+			if err != nil {
+				t.Fatalf("Open failed: %v", err)
+			}
+			defer f.Close()
+
+			got, err := io.ReadAll(f)
+			if err != nil {
+				t.Fatalf("read failed: %v", err)
+			}
+			if diff := cmp.Diff(content, string(got)); diff != "" {
+				t.Errorf("content mismatch, (-want,+got):\n%s", diff)
+			}
+		})
+	}
+
+	if _, err := input.Open(filepath.Join(dir, "bank.zip")); !errors.Is(err, input.ErrNotSingleMember) {
+		t.Errorf("Open of multiple members should fail with ErrNotSingleMember, got %v", err)
+	}
+	if _, err := input.Open(filepath.Join(dir, "bank.zip/bank3.csv")); !errors.Is(err, input.ErrMemberNotFound) {
+		t.Errorf("Open of missing member should fail with ErrMemberNotFound, got %v", err)
+	}
+}
+
+func TestExpand(t *testing.T) {
+	dir := t.TempDir()
+	archive := filepath.Join(dir, "bank.zip")
+	writeZip(t, archive, "bank1.csv", "nested/", "nested/bank2.csv.gz")
+
+	got, err := input.Expand("bank3.csv", archive)
+	if err != nil {
+		t.Fatalf("Expand failed: %v", err)
+	}
+
+	want := []string{"bank3.csv", archive + "/bank1.csv", archive + "/nested/bank2.csv.gz"}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Expand mismatch, (-want,+got):\n%s", diff)
+	}
+}
@@ -0,0 +1,45 @@
+package statements
+
+import (
+	"encoding/json"
+	"io"
+
+	"github.com/govalues/decimal"
+)
+
+// Balance is the opening and closing balance of a statement source
+type Balance struct {
+	Opening decimal.Decimal `json:"opening"`
+	Closing decimal.Decimal `json:"closing"`
+	// Total is the sum of every line of the file, including the ones out
+	// of the date range, as the balance of the file covers them all. It's
+	// only set by the files carrying their balance, see [BalanceReader].
+	Total *decimal.Decimal `json:"-"`
+}
+
+// addLine adds the amount of the line into [Balance.Total]
+func (b *Balance) addLine(amount decimal.Decimal) error {
+	total := decimal.Zero
+	if b.Total != nil {
+		total = *b.Total
+	}
+
+	total, err := total.Add(amount)
+	if err != nil {
+		return err
+	}
+	b.Total = &total
+	return nil
+}
+
+// ReadBalance parses the balance out of a json sidecar, e.g.
+//
+//	{"opening": "1000.00", "closing": "2600.00"}
+func ReadBalance(r io.Reader) (Balance, error) {
+	var balance Balance
+	if err := json.NewDecoder(r).Decode(&balance); err != nil {
+		return Balance{}, err
+	}
+
+	return balance, nil
+}
@@ -0,0 +1,50 @@
+package statements
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/rickyson96/amartha-reconciliation-service/internal/testutils"
+)
+
+func TestReadBalance(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    Balance
+		wantErr bool
+	}{
+		{
+			name:  "string amounts",
+			input: `{"opening": "1000.00", "closing": "-2600.50"}`,
+			want: Balance{
+				Opening: testutils.NewDecimal(t, 100000, 2),
+				Closing: testutils.NewDecimal(t, -260050, 2),
+			},
+		},
+		{
+			name:  "number amounts",
+			input: `{"opening": 10, "closing": 20}`,
+			want: Balance{
+				Opening: testutils.NewDecimal(t, 10, 0),
+				Closing: testutils.NewDecimal(t, 20, 0),
+			},
+		},
+		{name: "wrong amount", input: `{"opening": "woo"}`, wantErr: true},
+		{name: "wrong json", input: `{`, wantErr: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := ReadBalance(strings.NewReader(test.input))
+			if test.wantErr != (err != nil) {
+				t.Errorf("wantErr is %t, but err is %v", test.wantErr, err)
+			}
+
+			if diff := cmp.Diff(test.want, got); diff != "" {
+				t.Errorf("ReadBalance mismatch, (-want,+got):\n%s", diff)
+			}
+		})
+	}
+}
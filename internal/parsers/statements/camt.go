@@ -0,0 +1,326 @@
+package statements
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/govalues/decimal"
+	csvparser "github.com/rickyson96/amartha-reconciliation-service/internal/csv_parser"
+	"github.com/rickyson96/amartha-reconciliation-service/internal/parsers/transactions"
+)
+
+// CamtOptions configures the ISO 20022 camt.053 and camt.052 statements
+type CamtOptions struct {
+	// ValueDate uses the value date of the entries instead of their
+	// booking date, when they have one
+	ValueDate bool `json:"valueDate"`
+	// Pending includes the entries not booked yet, e.g. on the intraday
+	// camt.052 reports, flagged by [Statement.Pending]
+	Pending bool `json:"pending"`
+}
+
+type (
+	camtDate struct {
+		Date     string `xml:"Dt"`
+		DateTime string `xml:"DtTm"`
+	}
+
+	camtBalance struct {
+		Type      string `xml:"Tp>CdOrPrtry>Cd"`
+		Amount    string `xml:"Amt"`
+		Indicator string `xml:"CdtDbtInd"`
+	}
+
+	camtEntry struct {
+		EntryRef  string `xml:"NtryRef"`
+		Amount    string `xml:"Amt"`
+		Indicator string `xml:"CdtDbtInd"`
+		// Status is the code, which is nested since camt.053.001.08
+		Status struct {
+			Text string `xml:",chardata"`
+			Code string `xml:"Cd"`
+		} `xml:"Sts"`
+		BookingDate camtDate `xml:"BookgDt"`
+		ValueDate   camtDate `xml:"ValDt"`
+		ServicerRef string   `xml:"AcctSvcrRef"`
+		Details     []struct {
+			EndToEndID     string   `xml:"Refs>EndToEndId"`
+			Unstructured   []string `xml:"RmtInf>Ustrd"`
+			AdditionalInfo string   `xml:"AddtlTxInf"`
+		} `xml:"NtryDtls>TxDtls"`
+		AdditionalInfo string `xml:"AddtlNtryInf"`
+	}
+)
+
+// CamtParser streams the entries of ISO 20022 camt.053 (end of day
+// statement) and camt.052 (intraday report) as statements.
+//
+// The unique identifier is the account servicer reference of the entry,
+// falling back to the entry reference, or the content of the entry when
+// it has neither, and the end to end id is the reference.
+//
+// The statements (or reports) of the file are treated as the consecutive
+// statements of the account, so the opening balance is taken from the
+// first statement and the closing balance from the last one.
+type CamtParser struct {
+	decoder *xml.Decoder
+	options CamtOptions
+	filter  func(data Statement) bool
+	balance Balance
+	// opening and closing report whether the balances are found
+	opening bool
+	closing bool
+	// finalClosing reports whether the closing balance of the current
+	// statement is the booked closing rather than the interim one
+	finalClosing bool
+	// next is the entry found while looking for the balances
+	next *xml.StartElement
+	ids  contentIDs
+}
+
+// NewCamtParser creates the camt statement parser, reading through the
+// balances preceding the entries
+func NewCamtParser(file io.Reader, startDate, endDate time.Time, options CamtOptions) (*CamtParser, error) {
+	p := &CamtParser{
+		decoder: xml.NewDecoder(file),
+		options: options,
+		filter:  filter(startDate, endDate),
+		ids:     make(contentIDs),
+	}
+
+	start, err := p.nextEntry()
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+	if err == nil {
+		p.next = &start
+	}
+
+	return p, nil
+}
+
+func (p *CamtParser) readBalance(start xml.StartElement) error {
+	var bal camtBalance
+	if err := p.decoder.DecodeElement(&bal, &start); err != nil {
+		return err
+	}
+
+	amount, err := signedCamtAmount(bal.Amount, bal.Indicator)
+	if err != nil {
+		return fmt.Errorf("balance %s: %w", bal.Type, err)
+	}
+
+	switch bal.Type {
+	// opening booked, or previously closed booked on the intraday report
+	case "OPBD", "PRCD":
+		if !p.opening {
+			p.balance.Opening, p.opening = amount, true
+		}
+	// closing booked, or interim booked on the intraday report
+	case "CLBD", "ITBD":
+		if !p.finalClosing {
+			p.balance.Closing, p.closing = amount, true
+			p.finalClosing = bal.Type == "CLBD"
+		}
+	}
+	return nil
+}
+
+// Balance returns the opening and closing booked balance of the file,
+// along with the total of its booked entries. The closing balance of the
+// following statements is read along with their entries, so it's
+// complete once the file is read.
+func (p *CamtParser) Balance() (Balance, bool) {
+	return p.balance, p.opening && p.closing
+}
+
+func (p *CamtParser) Read() (Statement, error) {
+	row, err := p.ReadRow()
+	return row.Data, err
+}
+
+// ReadRow returns the next entry, where the line is the line of the
+// entry element
+func (p *CamtParser) ReadRow() (csvparser.Row[Statement], error) {
+	for {
+		start, err := p.nextEntry()
+		if err != nil {
+			return csvparser.Row[Statement]{}, err
+		}
+
+		line, _ := p.decoder.InputPos()
+		var entry camtEntry
+		if err := p.decoder.DecodeElement(&entry, &start); err != nil {
+			return csvparser.Row[Statement]{}, fmt.Errorf("camt: %w", err)
+		}
+
+		status := strings.TrimSpace(entry.Status.Text)
+		if entry.Status.Code != "" {
+			status = entry.Status.Code
+		}
+		if status != "BOOK" && !p.options.Pending {
+			continue
+		}
+
+		stmt, err := p.parse(entry)
+		if err != nil {
+			return csvparser.Row[Statement]{}, &csvparser.RowError{Line: line, Err: err}
+		}
+		// the booked balance only covers the booked entries
+		stmt.Pending = status != "BOOK"
+		if !stmt.Pending {
+			if err := p.balance.addLine(stmt.Amount); err != nil {
+				return csvparser.Row[Statement]{}, &csvparser.RowError{Line: line, Err: err}
+			}
+		}
+		if !p.filter(stmt) {
+			continue
+		}
+
+		record := []string{
+			stmt.UniqueIdentifier,
+			stmt.Amount.String(),
+			stmt.Date.Format(time.DateOnly),
+			stmt.Type.String(),
+			stmt.Reference,
+			stmt.Description,
+		}
+		return csvparser.Row[Statement]{Line: line, Record: record, Data: stmt}, nil
+	}
+}
+
+// nextEntry returns the start of the next entry element, reading the
+// balances of the statements on the way
+func (p *CamtParser) nextEntry() (xml.StartElement, error) {
+	if p.next != nil {
+		start := *p.next
+		p.next = nil
+		return start, nil
+	}
+
+	for {
+		token, err := p.decoder.Token()
+		if err == io.EOF {
+			return xml.StartElement{}, err
+		}
+		if err != nil {
+			return xml.StartElement{}, fmt.Errorf("camt: %w", err)
+		}
+
+		start, ok := token.(xml.StartElement)
+		if !ok {
+			continue
+		}
+
+		switch start.Name.Local {
+		case "Stmt", "Rpt":
+			p.finalClosing = false
+		case "Bal":
+			if err := p.readBalance(start); err != nil {
+				return xml.StartElement{}, fmt.Errorf("camt: %w", err)
+			}
+		case "Ntry":
+			return start, nil
+		}
+	}
+}
+
+func (p *CamtParser) parse(entry camtEntry) (Statement, error) {
+	amount, err := decimal.Parse(strings.TrimSpace(entry.Amount))
+	if err != nil {
+		return Statement{}, err
+	}
+
+	var stmtType transactions.TransactionType
+	switch strings.TrimSpace(entry.Indicator) {
+	case "DBIT":
+		stmtType = transactions.TransactionTypeDebit
+	case "CRDT":
+		stmtType = transactions.TransactionTypeCredit
+	default:
+		return Statement{}, fmt.Errorf("%q is %w", entry.Indicator, ErrUnknownIndicator)
+	}
+
+	dateField := entry.BookingDate
+	if p.options.ValueDate && entry.ValueDate != (camtDate{}) {
+		dateField = entry.ValueDate
+	}
+	date, err := dateField.parse()
+	if err != nil {
+		return Statement{}, err
+	}
+
+	var reference string
+	var descriptions []string
+	for _, d := range entry.Details {
+		if reference == "" && d.EndToEndID != "NOTPROVIDED" {
+			reference = d.EndToEndID
+		}
+		descriptions = append(descriptions, d.Unstructured...)
+		descriptions = append(descriptions, d.AdditionalInfo)
+	}
+	descriptions = append(descriptions, entry.AdditionalInfo)
+
+	stmt := Statement{
+		UniqueIdentifier: entry.ServicerRef,
+		Amount:           signed(amount, stmtType),
+		Date:             date,
+		Description:      joinNonEmpty(descriptions),
+		Type:             stmtType,
+		Reference:        reference,
+	}
+	if stmt.UniqueIdentifier == "" {
+		stmt.UniqueIdentifier = entry.EntryRef
+	}
+	if stmt.UniqueIdentifier == "" {
+		// entries without reference are identified by their content
+		stmt.UniqueIdentifier = p.ids.id(stmt)
+	}
+	return stmt, nil
+}
+
+func (d camtDate) parse() (time.Time, error) {
+	if d.Date != "" {
+		return time.Parse(time.DateOnly, strings.TrimSpace(d.Date))
+	}
+
+	value := strings.TrimSpace(d.DateTime)
+	dateTime, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		// the time zone is optional
+		dateTime, err = time.Parse("2006-01-02T15:04:05", value)
+		if err != nil {
+			return time.Time{}, err
+		}
+	}
+	return time.Date(dateTime.Year(), dateTime.Month(), dateTime.Day(), 0, 0, 0, 0, time.UTC), nil
+}
+
+func signedCamtAmount(value, indicator string) (decimal.Decimal, error) {
+	amount, err := decimal.Parse(strings.TrimSpace(value))
+	if err != nil {
+		return decimal.Decimal{}, err
+	}
+
+	switch strings.TrimSpace(indicator) {
+	case "DBIT":
+		return amount.Abs().Neg(), nil
+	case "CRDT":
+		return amount.Abs(), nil
+	default:
+		return decimal.Decimal{}, fmt.Errorf("%q is %w", indicator, ErrUnknownIndicator)
+	}
+}
+
+func joinNonEmpty(values []string) string {
+	var nonEmpty []string
+	for _, v := range values {
+		if v = strings.TrimSpace(v); v != "" {
+			nonEmpty = append(nonEmpty, v)
+		}
+	}
+	return strings.Join(nonEmpty, " ")
+}
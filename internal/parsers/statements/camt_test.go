@@ -0,0 +1,243 @@
+package statements
+
+import (
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/rickyson96/amartha-reconciliation-service/internal/parsers/transactions"
+	"github.com/rickyson96/amartha-reconciliation-service/internal/testutils"
+)
+
+const camt053 = `<?xml version="1.0" encoding="UTF-8"?>
+<Document xmlns="urn:iso:std:iso:20022:tech:xsd:camt.053.001.02">
+  <BkToCstmrStmt>
+    <GrpHdr><MsgId>MSG1</MsgId></GrpHdr>
+    <Stmt>
+      <Id>STMT1</Id>
+      <Bal>
+        <Tp><CdOrPrtry><Cd>OPBD</Cd></CdOrPrtry></Tp>
+        <Amt Ccy="IDR">1000.00</Amt><CdtDbtInd>CRDT</CdtDbtInd>
+      </Bal>
+      <Bal>
+        <Tp><CdOrPrtry><Cd>CLBD</Cd></CdOrPrtry></Tp>
+        <Amt Ccy="IDR">1083.00</Amt><CdtDbtInd>CRDT</CdtDbtInd>
+      </Bal>
+      <Ntry>
+        <Amt Ccy="IDR">100.00</Amt><CdtDbtInd>CRDT</CdtDbtInd><Sts>BOOK</Sts>
+        <BookgDt><Dt>2025-03-10</Dt></BookgDt><ValDt><Dt>2025-03-11</Dt></ValDt>
+        <AcctSvcrRef>B001</AcctSvcrRef>
+        <NtryDtls><TxDtls>
+          <Refs><EndToEndId>TRX1</EndToEndId></Refs>
+          <RmtInf><Ustrd>INV 1</Ustrd></RmtInf>
+        </TxDtls></NtryDtls>
+        <AddtlNtryInf>TRANSFER</AddtlNtryInf>
+      </Ntry>
+      <Ntry>
+        <NtryRef>E002</NtryRef>
+        <Amt Ccy="IDR">10.00</Amt><CdtDbtInd>DBIT</CdtDbtInd><Sts><Cd>BOOK</Cd></Sts>
+        <BookgDt><DtTm>2025-03-12T09:30:00+07:00</DtTm></BookgDt>
+        <NtryDtls><TxDtls><Refs><EndToEndId>NOTPROVIDED</EndToEndId></Refs></TxDtls></NtryDtls>
+        <AddtlNtryInf>ADMIN FEE</AddtlNtryInf>
+      </Ntry>
+      <Ntry>
+        <NtryRef>E003</NtryRef>
+        <Amt Ccy="IDR">5.00</Amt><CdtDbtInd>DBIT</CdtDbtInd><Sts>PDNG</Sts>
+        <BookgDt><Dt>2025-03-12</Dt></BookgDt>
+      </Ntry>
+      <Ntry>
+        <NtryRef>E004</NtryRef>
+        <Amt Ccy="IDR">7.00</Amt><CdtDbtInd>DBIT</CdtDbtInd><Sts>BOOK</Sts>
+        <BookgDt><Dt>2025-04-01</Dt></BookgDt>
+      </Ntry>
+    </Stmt>
+  </BkToCstmrStmt>
+</Document>`
+
+func TestCamtParser(t *testing.T) {
+	march1, march31 := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC), time.Date(2025, 3, 31, 0, 0, 0, 0, time.UTC)
+	reader, err := NewReader("bank4.xml", strings.NewReader(camt053), march1, march31, DefaultFormat)
+	if err != nil {
+		t.Fatalf("NewReader failed: %v", err)
+	}
+
+	balance, ok := reader.(BalanceReader).Balance()
+	if !ok {
+		t.Fatalf("Balance should be found")
+	}
+	wantBalance := Balance{Opening: testutils.NewDecimal(t, 100000, 2), Closing: testutils.NewDecimal(t, 108300, 2)}
+	if diff := cmp.Diff(wantBalance, balance); diff != "" {
+		t.Errorf("Balance mismatch, (-want,+got):\n%s", diff)
+	}
+
+	var got []Statement
+	var lines []int
+	for {
+		row, err := reader.ReadRow()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("unwanted error: %v", err)
+		}
+		got = append(got, row.Data)
+		lines = append(lines, row.Line)
+	}
+
+	want := []Statement{{
+		UniqueIdentifier: "B001",
+		Amount:           testutils.NewDecimal(t, 10000, 2),
+		Date:             time.Date(2025, 3, 10, 0, 0, 0, 0, time.UTC),
+		Description:      "INV 1 TRANSFER",
+		Type:             transactions.TransactionTypeCredit,
+		Reference:        "TRX1",
+	}, {
+		UniqueIdentifier: "E002",
+		Amount:           testutils.NewDecimal(t, -1000, 2),
+		Date:             time.Date(2025, 3, 12, 0, 0, 0, 0, time.UTC),
+		Description:      "ADMIN FEE",
+		Type:             transactions.TransactionTypeDebit,
+	}}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("ReadRow() mismatch, (-want,+got):\n%s", diff)
+	}
+	if diff := cmp.Diff([]int{15, 25}, lines); diff != "" {
+		t.Errorf("lines mismatch, (-want,+got):\n%s", diff)
+	}
+
+	// the total covers the booked entries out of the date range too
+	total := testutils.NewDecimal(t, 8300, 2)
+	wantBalance.Total = &total
+	balance, _ = reader.(BalanceReader).Balance()
+	if diff := cmp.Diff(wantBalance, balance); diff != "" {
+		t.Errorf("Balance after reading mismatch, (-want,+got):\n%s", diff)
+	}
+}
+
+func TestCamtParserOptions(t *testing.T) {
+	march1, march31 := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC), time.Date(2025, 3, 31, 0, 0, 0, 0, time.UTC)
+	parser, err := NewCamtParser(strings.NewReader(camt053), march1, march31, CamtOptions{ValueDate: true, Pending: true})
+	if err != nil {
+		t.Fatalf("NewCamtParser failed: %v", err)
+	}
+
+	var got, pending []string
+	for {
+		stmt, err := parser.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("unwanted error: %v", err)
+		}
+		got = append(got, stmt.UniqueIdentifier+" "+stmt.Date.Format(time.DateOnly))
+		if stmt.Pending {
+			pending = append(pending, stmt.UniqueIdentifier)
+		}
+	}
+
+	// E002 doesn't have value date, falling back to its booking date
+	want := []string{"B001 2025-03-11", "E002 2025-03-12", "E003 2025-03-12"}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Read() mismatch, (-want,+got):\n%s", diff)
+	}
+	if diff := cmp.Diff([]string{"E003"}, pending); diff != "" {
+		t.Errorf("pending mismatch, (-want,+got):\n%s", diff)
+	}
+
+	// the pending entry isn't part of the booked balance
+	total := testutils.NewDecimal(t, 8300, 2)
+	wantBalance := Balance{Opening: testutils.NewDecimal(t, 100000, 2), Closing: testutils.NewDecimal(t, 108300, 2), Total: &total}
+	balance, _ := parser.Balance()
+	if diff := cmp.Diff(wantBalance, balance); diff != "" {
+		t.Errorf("Balance mismatch, (-want,+got):\n%s", diff)
+	}
+}
+
+func TestCamtParserMultipleStatements(t *testing.T) {
+	input := `<Document><BkToCstmrStmt>
+  <Stmt>
+    <Bal><Tp><CdOrPrtry><Cd>OPBD</Cd></CdOrPrtry></Tp><Amt>1000.00</Amt><CdtDbtInd>CRDT</CdtDbtInd></Bal>
+    <Bal><Tp><CdOrPrtry><Cd>CLBD</Cd></CdOrPrtry></Tp><Amt>1090.00</Amt><CdtDbtInd>CRDT</CdtDbtInd></Bal>
+    <Ntry><NtryRef>E1</NtryRef><Amt>90.00</Amt><CdtDbtInd>CRDT</CdtDbtInd><Sts>BOOK</Sts><BookgDt><Dt>2025-03-10</Dt></BookgDt></Ntry>
+  </Stmt>
+  <Stmt>
+    <Bal><Tp><CdOrPrtry><Cd>OPBD</Cd></CdOrPrtry></Tp><Amt>1090.00</Amt><CdtDbtInd>CRDT</CdtDbtInd></Bal>
+    <Bal><Tp><CdOrPrtry><Cd>CLBD</Cd></CdOrPrtry></Tp><Amt>1085.00</Amt><CdtDbtInd>CRDT</CdtDbtInd></Bal>
+    <Ntry><NtryRef>E2</NtryRef><Amt>5.00</Amt><CdtDbtInd>DBIT</CdtDbtInd><Sts>BOOK</Sts><BookgDt><Dt>2025-03-11</Dt></BookgDt></Ntry>
+  </Stmt>
+</BkToCstmrStmt></Document>`
+
+	march1, march31 := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC), time.Date(2025, 3, 31, 0, 0, 0, 0, time.UTC)
+	parser, err := NewCamtParser(strings.NewReader(input), march1, march31, CamtOptions{})
+	if err != nil {
+		t.Fatalf("NewCamtParser failed: %v", err)
+	}
+
+	var got []string
+	for {
+		stmt, err := parser.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("unwanted error: %v", err)
+		}
+		got = append(got, stmt.UniqueIdentifier)
+	}
+	if diff := cmp.Diff([]string{"E1", "E2"}, got); diff != "" {
+		t.Errorf("Read() mismatch, (-want,+got):\n%s", diff)
+	}
+
+	// the opening of the first statement and the closing of the last one
+	balance, ok := parser.Balance()
+	if !ok {
+		t.Fatalf("Balance should be found")
+	}
+	total := testutils.NewDecimal(t, 8500, 2)
+	want := Balance{Opening: testutils.NewDecimal(t, 100000, 2), Closing: testutils.NewDecimal(t, 108500, 2), Total: &total}
+	if diff := cmp.Diff(want, balance); diff != "" {
+		t.Errorf("Balance mismatch, (-want,+got):\n%s", diff)
+	}
+}
+
+func TestCamtParserContentID(t *testing.T) {
+	entry := func(amount, indicator, date, info string) string {
+		return `<Ntry><Amt>` + amount + `</Amt><CdtDbtInd>` + indicator + `</CdtDbtInd><Sts>BOOK</Sts><BookgDt><Dt>` + date + `</Dt></BookgDt><AddtlNtryInf>` + info + `</AddtlNtryInf></Ntry>`
+	}
+	fee := entry("10.00", "DBIT", "2025-03-11", "ADMIN FEE")
+	transfer := entry("20.00", "CRDT", "2025-03-12", "TRANSFER")
+
+	read := func(entries ...string) []string {
+		input := `<Document><BkToCstmrStmt><Stmt>` + strings.Join(entries, "") + `</Stmt></BkToCstmrStmt></Document>`
+		march1, march31 := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC), time.Date(2025, 3, 31, 0, 0, 0, 0, time.UTC)
+		parser, err := NewCamtParser(strings.NewReader(input), march1, march31, CamtOptions{})
+		if err != nil {
+			t.Fatalf("NewCamtParser failed: %v", err)
+		}
+
+		var ids []string
+		for {
+			stmt, err := parser.Read()
+			if err == io.EOF {
+				return ids
+			}
+			if err != nil {
+				t.Fatalf("unwanted error: %v", err)
+			}
+			ids = append(ids, stmt.UniqueIdentifier)
+		}
+	}
+
+	// the overlapping file has the same entries in other positions
+	first := read(fee, fee, transfer)
+	second := read(transfer, fee)
+	if first[0] == first[1] || first[0] == first[2] {
+		t.Errorf("entries of different content or occurrence should have different ids, got %v", first)
+	}
+	if diff := cmp.Diff([]string{first[2], first[0]}, second); diff != "" {
+		t.Errorf("ids mismatch, (-want,+got):\n%s", diff)
+	}
+}
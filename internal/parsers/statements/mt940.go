@@ -0,0 +1,384 @@
+package statements
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/govalues/decimal"
+	csvparser "github.com/rickyson96/amartha-reconciliation-service/internal/csv_parser"
+	"github.com/rickyson96/amartha-reconciliation-service/internal/parsers/transactions"
+)
+
+var ErrInvalidMT940Field = errors.New("invalid mt940 field")
+
+// MT940Options configures the SWIFT MT940 statements
+type MT940Options struct {
+	// EntryDate uses the entry date of the statement lines instead of
+	// their value date, when they have one
+	EntryDate bool `json:"entryDate"`
+}
+
+// mt940Field is the tag and the value of a field, where the value of
+// multiline fields are joined by newline
+type mt940Field struct {
+	tag   string
+	value string
+	line  int
+}
+
+// MT940Parser streams the statement lines (:61:) of SWIFT MT940 as
+// statements, along with the narrative (:86:) following them as the
+// description.
+//
+// The unique identifier is the bank reference of the statement line,
+// falling back to the customer reference, which is the reference unless
+// it's NONREF, or the content of the line when it has neither. The
+// opening balance is the first :60F: or :60M: and the closing balance is
+// the last :62F: of the file, or the last :62M: when there's no final
+// balance.
+type MT940Parser struct {
+	scanner *bufio.Scanner
+	options MT940Options
+	filter  func(data Statement) bool
+	balance Balance
+	// opening and closing report whether the balances are found
+	opening bool
+	closing bool
+	// finalClosing reports whether the closing balance is the final
+	// balance rather than the intermediate one
+	finalClosing bool
+	// line is the last line read by the scanner
+	line int
+	// current is the field being read, which continues until the next
+	// field, and next is the field read ahead of the statement line
+	current *mt940Field
+	next    *mt940Field
+	ids     contentIDs
+}
+
+// NewMT940Parser creates the MT940 statement parser, reading through the
+// opening balance preceding the statement lines
+func NewMT940Parser(file io.Reader, startDate, endDate time.Time, options MT940Options) (*MT940Parser, error) {
+	p := &MT940Parser{
+		scanner: bufio.NewScanner(file),
+		options: options,
+		filter:  filter(startDate, endDate),
+		ids:     make(contentIDs),
+	}
+
+	field, err := p.nextStatementLine()
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+	if err == nil {
+		p.next = &field
+	}
+
+	return p, nil
+}
+
+// readField reads the next tagged field, skipping the SWIFT blocks and
+// the message separators
+func (p *MT940Parser) readField() (mt940Field, error) {
+	for p.scanner.Scan() {
+		p.line++
+		text := strings.TrimRight(p.scanner.Text(), "\r")
+		if p.line == 1 {
+			text = strings.TrimPrefix(text, "\ufeff")
+		}
+
+		var next *mt940Field
+		switch {
+		case strings.HasPrefix(text, "{"), text == "-", strings.HasPrefix(text, "-}"):
+			// the basic header block or the end of the message, followed
+			// by the trailer blocks. The other lines starting with "-"
+			// continue the field, e.g. the narrative.
+		case strings.HasPrefix(text, ":"):
+			tag, value, ok := strings.Cut(text[1:], ":")
+			if !ok {
+				return mt940Field{}, &csvparser.RowError{Line: p.line, Err: fmt.Errorf("%q is %w", text, ErrInvalidMT940Field)}
+			}
+			next = &mt940Field{tag: tag, value: value, line: p.line}
+		case p.current != nil:
+			p.current.value += "\n" + text
+			continue
+		default:
+			continue
+		}
+
+		field := p.current
+		p.current = next
+		if field != nil {
+			return *field, nil
+		}
+	}
+	if err := p.scanner.Err(); err != nil {
+		return mt940Field{}, fmt.Errorf("mt940: %w", err)
+	}
+
+	if field := p.current; field != nil {
+		p.current = nil
+		return *field, nil
+	}
+	return mt940Field{}, io.EOF
+}
+
+// nextField returns the field read ahead, or the next one
+func (p *MT940Parser) nextField() (mt940Field, error) {
+	if p.next != nil {
+		field := *p.next
+		p.next = nil
+		return field, nil
+	}
+	return p.readField()
+}
+
+// nextStatementLine returns the next :61: field, reading the balances on
+// the way
+func (p *MT940Parser) nextStatementLine() (mt940Field, error) {
+	for {
+		field, err := p.nextField()
+		if err != nil {
+			return mt940Field{}, err
+		}
+
+		switch field.tag {
+		case "60F", "60M", "62F", "62M":
+			if err := p.readBalance(field); err != nil {
+				return mt940Field{}, &csvparser.RowError{Line: field.line, Err: err}
+			}
+		case "61":
+			return field, nil
+		}
+	}
+}
+
+// readBalance keeps the first opening balance, and the closing balance of
+// the last statement, preferring the final balance over the intermediate
+// one
+func (p *MT940Parser) readBalance(field mt940Field) error {
+	switch field.tag {
+	case "60F", "60M":
+		if p.opening {
+			return nil
+		}
+		amount, err := parseMT940Balance(field.value)
+		if err != nil {
+			return err
+		}
+		p.balance.Opening, p.opening = amount, true
+	case "62F", "62M":
+		if p.finalClosing && field.tag == "62M" {
+			return nil
+		}
+		amount, err := parseMT940Balance(field.value)
+		if err != nil {
+			return err
+		}
+		p.balance.Closing, p.closing = amount, true
+		p.finalClosing = p.finalClosing || field.tag == "62F"
+	}
+	return nil
+}
+
+// Balance returns the opening and closing balance of the file, along with
+// the total of its statement lines. The closing balance follows the
+// statement lines, so it's complete once the file is read.
+func (p *MT940Parser) Balance() (Balance, bool) {
+	return p.balance, p.opening && p.closing
+}
+
+func (p *MT940Parser) Read() (Statement, error) {
+	row, err := p.ReadRow()
+	return row.Data, err
+}
+
+// ReadRow returns the next statement line, where the line is the line of
+// its :61: field
+func (p *MT940Parser) ReadRow() (csvparser.Row[Statement], error) {
+	for {
+		field, err := p.nextStatementLine()
+		if err != nil {
+			return csvparser.Row[Statement]{}, err
+		}
+
+		var narrative string
+		next, err := p.nextField()
+		switch {
+		case err == nil && next.tag == "86":
+			narrative = next.value
+		case err == nil:
+			p.next = &next
+		case err != io.EOF:
+			return csvparser.Row[Statement]{}, err
+		}
+
+		stmt, err := p.parse(field.value, narrative)
+		if err != nil {
+			return csvparser.Row[Statement]{}, &csvparser.RowError{Line: field.line, Err: err}
+		}
+		if err := p.balance.addLine(stmt.Amount); err != nil {
+			return csvparser.Row[Statement]{}, &csvparser.RowError{Line: field.line, Err: err}
+		}
+		if !p.filter(stmt) {
+			continue
+		}
+
+		record := []string{
+			stmt.UniqueIdentifier,
+			stmt.Amount.String(),
+			stmt.Date.Format(time.DateOnly),
+			stmt.Type.String(),
+			stmt.Reference,
+			stmt.Description,
+		}
+		return csvparser.Row[Statement]{Line: field.line, Record: record, Data: stmt}, nil
+	}
+}
+
+// parse parses the statement line, e.g.
+// "2503100310C100,00NTRFTRX1//B001" followed by the supplementary
+// details on the next line
+func (p *MT940Parser) parse(value, narrative string) (Statement, error) {
+	line, supplementary, _ := strings.Cut(value, "\n")
+	invalid := fmt.Errorf(":61: %q is %w", line, ErrInvalidMT940Field)
+
+	if len(line) < 6 {
+		return Statement{}, invalid
+	}
+	date, err := time.Parse("060102", line[:6])
+	if err != nil {
+		return Statement{}, err
+	}
+	line = line[6:]
+
+	// the entry date is the optional month and day
+	if len(line) >= 4 && isDigits(line[:4]) {
+		if p.options.EntryDate {
+			if date, err = entryDate(date, line[:4]); err != nil {
+				return Statement{}, err
+			}
+		}
+		line = line[4:]
+	}
+
+	var stmtType transactions.TransactionType
+	switch {
+	// reversals are the opposite of what they reverse
+	case strings.HasPrefix(line, "RC"):
+		stmtType, line = transactions.TransactionTypeDebit, line[2:]
+	case strings.HasPrefix(line, "RD"):
+		stmtType, line = transactions.TransactionTypeCredit, line[2:]
+	case strings.HasPrefix(line, "C"):
+		stmtType, line = transactions.TransactionTypeCredit, line[1:]
+	case strings.HasPrefix(line, "D"):
+		stmtType, line = transactions.TransactionTypeDebit, line[1:]
+	default:
+		return Statement{}, fmt.Errorf("%q is %w", line, ErrUnknownIndicator)
+	}
+
+	// the funds code is the optional third character of the currency
+	if line != "" && !isDigits(line[:1]) {
+		line = line[1:]
+	}
+
+	end := strings.IndexFunc(line, func(r rune) bool { return (r < '0' || r > '9') && r != ',' })
+	if end <= 0 {
+		return Statement{}, invalid
+	}
+	amount, err := parseMT940Amount(line[:end])
+	if err != nil {
+		return Statement{}, err
+	}
+	line = line[end:]
+
+	// the transaction type identification code, e.g. NTRF
+	if len(line) < 4 {
+		return Statement{}, invalid
+	}
+	customerRef, bankRef, _ := strings.Cut(line[4:], "//")
+
+	var reference string
+	if customerRef != "NONREF" {
+		reference = customerRef
+	}
+
+	description := strings.ReplaceAll(narrative, "\n", " ")
+	if description == "" {
+		description = supplementary
+	}
+
+	stmt := Statement{
+		UniqueIdentifier: bankRef,
+		Amount:           signed(amount, stmtType),
+		Date:             date,
+		Description:      strings.TrimSpace(description),
+		Type:             stmtType,
+		Reference:        reference,
+	}
+	if stmt.UniqueIdentifier == "" {
+		stmt.UniqueIdentifier = reference
+	}
+	if stmt.UniqueIdentifier == "" {
+		// lines without reference are identified by their content
+		stmt.UniqueIdentifier = p.ids.id(stmt)
+	}
+	return stmt, nil
+}
+
+// entryDate returns the date of the month and day, within the year of
+// the value date, e.g. the entry on 0102 of the value date on 241231 is
+// on 2025
+func entryDate(valueDate time.Time, monthDay string) (time.Time, error) {
+	date, err := time.Parse("20060102", strconv.Itoa(valueDate.Year())+monthDay)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	switch diff := date.Sub(valueDate); {
+	case diff > 180*24*time.Hour:
+		return date.AddDate(-1, 0, 0), nil
+	case diff < -180*24*time.Hour:
+		return date.AddDate(1, 0, 0), nil
+	default:
+		return date, nil
+	}
+}
+
+// parseMT940Balance parses the balance, e.g. "C250310IDR1000,00"
+func parseMT940Balance(value string) (decimal.Decimal, error) {
+	value = strings.TrimSpace(value)
+	if len(value) < 11 {
+		return decimal.Decimal{}, fmt.Errorf("balance %q is %w", value, ErrInvalidMT940Field)
+	}
+
+	amount, err := parseMT940Amount(value[10:])
+	if err != nil {
+		return decimal.Decimal{}, fmt.Errorf("balance %q: %w", value, err)
+	}
+
+	switch value[0] {
+	case 'D':
+		return amount.Neg(), nil
+	case 'C':
+		return amount, nil
+	default:
+		return decimal.Decimal{}, fmt.Errorf("%q is %w", value[:1], ErrUnknownIndicator)
+	}
+}
+
+// parseMT940Amount parses the amount with comma as the decimal mark,
+// where the decimals are optional, e.g. "100,"
+func parseMT940Amount(value string) (decimal.Decimal, error) {
+	value = strings.TrimSuffix(strings.Replace(value, ",", ".", 1), ".")
+	return decimal.Parse(value)
+}
+
+func isDigits(s string) bool {
+	return strings.IndexFunc(s, func(r rune) bool { return r < '0' || r > '9' }) < 0
+}
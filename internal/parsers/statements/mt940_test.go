@@ -0,0 +1,182 @@
+package statements
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"testing/iotest"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/rickyson96/amartha-reconciliation-service/internal/parsers/transactions"
+	"github.com/rickyson96/amartha-reconciliation-service/internal/testutils"
+)
+
+const mt940 = `{1:F01BANKIDJAXXXX0000000000}{2:I940BANKIDJAXXXXN}{4:
+:20:STMT250310
+:25:1234567890
+:28C:1/1
+:60F:C250309IDR1000,00
+:61:2503100310C100,00NTRFTRX1//B001
+:86:TRANSFER FROM BUDI
+INV 1
+:61:2503120311D10,NCHGNONREF
+:86:ADMIN FEE
+:61:2503130313RD5,00NTRFNONREF//B003
+:61:2504010401D7,00NTRFNONREF//B004
+:62F:C250401IDR1088,00
+-}`
+
+func TestMT940Parser(t *testing.T) {
+	march1, march31 := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC), time.Date(2025, 3, 31, 0, 0, 0, 0, time.UTC)
+	reader, err := NewReader("bank5.sta", strings.NewReader(mt940), march1, march31, DefaultFormat)
+	if err != nil {
+		t.Fatalf("NewReader failed: %v", err)
+	}
+
+	var got []Statement
+	var lines []int
+	for {
+		row, err := reader.ReadRow()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("unwanted error: %v", err)
+		}
+		got = append(got, row.Data)
+		lines = append(lines, row.Line)
+	}
+
+	want := []Statement{{
+		UniqueIdentifier: "B001",
+		Amount:           testutils.NewDecimal(t, 10000, 2),
+		Date:             time.Date(2025, 3, 10, 0, 0, 0, 0, time.UTC),
+		Description:      "TRANSFER FROM BUDI INV 1",
+		Type:             transactions.TransactionTypeCredit,
+		Reference:        "TRX1",
+	}, {
+		Amount:      testutils.NewDecimal(t, -10, 0),
+		Date:        time.Date(2025, 3, 12, 0, 0, 0, 0, time.UTC),
+		Description: "ADMIN FEE",
+		Type:        transactions.TransactionTypeDebit,
+	}, {
+		// reversal of debit is a credit
+		UniqueIdentifier: "B003",
+		Amount:           testutils.NewDecimal(t, 500, 2),
+		Date:             time.Date(2025, 3, 13, 0, 0, 0, 0, time.UTC),
+		Type:             transactions.TransactionTypeCredit,
+	}}
+	// the line without reference is identified by its content
+	want[1].UniqueIdentifier = contentIDs{}.id(want[1])
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("ReadRow() mismatch, (-want,+got):\n%s", diff)
+	}
+	if diff := cmp.Diff([]int{6, 9, 11}, lines); diff != "" {
+		t.Errorf("lines mismatch, (-want,+got):\n%s", diff)
+	}
+
+	// the closing balance follows the statement lines
+	balance, ok := reader.(BalanceReader).Balance()
+	if !ok {
+		t.Fatalf("Balance should be found")
+	}
+	// the total covers the line out of the date range too
+	total := testutils.NewDecimal(t, 8800, 2)
+	wantBalance := Balance{Opening: testutils.NewDecimal(t, 100000, 2), Closing: testutils.NewDecimal(t, 108800, 2), Total: &total}
+	if diff := cmp.Diff(wantBalance, balance); diff != "" {
+		t.Errorf("Balance mismatch, (-want,+got):\n%s", diff)
+	}
+}
+
+func TestMT940ParserEntryDate(t *testing.T) {
+	start, end := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), time.Date(2025, 12, 31, 0, 0, 0, 0, time.UTC)
+	data := ":60F:C241231IDR0,\n:61:2412310102C1,NTRFTRX1\n:61:2501021231D1,NTRFTRX2\n:62F:C250102IDR0,\n"
+	parser, err := NewMT940Parser(strings.NewReader(data), start, end, MT940Options{EntryDate: true})
+	if err != nil {
+		t.Fatalf("NewMT940Parser failed: %v", err)
+	}
+
+	var got []string
+	for {
+		stmt, err := parser.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("unwanted error: %v", err)
+		}
+		got = append(got, stmt.UniqueIdentifier+" "+stmt.Date.Format(time.DateOnly))
+	}
+
+	// the entry dates cross the year of the value dates
+	want := []string{"TRX1 2025-01-02", "TRX2 2024-12-31"}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Read() mismatch, (-want,+got):\n%s", diff)
+	}
+}
+
+func TestMT940ParserNarrativeDash(t *testing.T) {
+	start, end := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC), time.Date(2025, 12, 31, 0, 0, 0, 0, time.UTC)
+	data := "{4:\n:60F:C250309IDR0,\n:61:2503100310C1,NTRFTRX1\n:86:TRANSFER\n-REF 123\n:62F:C250310IDR1,\n-}{5:{CHK:123}}\n"
+	parser, err := NewMT940Parser(strings.NewReader(data), start, end, MT940Options{})
+	if err != nil {
+		t.Fatalf("NewMT940Parser failed: %v", err)
+	}
+
+	stmt, err := parser.Read()
+	if err != nil {
+		t.Fatalf("unwanted error: %v", err)
+	}
+	// the narrative line starting with "-" doesn't end the message
+	if stmt.Description != "TRANSFER -REF 123" {
+		t.Errorf("Description mismatch, got %q", stmt.Description)
+	}
+	if _, err := parser.Read(); err != io.EOF {
+		t.Fatalf("want EOF, got %v", err)
+	}
+	if balance, ok := parser.Balance(); !ok || !balance.Closing.Equal(testutils.NewDecimal(t, 1, 0)) {
+		t.Errorf("Balance mismatch, got %+v", balance)
+	}
+}
+
+func TestMT940ParserStreams(t *testing.T) {
+	start, end := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC), time.Date(2025, 12, 31, 0, 0, 0, 0, time.UTC)
+	errRead := errors.New("read failed")
+	data := ":60F:C250309IDR0,\n:61:2503100310C1,NTRFTRX1\n:86:FIRST\n:61:2503100310C1,NTRFTRX2\n"
+	parser, err := NewMT940Parser(io.MultiReader(strings.NewReader(data), iotest.ErrReader(errRead)), start, end, MT940Options{})
+	if err != nil {
+		t.Fatalf("NewMT940Parser failed: %v", err)
+	}
+
+	// the statement lines are read before the rest of the file
+	stmt, err := parser.Read()
+	if err != nil {
+		t.Fatalf("unwanted error: %v", err)
+	}
+	if stmt.UniqueIdentifier != "TRX1" || stmt.Description != "FIRST" {
+		t.Errorf("Read() mismatch, got %+v", stmt)
+	}
+	if _, err := parser.Read(); !errors.Is(err, errRead) {
+		t.Errorf("Read() should fail with the read error, got %v", err)
+	}
+}
+
+func TestMT940ParserInvalid(t *testing.T) {
+	start, end := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC), time.Date(2025, 12, 31, 0, 0, 0, 0, time.UTC)
+	parser, err := NewMT940Parser(strings.NewReader(":61:250310X1,NTRFTRX1\n:61:2503101,NTRFTRX2\n"), start, end, MT940Options{})
+	if err != nil {
+		t.Fatalf("NewMT940Parser failed: %v", err)
+	}
+
+	if _, err := parser.Read(); !errors.Is(err, ErrUnknownIndicator) {
+		t.Errorf("Read() should fail with ErrUnknownIndicator, got %v", err)
+	}
+	if _, err := parser.Read(); !errors.Is(err, ErrUnknownIndicator) {
+		t.Errorf("Read() should fail with ErrUnknownIndicator, got %v", err)
+	}
+	if _, err := parser.Read(); err != io.EOF {
+		t.Errorf("Read() should be done, got %v", err)
+	}
+}
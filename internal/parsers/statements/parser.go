@@ -1,39 +1,272 @@
 package statements
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
 	"io"
+	"slices"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/govalues/decimal"
 	csvparser "github.com/rickyson96/amartha-reconciliation-service/internal/csv_parser"
+	"github.com/rickyson96/amartha-reconciliation-service/internal/parsers/transactions"
+)
+
+var (
+	ErrUnknownIndicator = errors.New("unknown debit/credit indicator")
+	ErrMissingAmount    = errors.New("both debit and credit amount are empty")
+	ErrMissingColumn    = errors.New("missing column")
 )
 
 type (
 	Statement struct {
 		UniqueIdentifier string
-		Amount           decimal.Decimal
-		Date             time.Time
+		// Amount is negative for debits
+		Amount      decimal.Decimal
+		Date        time.Time
+		Description string
+		Type        transactions.TransactionType
+		// Reference is the structured reference provided by the bank,
+		// e.g. the end to end id of camt statements
+		Reference string
+		// Pending reports whether the bank hasn't booked the line yet,
+		// e.g. the pending entries of camt intraday reports
+		Pending bool
+	}
+
+	// Format describes the column layout of a statement csv.
+	//
+	// Columns are zero based index, a negative column means that the
+	// statement doesn't provide the data.
+	//
+	// The statement type is determined by the first available option:
+	//  1. split columns, when both DebitAmountColumn and CreditAmountColumn are set
+	//  2. indicator column, when TypeColumn is set
+	//  3. the sign of the amount
+	Format struct {
+		UniqueIdentifierColumn int `json:"uniqueIdentifierColumn"`
+		AmountColumn           int `json:"amountColumn"`
+		DateColumn             int `json:"dateColumn"`
+		DescriptionColumn      int `json:"descriptionColumn"`
+		FieldPerRow            int `json:"fieldPerRow"`
+
+		TypeColumn int `json:"typeColumn"`
+		// DebitIndicators and CreditIndicators are the case-insensitive
+		// values of TypeColumn, defaults to [DefaultDebitIndicators]
+		// and [DefaultCreditIndicators].
+		DebitIndicators  []string `json:"debitIndicators"`
+		CreditIndicators []string `json:"creditIndicators"`
+
+		DebitAmountColumn  int `json:"debitAmountColumn"`
+		CreditAmountColumn int `json:"creditAmountColumn"`
+
+		// Type is the file type, one of [FileTypeCSV], [FileTypeXLSX],
+		// [FileTypeCamt], [FileTypeMT940] or [FileTypeFixed].
+		// Defaults to the file extension, see [Format.FileType].
+		Type string `json:"type"`
+		// Dialect is the delimiter, charset, etc. of the csv
+		csvparser.Dialect
+		// XLSX selects the sheet and its rows of the spreadsheet
+		XLSX csvparser.XLSXOptions `json:"xlsx"`
+		// Camt configures the camt.053 and camt.052 statements
+		Camt CamtOptions `json:"camt"`
+		// MT940 configures the SWIFT MT940 statements
+		MT940 MT940Options `json:"mt940"`
+		// FixedWidth is the record layout of the fixed-width files
+		FixedWidth csvparser.FixedWidthLayout `json:"fixedWidth"`
 	}
 )
 
-func parse(data []string) (Statement, error) {
-	amount, err := decimal.Parse(data[1])
+// BookingType is the type the statement is booked as, either credit or
+// debit. The formats set the type along with the amount sign, while the
+// statements built without a format, whose zero type is credit, are typed
+// by the sign of their amount.
+func (s Statement) BookingType() transactions.TransactionType {
+	if s.Type == transactions.TransactionTypeCredit && s.Amount.IsNeg() {
+		return transactions.TransactionTypeDebit
+	}
+	return s.Type.BookingType()
+}
+
+var (
+	DefaultDebitIndicators  = []string{"D", "DB", "DR", "DEBIT"}
+	DefaultCreditIndicators = []string{"C", "CR", "CREDIT"}
+)
+
+// DefaultFormat is the layout described in the data model, which
+// doesn't contain any description.
+var DefaultFormat = Format{
+	UniqueIdentifierColumn: 0,
+	AmountColumn:           1,
+	DateColumn:             2,
+	DescriptionColumn:      -1,
+	FieldPerRow:            3,
+	TypeColumn:             -1,
+	DebitAmountColumn:      -1,
+	CreditAmountColumn:     -1,
+}
+
+// columns returns the number of columns read by the format
+func (f Format) columns() int {
+	columns := max(f.UniqueIdentifierColumn, f.DateColumn, f.DescriptionColumn)
+	if f.DebitAmountColumn >= 0 && f.CreditAmountColumn >= 0 {
+		columns = max(columns, f.DebitAmountColumn, f.CreditAmountColumn)
+	} else {
+		columns = max(columns, f.AmountColumn, f.TypeColumn)
+	}
+	return columns + 1
+}
+
+func (f Format) parse(data []string) (Statement, error) {
+	if len(data) < f.columns() {
+		return Statement{}, fmt.Errorf("%w: the row has %d columns, the format reads %d", ErrMissingColumn, len(data), f.columns())
+	}
+
+	amount, stmtType, err := f.parseAmount(data)
 	if err != nil {
 		return Statement{}, err
 	}
 
-	statementTime, err := time.Parse(time.DateOnly, data[2])
+	statementTime, err := parseDate(data[f.DateColumn])
 	if err != nil {
 		return Statement{}, err
 	}
 
+	var description string
+	if f.DescriptionColumn >= 0 {
+		description = data[f.DescriptionColumn]
+	}
+
 	return Statement{
-		UniqueIdentifier: data[0],
+		UniqueIdentifier: data[f.UniqueIdentifierColumn],
 		Amount:           amount,
 		Date:             statementTime,
+		Description:      description,
+		Type:             stmtType,
 	}, nil
 }
 
+// parseDate parses the statement date, dropping the time when it has
+// one, e.g. booking time of spreadsheet statements
+func parseDate(value string) (time.Time, error) {
+	date, err := time.Parse(time.DateOnly, value)
+	if err == nil {
+		return date, nil
+	}
+
+	dateTime, dtErr := time.Parse(time.DateTime, value)
+	if dtErr != nil {
+		return time.Time{}, err
+	}
+	return dateTime.Truncate(24 * time.Hour), nil
+}
+
+// parseAmount returns the signed amount along with the statement type
+func (f Format) parseAmount(data []string) (decimal.Decimal, transactions.TransactionType, error) {
+	if f.DebitAmountColumn >= 0 && f.CreditAmountColumn >= 0 {
+		return f.parseSplitAmount(data)
+	}
+
+	amount, err := decimal.Parse(data[f.AmountColumn])
+	if err != nil {
+		return decimal.Decimal{}, 0, err
+	}
+
+	if f.TypeColumn < 0 {
+		if amount.IsNeg() {
+			return amount, transactions.TransactionTypeDebit, nil
+		}
+		return amount, transactions.TransactionTypeCredit, nil
+	}
+
+	stmtType, err := f.parseIndicator(data[f.TypeColumn])
+	if err != nil {
+		return decimal.Decimal{}, 0, err
+	}
+
+	return signed(amount, stmtType), stmtType, nil
+}
+
+func (f Format) parseSplitAmount(data []string) (decimal.Decimal, transactions.TransactionType, error) {
+	columns := []struct {
+		column   int
+		stmtType transactions.TransactionType
+	}{
+		{f.DebitAmountColumn, transactions.TransactionTypeDebit},
+		{f.CreditAmountColumn, transactions.TransactionTypeCredit},
+	}
+
+	for _, c := range columns {
+		value := strings.TrimSpace(data[c.column])
+		if value == "" {
+			continue
+		}
+
+		amount, err := decimal.Parse(value)
+		if err != nil {
+			return decimal.Decimal{}, 0, err
+		}
+
+		if amount.IsZero() {
+			continue
+		}
+
+		return signed(amount, c.stmtType), c.stmtType, nil
+	}
+
+	return decimal.Decimal{}, 0, ErrMissingAmount
+}
+
+func (f Format) parseIndicator(indicator string) (transactions.TransactionType, error) {
+	debits, credits := f.DebitIndicators, f.CreditIndicators
+	if debits == nil {
+		debits = DefaultDebitIndicators
+	}
+	if credits == nil {
+		credits = DefaultCreditIndicators
+	}
+
+	indicator = strings.TrimSpace(indicator)
+	equalFold := func(s string) bool { return strings.EqualFold(s, indicator) }
+	switch {
+	case slices.ContainsFunc(debits, equalFold):
+		return transactions.TransactionTypeDebit, nil
+	case slices.ContainsFunc(credits, equalFold):
+		return transactions.TransactionTypeCredit, nil
+	default:
+		return 0, fmt.Errorf("%q is %w", indicator, ErrUnknownIndicator)
+	}
+}
+
+// signed makes the amount negative for debits
+func signed(amount decimal.Decimal, stmtType transactions.TransactionType) decimal.Decimal {
+	if stmtType == transactions.TransactionTypeDebit {
+		return amount.Abs().Neg()
+	}
+	return amount.Abs()
+}
+
+// contentIDs identifies the lines without reference by their content, so
+// the same line on the overlapping files of an account gets the same id.
+// The lines of the same content are told apart by their occurrence.
+type contentIDs map[string]int
+
+func (ids contentIDs) id(stmt Statement) string {
+	content := fmt.Sprintf("%s|%s|%s|%s", stmt.Date.Format(time.DateOnly), stmt.Type, stmt.Amount.Trim(0), stmt.Description)
+	ids[content]++
+
+	sum := sha256.Sum256([]byte(content))
+	id := "entry-" + hex.EncodeToString(sum[:8])
+	if n := ids[content]; n > 1 {
+		id += "-" + strconv.Itoa(n)
+	}
+	return id
+}
+
 func filter(startDate, endDate time.Time) func(data Statement) bool {
 	return func(data Statement) bool {
 		if data.Date.Before(startDate) || data.Date.After(endDate) {
@@ -43,13 +276,21 @@ func filter(startDate, endDate time.Time) func(data Statement) bool {
 	}
 }
 
-func NewCSVParser(filepath io.Reader, startDate, endDate time.Time) *csvparser.CSVParser[Statement] {
+// NewCSVParser creates statement parser for csv following [DefaultFormat]
+func NewCSVParser(file io.Reader, startDate, endDate time.Time) *csvparser.CSVParser[Statement] {
+	return NewCSVParserWithFormat(file, startDate, endDate, DefaultFormat)
+}
+
+// NewCSVParserWithFormat creates statement parser for csv with the
+// given column layout
+func NewCSVParserWithFormat(file io.Reader, startDate, endDate time.Time, format Format) *csvparser.CSVParser[Statement] {
 	return csvparser.NewCSVParser(
-		filepath,
-		parse,
+		file,
+		format.parse,
 		filter(startDate, endDate),
 		csvparser.CSVParserOptions{
 			ContainsHeader: true,
-			FieldPerRow:    3,
+			FieldPerRow:    format.FieldPerRow,
+			Dialect:        format.Dialect,
 		})
 }
@@ -5,6 +5,9 @@ import (
 	"time"
 
 	"github.com/google/go-cmp/cmp"
+	"github.com/govalues/decimal"
+	csvparser "github.com/rickyson96/amartha-reconciliation-service/internal/csv_parser"
+	"github.com/rickyson96/amartha-reconciliation-service/internal/parsers/transactions"
 	"github.com/rickyson96/amartha-reconciliation-service/internal/testutils"
 )
 
@@ -32,6 +35,17 @@ func TestParse(t *testing.T) {
 				UniqueIdentifier: "1",
 				Amount:           testutils.NewDecimal(t, -10, 0),
 				Date:             time.Date(2025, 01, 02, 0, 0, 0, 0, time.UTC),
+				Type:             transactions.TransactionTypeDebit,
+			},
+			wantErr: false,
+		},
+		{
+			name: "date with time",
+			data: []string{"1", "10", "2025-01-02 13:45:00"},
+			want: Statement{
+				UniqueIdentifier: "1",
+				Amount:           testutils.NewDecimal(t, 10, 0),
+				Date:             time.Date(2025, 01, 02, 0, 0, 0, 0, time.UTC),
 			},
 			wantErr: false,
 		},
@@ -41,7 +55,7 @@ func TestParse(t *testing.T) {
 
 	for _, test := range tests {
 		t.Run(test.name, func(t *testing.T) {
-			got, err := parse(test.data)
+			got, err := DefaultFormat.parse(test.data)
 			if test.wantErr != (err != nil) {
 				t.Errorf("wantErr is %t, but err is %v", test.wantErr, err)
 			}
@@ -53,6 +67,161 @@ func TestParse(t *testing.T) {
 	}
 }
 
+func TestFormatParse(t *testing.T) {
+	format := Format{
+		UniqueIdentifierColumn: 1,
+		AmountColumn:           3,
+		DateColumn:             0,
+		DescriptionColumn:      2,
+		FieldPerRow:            4,
+		TypeColumn:             -1,
+		DebitAmountColumn:      -1,
+		CreditAmountColumn:     -1,
+	}
+	tests := []struct {
+		name    string
+		data    []string
+		want    Statement
+		wantErr bool
+	}{
+		{
+			name: "success with description",
+			data: []string{"2025-01-02", "1", "TRF REF:ABC-1", "-10"},
+			want: Statement{
+				UniqueIdentifier: "1",
+				Amount:           testutils.NewDecimal(t, -10, 0),
+				Date:             time.Date(2025, 01, 02, 0, 0, 0, 0, time.UTC),
+				Description:      "TRF REF:ABC-1",
+				Type:             transactions.TransactionTypeDebit,
+			},
+			wantErr: false,
+		},
+		{"wrong amount", []string{"2025-01-02", "1", "desc", "woo"}, Statement{}, true},
+		{"wrong date", []string{"woo", "1", "desc", "10"}, Statement{}, true},
+		{"missing column", []string{"2025-01-02", "1", "desc"}, Statement{}, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := format.parse(test.data)
+			if test.wantErr != (err != nil) {
+				t.Errorf("wantErr is %t, but err is %v", test.wantErr, err)
+			}
+
+			if diff := cmp.Diff(test.want, got); diff != "" {
+				t.Errorf("parse(%v) mismatch, (-want,+got):\n%s", test.data, diff)
+			}
+		})
+	}
+}
+
+func TestFormatParseType(t *testing.T) {
+	indicatorFormat := Format{
+		UniqueIdentifierColumn: 0,
+		AmountColumn:           1,
+		DateColumn:             2,
+		DescriptionColumn:      -1,
+		TypeColumn:             3,
+		DebitAmountColumn:      -1,
+		CreditAmountColumn:     -1,
+	}
+	customIndicatorFormat := indicatorFormat
+	customIndicatorFormat.DebitIndicators = []string{"K"}
+	customIndicatorFormat.CreditIndicators = []string{"M"}
+	splitFormat := Format{
+		UniqueIdentifierColumn: 0,
+		AmountColumn:           -1,
+		DateColumn:             1,
+		DescriptionColumn:      -1,
+		TypeColumn:             -1,
+		DebitAmountColumn:      2,
+		CreditAmountColumn:     3,
+	}
+
+	tests := []struct {
+		name       string
+		format     Format
+		data       []string
+		wantAmount decimal.Decimal
+		wantType   transactions.TransactionType
+		wantErr    bool
+	}{
+		{"indicator debit", indicatorFormat, []string{"1", "10", "2025-01-02", "DB"}, testutils.NewDecimal(t, -10, 0), transactions.TransactionTypeDebit, false},
+		{"indicator credit lowercase", indicatorFormat, []string{"1", "10", "2025-01-02", " cr "}, testutils.NewDecimal(t, 10, 0), transactions.TransactionTypeCredit, false},
+		{"indicator overrides sign", indicatorFormat, []string{"1", "-10", "2025-01-02", "C"}, testutils.NewDecimal(t, 10, 0), transactions.TransactionTypeCredit, false},
+		{"unknown indicator", indicatorFormat, []string{"1", "10", "2025-01-02", "X"}, decimal.Decimal{}, 0, true},
+		{"custom indicator", customIndicatorFormat, []string{"1", "10", "2025-01-02", "K"}, testutils.NewDecimal(t, -10, 0), transactions.TransactionTypeDebit, false},
+		{"custom indicator replaces default", customIndicatorFormat, []string{"1", "10", "2025-01-02", "D"}, decimal.Decimal{}, 0, true},
+		{"split debit", splitFormat, []string{"1", "2025-01-02", "10", ""}, testutils.NewDecimal(t, -10, 0), transactions.TransactionTypeDebit, false},
+		{"split credit", splitFormat, []string{"1", "2025-01-02", "0.00", "10"}, testutils.NewDecimal(t, 10, 0), transactions.TransactionTypeCredit, false},
+		{"split empty", splitFormat, []string{"1", "2025-01-02", "", " "}, decimal.Decimal{}, 0, true},
+		{"split wrong amount", splitFormat, []string{"1", "2025-01-02", "woo", ""}, decimal.Decimal{}, 0, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := test.format.parse(test.data)
+			if test.wantErr != (err != nil) {
+				t.Errorf("wantErr is %t, but err is %v", test.wantErr, err)
+			}
+
+			if diff := cmp.Diff(test.wantAmount, got.Amount); diff != "" {
+				t.Errorf("parse(%v) amount mismatch, (-want,+got):\n%s", test.data, diff)
+			}
+			if diff := cmp.Diff(test.wantType, got.Type); diff != "" {
+				t.Errorf("parse(%v) type mismatch, (-want,+got):\n%s", test.data, diff)
+			}
+		})
+	}
+}
+
+func TestStatementBookingType(t *testing.T) {
+	tests := []struct {
+		name   string
+		amount int64
+		typ    transactions.TransactionType
+		want   transactions.TransactionType
+	}{
+		{"no type credit", 10, 0, transactions.TransactionTypeCredit},
+		// the zero type doesn't turn a debit into a credit
+		{"no type debit", -10, 0, transactions.TransactionTypeDebit},
+		{"explicit debit", 10, transactions.TransactionTypeDebit, transactions.TransactionTypeDebit},
+		{"explicit credit", 10, transactions.TransactionTypeCredit, transactions.TransactionTypeCredit},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			stmt := Statement{Amount: testutils.NewDecimal(t, test.amount, 0), Type: test.typ}
+			if got := stmt.BookingType(); got != test.want {
+				t.Errorf("BookingType() = %v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestFormatFileType(t *testing.T) {
+	tests := []struct {
+		source string
+		format Format
+		want   string
+	}{
+		{"bank1.csv", DefaultFormat, FileTypeCSV},
+		{"data/bank4.XLSX", DefaultFormat, FileTypeXLSX},
+		{"bank.zip/bank4.xlsx.gz", DefaultFormat, FileTypeXLSX},
+		{"bank4.dat", Format{Type: "XLSX"}, FileTypeXLSX},
+		{"bank5.STA", DefaultFormat, FileTypeMT940},
+		{"bank6.txt", Format{FixedWidth: csvparser.FixedWidthLayout{Detail: csvparser.FixedWidthRecord{Fields: []csvparser.FixedWidthField{{Start: 1}}}}}, FileTypeFixed},
+	}
+
+	for _, test := range tests {
+		t.Run(test.source, func(t *testing.T) {
+			if got := test.format.FileType(test.source); got != test.want {
+				t.Errorf("FileType(%q) = %q, want %q", test.source, got, test.want)
+			}
+		})
+	}
+}
+
 func TestFilter(t *testing.T) {
 	type input struct {
 		startDate string
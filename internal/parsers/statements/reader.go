@@ -0,0 +1,108 @@
+package statements
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+	"time"
+
+	csvparser "github.com/rickyson96/amartha-reconciliation-service/internal/csv_parser"
+)
+
+const (
+	FileTypeCSV   = "csv"
+	FileTypeXLSX  = "xlsx"
+	FileTypeCamt  = "camt"
+	FileTypeMT940 = "mt940"
+	FileTypeFixed = "fixed"
+)
+
+var ErrUnknownFileType = errors.New("unknown statement file type")
+
+// Reader reads the statements one at a time, see [csvparser.CSVParser]
+type Reader interface {
+	Read() (Statement, error)
+	ReadRow() (csvparser.Row[Statement], error)
+}
+
+// BalanceReader is the [Reader] of the files carrying their balance
+type BalanceReader interface {
+	Reader
+	// Balance returns the opening and closing balance of the file, if
+	// the file has them. The closing balance may follow the statements,
+	// so the balance is complete once the file is read.
+	Balance() (Balance, bool)
+}
+
+// FileType returns the file type of the source, detected by its
+// extension unless the format declares it or its fixed-width layout
+func (f Format) FileType(source string) string {
+	if f.Type != "" {
+		return strings.ToLower(f.Type)
+	}
+	if !f.FixedWidth.IsZero() {
+		return FileTypeFixed
+	}
+
+	// compressed sources are decompressed while being read
+	name := strings.ToLower(path.Base(source))
+	name = strings.TrimSuffix(strings.TrimSuffix(name, ".gz"), ".bz2")
+	switch path.Ext(name) {
+	case ".xlsx":
+		return FileTypeXLSX
+	case ".xml":
+		return FileTypeCamt
+	case ".sta", ".mt940", ".940":
+		return FileTypeMT940
+	default:
+		return FileTypeCSV
+	}
+}
+
+// NewReader creates the statement reader of the source following its
+// file type, see [Format.FileType]
+func NewReader(source string, file io.Reader, startDate, endDate time.Time, format Format) (Reader, error) {
+	switch fileType := format.FileType(source); fileType {
+	case FileTypeCSV:
+		return NewCSVParserWithFormat(file, startDate, endDate, format), nil
+	case FileTypeXLSX:
+		return NewXLSXParserWithFormat(file, startDate, endDate, format)
+	case FileTypeCamt:
+		return NewCamtParser(file, startDate, endDate, format.Camt)
+	case FileTypeMT940:
+		return NewMT940Parser(file, startDate, endDate, format.MT940)
+	case FileTypeFixed:
+		return NewFixedWidthParserWithFormat(file, startDate, endDate, format)
+	default:
+		return nil, fmt.Errorf("%q is %w", fileType, ErrUnknownFileType)
+	}
+}
+
+// NewXLSXParserWithFormat creates statement parser for the spreadsheet
+// with the given column layout, where the date column may hold Excel
+// serial dates
+func NewXLSXParserWithFormat(file io.Reader, startDate, endDate time.Time, format Format) (*csvparser.CSVParser[Statement], error) {
+	options := format.XLSX
+	options.FieldPerRow = format.FieldPerRow
+	options.DateColumns = []int{format.DateColumn}
+
+	rows, err := csvparser.NewXLSXReader(file, options)
+	if err != nil {
+		return nil, err
+	}
+
+	return csvparser.NewRowParser(rows, format.parse, filter(startDate, endDate), true), nil
+}
+
+// NewFixedWidthParserWithFormat creates statement parser for the
+// fixed-width file, where the columns are the detail fields of the layout
+func NewFixedWidthParserWithFormat(file io.Reader, startDate, endDate time.Time, format Format) (*csvparser.CSVParser[Statement], error) {
+	if fields := len(format.FixedWidth.Detail.Fields); fields < format.columns() {
+		return nil, fmt.Errorf("%w: the fixed-width detail has %d fields, the format reads %d", ErrMissingColumn, fields, format.columns())
+	}
+
+	rows := csvparser.NewFixedWidthReader(file, format.FixedWidth)
+	return csvparser.NewRowParser(rows, format.parse, filter(startDate, endDate), false), nil
+}
@@ -0,0 +1,148 @@
+package transactions
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"io"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/rickyson96/amartha-reconciliation-service/internal/testutils"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+func TestNewDatabaseReader(t *testing.T) {
+	db, err := sql.Open("sqlite3", filepath.Join(t.TempDir(), "ledger.db"))
+	if err != nil {
+		t.Fatalf("failed opening database: %v", err)
+	}
+	defer db.Close()
+
+	_, err = db.Exec(`
+		CREATE TABLE ledger (id TEXT, amount NUMERIC, kind TEXT, created_at DATETIME, original_id TEXT);
+		INSERT INTO ledger VALUES
+			('TRX0', 1, 'CREDIT', '2025-10-01 00:00:00', NULL),
+			('TRX1', 100.5, 'CREDIT', '2025-10-01 11:12:13', NULL),
+			('TRX2', 10, 'REVERSAL', '2025-10-31 23:59:59', 'TRX1'),
+			('TRX3', 7, 'DEBIT', '2025-11-01 00:00:00', NULL),
+			('TRX4', 5, 'DEBIT', '2025-09-30 23:59:59', NULL);`)
+	if err != nil {
+		t.Fatalf("failed creating ledger: %v", err)
+	}
+
+	format := Format{
+		TrxIDColumn:           0,
+		AmountColumn:          1,
+		TypeColumn:            2,
+		TransactionTimeColumn: 3,
+		ReferenceColumn:       4,
+	}
+	start, end := time.Date(2025, 10, 1, 0, 0, 0, 0, time.UTC), time.Date(2025, 10, 31, 0, 0, 0, 0, time.UTC)
+
+	// the rows at the start date midnight and the day after the end date
+	// are on their side of the bounds, regardless of the parser filter
+	rows, err := db.Query("SELECT id FROM ledger WHERE created_at >= ? AND created_at < ? ORDER BY id", queryBounds("sqlite3", start, end)...)
+	if err != nil {
+		t.Fatalf("failed querying ledger: %v", err)
+	}
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			t.Fatalf("failed scanning ledger: %v", err)
+		}
+		ids = append(ids, id)
+	}
+	rows.Close()
+	if diff := cmp.Diff([]string{"TRX0", "TRX1", "TRX2"}, ids); diff != "" {
+		t.Errorf("rows within the bounds mismatch, (-want,+got):\n%s", diff)
+	}
+
+	query := "SELECT id, amount, kind, created_at, original_id FROM ledger WHERE created_at >= ? AND created_at < ? ORDER BY id"
+	parser, err := NewDatabaseReader(context.Background(), db, "sqlite3", query, start, end, format)
+	if err != nil {
+		t.Fatalf("NewDatabaseReader failed: %v", err)
+	}
+
+	var got []Transaction
+	var lines []int
+	for {
+		row, err := parser.ReadRow()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("unwanted error: %v", err)
+		}
+		got = append(got, row.Data)
+		lines = append(lines, row.Line)
+	}
+
+	want := []Transaction{{
+		TrxID:           "TRX0",
+		Amount:          testutils.NewDecimal(t, 1, 0),
+		Type:            TransactionTypeCredit,
+		TransactionTime: time.Date(2025, 10, 1, 0, 0, 0, 0, time.UTC),
+	}, {
+		TrxID:           "TRX1",
+		Amount:          testutils.NewDecimal(t, 1005, 1),
+		Type:            TransactionTypeCredit,
+		TransactionTime: time.Date(2025, 10, 1, 11, 12, 13, 0, time.UTC),
+	}, {
+		TrxID:           "TRX2",
+		Amount:          testutils.NewDecimal(t, 10, 0),
+		Type:            TransactionTypeReversal,
+		TransactionTime: time.Date(2025, 10, 31, 23, 59, 59, 0, time.UTC),
+		Reference:       "TRX1",
+	}}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("ReadRow() mismatch, (-want,+got):\n%s", diff)
+	}
+	if diff := cmp.Diff([]int{1, 2, 3}, lines); diff != "" {
+		t.Errorf("lines mismatch, (-want,+got):\n%s", diff)
+	}
+}
+
+func TestNewDatabaseReaderClose(t *testing.T) {
+	db, err := sql.Open("sqlite3", filepath.Join(t.TempDir(), "ledger.db"))
+	if err != nil {
+		t.Fatalf("failed opening database: %v", err)
+	}
+	defer db.Close()
+
+	_, err = db.Exec(`
+		CREATE TABLE ledger (id TEXT, amount TEXT, kind TEXT, created_at DATETIME);
+		INSERT INTO ledger VALUES
+			('TRX1', 'oops', 'CREDIT', '2025-10-01 11:12:13'),
+			('TRX2', '10', 'DEBIT', '2025-10-02 11:12:13');`)
+	if err != nil {
+		t.Fatalf("failed creating ledger: %v", err)
+	}
+	start, end := time.Date(2025, 10, 1, 0, 0, 0, 0, time.UTC), time.Date(2025, 10, 31, 0, 0, 0, 0, time.UTC)
+
+	_, err = NewDatabaseReader(context.Background(), db, "sqlite3", "SELECT id, amount, kind FROM ledger", start, end, DefaultFormat)
+	if !errors.Is(err, ErrMissingColumn) {
+		t.Errorf("want ErrMissingColumn, got %v", err)
+	}
+	if inUse := db.Stats().InUse; inUse != 0 {
+		t.Errorf("want the connection released on missing columns, %d in use", inUse)
+	}
+
+	parser, err := NewDatabaseReader(context.Background(), db, "sqlite3", "SELECT id, amount, kind, created_at FROM ledger ORDER BY id", start, end, DefaultFormat)
+	if err != nil {
+		t.Fatalf("NewDatabaseReader failed: %v", err)
+	}
+	if _, err := parser.Read(); err == nil {
+		t.Fatalf("want error on the invalid amount")
+	}
+	if err := parser.Close(); err != nil {
+		t.Errorf("Close failed: %v", err)
+	}
+	if inUse := db.Stats().InUse; inUse != 0 {
+		t.Errorf("want the connection released on close, %d in use", inUse)
+	}
+}
@@ -1,16 +1,24 @@
 package transactions
 
 import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
 	"io"
+	"slices"
+	"strings"
 	"time"
 
 	"github.com/govalues/decimal"
 	csvparser "github.com/rickyson96/amartha-reconciliation-service/internal/csv_parser"
 )
 
-//go:generate go-enum --alias=CREDIT:Credit,DEBIT:Debit
+var ErrMissingColumn = errors.New("missing column")
+
+//go:generate go-enum --alias=CREDIT:Credit,DEBIT:Debit,REVERSAL:Reversal,FEE:Fee,REFUND:Refund --nocase --marshal
 type (
-	// ENUM(CREDIT, DEBIT)
+	// ENUM(CREDIT, DEBIT, REVERSAL, FEE, REFUND)
 	TransactionType int
 
 	Transaction struct {
@@ -18,49 +26,281 @@ type (
 		Amount          decimal.Decimal
 		Type            TransactionType
 		TransactionTime time.Time
+		// Reference is the TrxID this transaction refers to, e.g. the
+		// original transaction of a reversal
+		Reference string
+	}
+
+	// Format describes the column layout of a transaction csv.
+	//
+	// Columns are zero based index, a negative column means that the
+	// transaction doesn't provide the data.
+	Format struct {
+		TrxIDColumn           int `json:"trxIDColumn"`
+		AmountColumn          int `json:"amountColumn"`
+		TypeColumn            int `json:"typeColumn"`
+		TransactionTimeColumn int `json:"transactionTimeColumn"`
+		ReferenceColumn       int `json:"referenceColumn"`
+		FieldPerRow           int `json:"fieldPerRow"`
+		// TypeAliases maps the exported type, case-insensitively, into
+		// the [TransactionType]. e.g. {"CR": "CREDIT", "REV": "REVERSAL"}
+		TypeAliases map[string]TransactionType `json:"typeAliases"`
+
+		// Dialect is the delimiter, charset, etc. of the csv
+		csvparser.Dialect
+		// FixedWidth is the record layout of the fixed-width files, the
+		// columns are the detail fields of the layout
+		FixedWidth csvparser.FixedWidthLayout `json:"fixedWidth"`
+		// JSON is the paths of the fields of the json lines files
+		JSON JSONFields `json:"json"`
+	}
+
+	// JSONFields are the json paths of the transaction fields, e.g.
+	// "$.amount.value". The reference is optional.
+	JSONFields struct {
+		TrxID           string `json:"trxID"`
+		Amount          string `json:"amount"`
+		Type            string `json:"type"`
+		TransactionTime string `json:"transactionTime"`
+		Reference       string `json:"reference"`
+		// TimeLayout is the layout of the transaction time, defaults to
+		// [time.RFC3339]
+		TimeLayout string `json:"timeLayout"`
 	}
 )
 
-func parse(data []string) (Transaction, error) {
-	amount, err := decimal.Parse(data[1])
+// DefaultFormat is the layout described in the data model
+var DefaultFormat = Format{
+	TrxIDColumn:           0,
+	AmountColumn:          1,
+	TypeColumn:            2,
+	TransactionTimeColumn: 3,
+	ReferenceColumn:       -1,
+	FieldPerRow:           4,
+}
+
+// BookingType is the type the transaction is booked as on the bank
+// statement, which is either credit or debit.
+//
+// Reversal returns the money of a failed debit, so it's booked as
+// credit, unless it reverses a credit, see [Transaction.BookingType]. Fee
+// and refund take money out of the account, so they're booked as debit,
+// e.g. fee matches the bank charge lines.
+func (x TransactionType) BookingType() TransactionType {
+	switch x {
+	case TransactionTypeCredit, TransactionTypeReversal:
+		return TransactionTypeCredit
+	default:
+		return TransactionTypeDebit
+	}
+}
+
+// BookingType is the type the transaction is booked as on the bank
+// statement. Reversal of a credit carries a negative amount, so it's
+// booked as debit.
+func (t Transaction) BookingType() TransactionType {
+	if t.Type == TransactionTypeReversal && t.Amount.IsNeg() {
+		return TransactionTypeDebit
+	}
+	return t.Type.BookingType()
+}
+
+// InRange reports whether the transaction is within the dates, inclusive.
+// The dates are of the transaction time at its own offset, which are the
+// dates it's matched on.
+func (t Transaction) InRange(startDate, endDate time.Time) bool {
+	tt := t.TransactionTime
+	wall := time.Date(tt.Year(), tt.Month(), tt.Day(), tt.Hour(), tt.Minute(), tt.Second(), tt.Nanosecond(), time.UTC)
+	return !wall.Before(startDate) && wall.Before(endDate.AddDate(0, 0, 1))
+}
+
+// columns returns the number of columns read by the format
+func (f Format) columns() int {
+	return max(f.TrxIDColumn, f.AmountColumn, f.TypeColumn, f.TransactionTimeColumn, f.ReferenceColumn) + 1
+}
+
+func (f Format) parse(data []string) (Transaction, error) {
+	if len(data) < f.columns() {
+		return Transaction{}, fmt.Errorf("%w: the row has %d columns, the format reads %d", ErrMissingColumn, len(data), f.columns())
+	}
+
+	amount, err := decimal.Parse(data[f.AmountColumn])
 	if err != nil {
 		return Transaction{}, err
 	}
 
-	transactionType, err := ParseTransactionType(data[2])
+	transactionType, err := f.parseType(data[f.TypeColumn])
 	if err != nil {
 		return Transaction{}, err
 	}
 
-	transactionTime, err := time.Parse(time.DateTime, data[3])
+	transactionTime, err := time.Parse(time.DateTime, data[f.TransactionTimeColumn])
 	if err != nil {
 		return Transaction{}, err
 	}
 
+	var reference string
+	if f.ReferenceColumn >= 0 {
+		reference = data[f.ReferenceColumn]
+	}
+
 	return Transaction{
-		TrxID:           data[0],
+		TrxID:           data[f.TrxIDColumn],
 		Amount:          amount,
 		Type:            transactionType,
 		TransactionTime: transactionTime,
+		Reference:       reference,
 	}, nil
 }
 
+func (f Format) parseType(value string) (TransactionType, error) {
+	value = strings.TrimSpace(value)
+	for alias, trxType := range f.TypeAliases {
+		if strings.EqualFold(alias, value) {
+			return trxType, nil
+		}
+	}
+
+	return ParseTransactionType(value)
+}
+
 func filter(startDate, endDate time.Time) func(data Transaction) bool {
 	return func(data Transaction) bool {
-		if data.TransactionTime.Before(startDate) || !data.TransactionTime.Before(endDate.AddDate(0, 0, 1)) {
-			return false
-		}
-		return true
+		return data.InRange(startDate, endDate)
 	}
 }
 
+// NewCSVParser creates transaction parser for csv following [DefaultFormat]
 func NewCSVParser(file io.Reader, startDate, endDate time.Time) *csvparser.CSVParser[Transaction] {
+	return NewCSVParserWithFormat(file, startDate, endDate, DefaultFormat)
+}
+
+// NewCSVParserWithFormat creates transaction parser for csv with the
+// given column layout
+func NewCSVParserWithFormat(file io.Reader, startDate, endDate time.Time, format Format) *csvparser.CSVParser[Transaction] {
 	return csvparser.NewCSVParser(
 		file,
-		parse,
+		format.parse,
 		filter(startDate, endDate),
 		csvparser.CSVParserOptions{
 			ContainsHeader: true,
-			FieldPerRow:    4,
+			FieldPerRow:    format.FieldPerRow,
+			Dialect:        format.Dialect,
 		})
 }
+
+// NewReader creates transaction parser for the csv, for the json lines
+// when the format has the json paths, or for the fixed-width file when
+// the format has its layout
+func NewReader(file io.Reader, startDate, endDate time.Time, format Format) (*csvparser.CSVParser[Transaction], error) {
+	switch {
+	case !format.JSON.IsZero():
+		return NewJSONLinesParserWithFormat(file, startDate, endDate, format)
+	case !format.FixedWidth.IsZero():
+		if fields := len(format.FixedWidth.Detail.Fields); fields < format.columns() {
+			return nil, fmt.Errorf("%w: the fixed-width detail has %d fields, the format reads %d", ErrMissingColumn, fields, format.columns())
+		}
+		rows := csvparser.NewFixedWidthReader(file, format.FixedWidth)
+		return csvparser.NewRowParser(rows, format.parse, filter(startDate, endDate), false), nil
+	default:
+		return NewCSVParserWithFormat(file, startDate, endDate, format), nil
+	}
+}
+
+// IsZero reports whether none of the fields has its path
+func (f JSONFields) IsZero() bool {
+	return f == JSONFields{}
+}
+
+// NewJSONLinesParserWithFormat creates transaction parser for the
+// newline delimited json, e.g. exported by the ledger api
+func NewJSONLinesParserWithFormat(file io.Reader, startDate, endDate time.Time, format Format) (*csvparser.CSVParser[Transaction], error) {
+	paths := []string{format.JSON.TrxID, format.JSON.Amount, format.JSON.Type, format.JSON.TransactionTime}
+	if format.JSON.Reference != "" {
+		paths = append(paths, format.JSON.Reference)
+	}
+
+	rows, err := csvparser.NewJSONLinesReader(file, paths)
+	if err != nil {
+		return nil, err
+	}
+
+	return csvparser.NewRowParser(rows, format.parseJSON, filter(startDate, endDate), false), nil
+}
+
+// parseJSON parses the values of the json paths, in the order of
+// [NewJSONLinesParserWithFormat]
+func (f Format) parseJSON(data []string) (Transaction, error) {
+	layout := f.JSON.TimeLayout
+	if layout == "" {
+		layout = time.RFC3339
+	}
+	transactionTime, err := time.Parse(layout, data[3])
+	if err != nil {
+		return Transaction{}, err
+	}
+
+	columns := Format{
+		TrxIDColumn:           0,
+		AmountColumn:          1,
+		TypeColumn:            2,
+		TransactionTimeColumn: 3,
+		ReferenceColumn:       -1,
+		TypeAliases:           f.TypeAliases,
+	}
+	if len(data) > 4 {
+		columns.ReferenceColumn = 4
+	}
+
+	// the record is kept as read, the time is parsed with its offset
+	values := slices.Clone(data)
+	values[3] = transactionTime.Format(time.DateTime)
+	trx, err := columns.parse(values)
+	if err != nil {
+		return Transaction{}, err
+	}
+	trx.TransactionTime = transactionTime
+	return trx, nil
+}
+
+// driverSQLite is the sqlite3 driver, whose datetimes are compared as text
+const driverSQLite = "sqlite3"
+
+// NewDatabaseReader runs the query and creates transaction parser for its
+// rows, where the columns are the selected columns. The parameters of
+// the query are the start date and the day after the end date, whose
+// placeholders follow the driver, e.g.
+// "WHERE created_at >= $1 AND created_at < $2" for postgres and
+// "WHERE created_at >= ? AND created_at < ?" for mysql and sqlite3.
+//
+// The rows are held until the parser is closed, see [csvparser.CSVParser.Close].
+func NewDatabaseReader(ctx context.Context, db *sql.DB, driver, query string, startDate, endDate time.Time, format Format) (*csvparser.CSVParser[Transaction], error) {
+	rows, err := db.QueryContext(ctx, query, queryBounds(driver, startDate, endDate)...)
+	if err != nil {
+		return nil, err
+	}
+
+	sqlRows, err := csvparser.NewSQLRows(rows)
+	if err != nil {
+		rows.Close()
+		return nil, err
+	}
+	if columns := sqlRows.Columns(); columns < format.columns() {
+		sqlRows.Close()
+		return nil, fmt.Errorf("%w: the query selects %d columns, the format reads %d", ErrMissingColumn, columns, format.columns())
+	}
+
+	return csvparser.NewRowParser(sqlRows, format.parse, filter(startDate, endDate), false), nil
+}
+
+// queryBounds returns the parameters of the query. SQLite stores the
+// datetimes as text, e.g. "2025-10-01 00:00:00", so its bounds are
+// formatted the same way rather than by the driver, which adds the offset
+// and puts the rows at the bounds on the wrong side of the comparison.
+func queryBounds(driver string, startDate, endDate time.Time) []any {
+	end := endDate.AddDate(0, 0, 1)
+	if driver == driverSQLite {
+		return []any{startDate.Format(time.DateTime), end.Format(time.DateTime)}
+	}
+	return []any{startDate, end}
+}
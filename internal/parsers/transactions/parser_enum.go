@@ -9,6 +9,7 @@ package transactions
 import (
 	"errors"
 	"fmt"
+	"strings"
 )
 
 const (
@@ -16,15 +17,24 @@ const (
 	TransactionTypeCredit TransactionType = iota
 	// TransactionTypeDebit is a TransactionType of type DEBIT.
 	TransactionTypeDebit
+	// TransactionTypeReversal is a TransactionType of type REVERSAL.
+	TransactionTypeReversal
+	// TransactionTypeFee is a TransactionType of type FEE.
+	TransactionTypeFee
+	// TransactionTypeRefund is a TransactionType of type REFUND.
+	TransactionTypeRefund
 )
 
 var ErrInvalidTransactionType = errors.New("not a valid TransactionType")
 
-const _TransactionTypeName = "CREDITDEBIT"
+const _TransactionTypeName = "CREDITDEBITREVERSALFEEREFUND"
 
 var _TransactionTypeMap = map[TransactionType]string{
-	TransactionTypeCredit: _TransactionTypeName[0:6],
-	TransactionTypeDebit:  _TransactionTypeName[6:11],
+	TransactionTypeCredit:   _TransactionTypeName[0:6],
+	TransactionTypeDebit:    _TransactionTypeName[6:11],
+	TransactionTypeReversal: _TransactionTypeName[11:19],
+	TransactionTypeFee:      _TransactionTypeName[19:22],
+	TransactionTypeRefund:   _TransactionTypeName[22:28],
 }
 
 // String implements the Stringer interface.
@@ -43,8 +53,16 @@ func (x TransactionType) IsValid() bool {
 }
 
 var _TransactionTypeValue = map[string]TransactionType{
-	_TransactionTypeName[0:6]:  TransactionTypeCredit,
-	_TransactionTypeName[6:11]: TransactionTypeDebit,
+	_TransactionTypeName[0:6]:                    TransactionTypeCredit,
+	strings.ToLower(_TransactionTypeName[0:6]):   TransactionTypeCredit,
+	_TransactionTypeName[6:11]:                   TransactionTypeDebit,
+	strings.ToLower(_TransactionTypeName[6:11]):  TransactionTypeDebit,
+	_TransactionTypeName[11:19]:                  TransactionTypeReversal,
+	strings.ToLower(_TransactionTypeName[11:19]): TransactionTypeReversal,
+	_TransactionTypeName[19:22]:                  TransactionTypeFee,
+	strings.ToLower(_TransactionTypeName[19:22]): TransactionTypeFee,
+	_TransactionTypeName[22:28]:                  TransactionTypeRefund,
+	strings.ToLower(_TransactionTypeName[22:28]): TransactionTypeRefund,
 }
 
 // ParseTransactionType attempts to convert a string to a TransactionType.
@@ -52,5 +70,33 @@ func ParseTransactionType(name string) (TransactionType, error) {
 	if x, ok := _TransactionTypeValue[name]; ok {
 		return x, nil
 	}
+	// Case insensitive parse, do a separate lookup to prevent unnecessary cost of lowercasing a string if we don't need to.
+	if x, ok := _TransactionTypeValue[strings.ToLower(name)]; ok {
+		return x, nil
+	}
 	return TransactionType(0), fmt.Errorf("%s is %w", name, ErrInvalidTransactionType)
 }
+
+// MarshalText implements the text marshaller method.
+func (x TransactionType) MarshalText() ([]byte, error) {
+	return []byte(x.String()), nil
+}
+
+// UnmarshalText implements the text unmarshaller method.
+func (x *TransactionType) UnmarshalText(text []byte) error {
+	name := string(text)
+	tmp, err := ParseTransactionType(name)
+	if err != nil {
+		return err
+	}
+	*x = tmp
+	return nil
+}
+
+// AppendText appends the textual representation of itself to the end of b
+// (allocating a larger slice if necessary) and returns the updated slice.
+//
+// Implementations must not retain b, nor mutate any bytes within b[:len(b)].
+func (x TransactionType) AppendText(b []byte) ([]byte, error) {
+	return append(b, x.String()...), nil
+}
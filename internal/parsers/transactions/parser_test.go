@@ -1,11 +1,15 @@
 package transactions
 
 import (
+	"errors"
+	"io"
 	"strconv"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/google/go-cmp/cmp"
+	csvparser "github.com/rickyson96/amartha-reconciliation-service/internal/csv_parser"
 	"github.com/rickyson96/amartha-reconciliation-service/internal/testutils"
 )
 
@@ -38,6 +42,28 @@ func TestParse(t *testing.T) {
 			},
 			wantErr: false,
 		},
+		{
+			name: "success parse case insensitive",
+			data: []string{"1", "10", "credit", "2025-10-01 11:12:13"},
+			want: Transaction{
+				TrxID:           "1",
+				Amount:          testutils.NewDecimal(t, 10, 0),
+				Type:            TransactionTypeCredit,
+				TransactionTime: time.Date(2025, 10, 01, 11, 12, 13, 0, time.UTC),
+			},
+			wantErr: false,
+		},
+		{
+			name: "success parse new types",
+			data: []string{"1", "10", "Reversal", "2025-10-01 11:12:13"},
+			want: Transaction{
+				TrxID:           "1",
+				Amount:          testutils.NewDecimal(t, 10, 0),
+				Type:            TransactionTypeReversal,
+				TransactionTime: time.Date(2025, 10, 01, 11, 12, 13, 0, time.UTC),
+			},
+			wantErr: false,
+		},
 		{
 			name:    "fail on wrong amount",
 			data:    []string{"1", "a", "CREDIT", "2025-10-01 11:12:13"},
@@ -46,7 +72,7 @@ func TestParse(t *testing.T) {
 		},
 		{
 			name:    "fail on wrong enum",
-			data:    []string{"1", "1", "credit", "2025-10-01 11:12:13"},
+			data:    []string{"1", "1", "kredit", "2025-10-01 11:12:13"},
 			want:    Transaction{},
 			wantErr: true,
 		},
@@ -60,7 +86,7 @@ func TestParse(t *testing.T) {
 
 	for _, test := range tests {
 		t.Run(test.name, func(t *testing.T) {
-			got, err := parse(test.data)
+			got, err := DefaultFormat.parse(test.data)
 			if test.wantErr != (err != nil) {
 				t.Errorf("wantErr is %t, but err is %v", test.wantErr, err)
 			}
@@ -72,6 +98,225 @@ func TestParse(t *testing.T) {
 	}
 }
 
+func TestNewReaderFixedWidth(t *testing.T) {
+	format := DefaultFormat
+	format.FixedWidth = csvparser.FixedWidthLayout{
+		CodeStart:  1,
+		CodeLength: 1,
+		Detail: csvparser.FixedWidthRecord{Code: "D", Fields: []csvparser.FixedWidthField{
+			{Name: "id", Start: 2, Length: 4},
+			{Name: "amount", Start: 6, Length: 8, Type: csvparser.FieldTypeNumber, Decimals: 2},
+			{Name: "type", Start: 14, Length: 6},
+			{Name: "time", Start: 20, Length: 14, Type: csvparser.FieldTypeDateTime, Layout: "20060102150405"},
+		}},
+		Trailer: csvparser.FixedWidthRecord{Code: "T", Fields: []csvparser.FixedWidthField{
+			{Name: "count", Start: 2, Length: 3},
+		}},
+		CountField: "count",
+	}
+	input := "DTRX100000100CREDIT20251001111213\nT001\n"
+
+	parser, err := NewReader(strings.NewReader(input), time.Date(2025, 10, 1, 0, 0, 0, 0, time.UTC), time.Date(2025, 10, 31, 0, 0, 0, 0, time.UTC), format)
+	if err != nil {
+		t.Fatalf("NewReader failed: %v", err)
+	}
+	got, err := parser.Parse()
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+
+	want := []Transaction{{
+		TrxID:           "TRX1",
+		Amount:          testutils.NewDecimal(t, 100, 2),
+		Type:            TransactionTypeCredit,
+		TransactionTime: time.Date(2025, 10, 1, 11, 12, 13, 0, time.UTC),
+	}}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Parse() mismatch, (-want,+got):\n%s", diff)
+	}
+}
+
+func TestNewReaderFixedWidthMissingFields(t *testing.T) {
+	format := DefaultFormat
+	format.FixedWidth = csvparser.FixedWidthLayout{
+		Detail: csvparser.FixedWidthRecord{Fields: []csvparser.FixedWidthField{
+			{Name: "id", Start: 1, Length: 4},
+			{Name: "amount", Start: 5, Length: 8, Type: csvparser.FieldTypeNumber, Decimals: 2},
+		}},
+	}
+
+	_, err := NewReader(strings.NewReader("TRX100000100\n"), time.Time{}, time.Time{}, format)
+	if !errors.Is(err, ErrMissingColumn) {
+		t.Errorf("want ErrMissingColumn, got %v", err)
+	}
+}
+
+func TestFormatParseMissingColumn(t *testing.T) {
+	_, err := DefaultFormat.parse([]string{"1", "10", "CREDIT"})
+	if !errors.Is(err, ErrMissingColumn) {
+		t.Errorf("want ErrMissingColumn, got %v", err)
+	}
+}
+
+func TestNewReaderJSONLines(t *testing.T) {
+	format := DefaultFormat
+	format.TypeAliases = map[string]TransactionType{"CR": TransactionTypeCredit}
+	format.JSON = JSONFields{
+		TrxID:           "$.id",
+		Amount:          "$.amount.value",
+		Type:            "$.type",
+		TransactionTime: "$.created_at",
+		Reference:       "$.refs[0]",
+	}
+	input := `{"id": "TRX1", "amount": {"value": 100.50, "currency": "IDR"}, "type": "CR", "created_at": "2025-10-01T11:12:13+07:00"}
+
+{"id": "TRX2", "amount": {"value": "10"}, "type": "REVERSAL", "created_at": "2025-10-02T00:00:00Z", "refs": ["TRX1"]}
+{"id": "TRX3", "amount": {"value": 5}, "type": "DEBIT", "created_at": "2025-11-01T00:00:00Z"}
+{"id": "TRX4", "amount": {"value": 7}, "type": "DEBIT", "created_at": "2025-10-31T23:30:00-05:00"}
+`
+
+	parser, err := NewReader(strings.NewReader(input), time.Date(2025, 10, 1, 0, 0, 0, 0, time.UTC), time.Date(2025, 10, 31, 0, 0, 0, 0, time.UTC), format)
+	if err != nil {
+		t.Fatalf("NewReader failed: %v", err)
+	}
+
+	var got []Transaction
+	var lines []int
+	for {
+		row, err := parser.ReadRow()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("unwanted error: %v", err)
+		}
+		got = append(got, row.Data)
+		lines = append(lines, row.Line)
+	}
+
+	// the time keeps its offset, and the window is on its own dates
+	want := []Transaction{{
+		TrxID:           "TRX1",
+		Amount:          testutils.NewDecimal(t, 10050, 2),
+		Type:            TransactionTypeCredit,
+		TransactionTime: time.Date(2025, 10, 1, 11, 12, 13, 0, time.FixedZone("", 7*60*60)),
+	}, {
+		TrxID:           "TRX2",
+		Amount:          testutils.NewDecimal(t, 10, 0),
+		Type:            TransactionTypeReversal,
+		TransactionTime: time.Date(2025, 10, 2, 0, 0, 0, 0, time.UTC),
+		Reference:       "TRX1",
+	}, {
+		TrxID:           "TRX4",
+		Amount:          testutils.NewDecimal(t, 7, 0),
+		Type:            TransactionTypeDebit,
+		TransactionTime: time.Date(2025, 10, 31, 23, 30, 0, 0, time.FixedZone("", -5*60*60)),
+	}}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("ReadRow() mismatch, (-want,+got):\n%s", diff)
+	}
+	if _, offset := got[0].TransactionTime.Zone(); offset != 7*60*60 {
+		t.Errorf("offset mismatch, got %d", offset)
+	}
+	if diff := cmp.Diff([]int{1, 3, 5}, lines); diff != "" {
+		t.Errorf("lines mismatch, (-want,+got):\n%s", diff)
+	}
+}
+
+func TestFormatParseReference(t *testing.T) {
+	format := DefaultFormat
+	format.ReferenceColumn = 4
+	format.FieldPerRow = 5
+
+	got, err := format.parse([]string{"2", "10", "REVERSAL", "2025-10-01 11:12:13", "1"})
+	if err != nil {
+		t.Fatalf("parse failed: %v", err)
+	}
+
+	want := Transaction{
+		TrxID:           "2",
+		Amount:          testutils.NewDecimal(t, 10, 0),
+		Type:            TransactionTypeReversal,
+		TransactionTime: time.Date(2025, 10, 01, 11, 12, 13, 0, time.UTC),
+		Reference:       "1",
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("parse mismatch, (-want,+got):\n%s", diff)
+	}
+}
+
+func TestFormatParseType(t *testing.T) {
+	format := DefaultFormat
+	format.TypeAliases = map[string]TransactionType{
+		"CR":  TransactionTypeCredit,
+		"DB":  TransactionTypeDebit,
+		"REV": TransactionTypeReversal,
+		"CHG": TransactionTypeFee,
+	}
+
+	tests := []struct {
+		value   string
+		want    TransactionType
+		wantErr bool
+	}{
+		{"CR", TransactionTypeCredit, false},
+		{"cr", TransactionTypeCredit, false},
+		{" db ", TransactionTypeDebit, false},
+		{"rev", TransactionTypeReversal, false},
+		{"CHG", TransactionTypeFee, false},
+		{"refund", TransactionTypeRefund, false},
+		{"XX", TransactionTypeCredit, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.value, func(t *testing.T) {
+			got, err := format.parseType(test.value)
+			if test.wantErr != (err != nil) {
+				t.Errorf("wantErr is %t, but err is %v", test.wantErr, err)
+			}
+			if got != test.want {
+				t.Errorf("parseType(%q) = %v, want %v", test.value, got, test.want)
+			}
+		})
+	}
+}
+
+func TestBookingType(t *testing.T) {
+	tests := map[TransactionType]TransactionType{
+		TransactionTypeCredit:   TransactionTypeCredit,
+		TransactionTypeDebit:    TransactionTypeDebit,
+		TransactionTypeReversal: TransactionTypeCredit,
+		TransactionTypeFee:      TransactionTypeDebit,
+		TransactionTypeRefund:   TransactionTypeDebit,
+	}
+
+	for trxType, want := range tests {
+		if got := trxType.BookingType(); got != want {
+			t.Errorf("%v.BookingType() = %v, want %v", trxType, got, want)
+		}
+	}
+}
+
+func TestTransactionBookingType(t *testing.T) {
+	tests := []struct {
+		name   string
+		amount int64
+		want   TransactionType
+	}{
+		{"reversal of debit", 10, TransactionTypeCredit},
+		{"reversal of credit", -10, TransactionTypeDebit},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			trx := Transaction{Amount: testutils.NewDecimal(t, test.amount, 0), Type: TransactionTypeReversal}
+			if got := trx.BookingType(); got != test.want {
+				t.Errorf("BookingType() = %v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
 func TestFilter(t *testing.T) {
 	type testData struct {
 		startDate string
@@ -0,0 +1,124 @@
+package balance
+
+import (
+	"io"
+	"time"
+
+	"github.com/govalues/decimal"
+	"github.com/rickyson96/amartha-reconciliation-service/internal/parsers/transactions"
+	"github.com/rickyson96/amartha-reconciliation-service/internal/processes/reconciliation"
+)
+
+// Day is the running balance of both system and bank at the end of a day
+type Day struct {
+	Date           time.Time
+	SystemMovement decimal.Decimal
+	SystemBalance  decimal.Decimal
+	BankMovement   decimal.Decimal
+	BankBalance    decimal.Decimal
+	// Difference is the system balance - bank balance
+	Difference decimal.Decimal
+}
+
+// Report is the day by day running balance comparison
+type Report struct {
+	Opening decimal.Decimal
+	Days    []Day
+	// FirstDivergence is the first day the balances differ, zero when
+	// they never diverge
+	FirstDivergence time.Time
+}
+
+// Diverged reports whether the system and bank balance ever differ
+func (r Report) Diverged() bool {
+	return !r.FirstDivergence.IsZero()
+}
+
+// Process computes the daily running balances of transactions and
+// statements from startDate to endDate (inclusive), starting from the
+// opening balance.
+//
+// Transactions are signed by their booking type, credit being positive,
+// while statements amount are already signed.
+func Process(trx reconciliation.Reader[transactions.Transaction], stmt reconciliation.Reader[reconciliation.StatementFilePair], opening decimal.Decimal, startDate, endDate time.Time) (Report, error) {
+	systemMovements := make(map[time.Time]decimal.Decimal)
+	for {
+		t, err := trx()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return Report{}, err
+		}
+
+		amount := t.Amount.Abs()
+		if t.BookingType() == transactions.TransactionTypeDebit {
+			amount = amount.Neg()
+		}
+
+		if err := addMovement(systemMovements, t.TransactionTime, amount); err != nil {
+			return Report{}, err
+		}
+	}
+
+	bankMovements := make(map[time.Time]decimal.Decimal)
+	for {
+		s, err := stmt()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return Report{}, err
+		}
+
+		if err := addMovement(bankMovements, s.Statement.Date, s.Statement.Amount); err != nil {
+			return Report{}, err
+		}
+	}
+
+	report := Report{Opening: opening}
+	systemBalance, bankBalance := opening, opening
+	for date := toDate(startDate); !date.After(toDate(endDate)); date = date.AddDate(0, 0, 1) {
+		day := Day{
+			Date:           date,
+			SystemMovement: systemMovements[date],
+			BankMovement:   bankMovements[date],
+		}
+
+		var err error
+		if systemBalance, err = systemBalance.Add(day.SystemMovement); err != nil {
+			return Report{}, err
+		}
+		if bankBalance, err = bankBalance.Add(day.BankMovement); err != nil {
+			return Report{}, err
+		}
+		if day.Difference, err = systemBalance.Sub(bankBalance); err != nil {
+			return Report{}, err
+		}
+		day.SystemBalance, day.BankBalance = systemBalance, bankBalance
+
+		if !day.Difference.IsZero() && !report.Diverged() {
+			report.FirstDivergence = date
+		}
+		report.Days = append(report.Days, day)
+	}
+
+	return report, nil
+}
+
+func addMovement(movements map[time.Time]decimal.Decimal, at time.Time, amount decimal.Decimal) error {
+	date := toDate(at)
+	total, err := movements[date].Add(amount)
+	if err != nil {
+		return err
+	}
+
+	movements[date] = total
+	return nil
+}
+
+// toDate truncates the time into its date, since statements only
+// contain date
+func toDate(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
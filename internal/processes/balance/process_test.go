@@ -0,0 +1,81 @@
+package balance_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/govalues/decimal"
+	"github.com/rickyson96/amartha-reconciliation-service/internal/parsers/statements"
+	"github.com/rickyson96/amartha-reconciliation-service/internal/parsers/transactions"
+	"github.com/rickyson96/amartha-reconciliation-service/internal/processes/balance"
+	"github.com/rickyson96/amartha-reconciliation-service/internal/processes/reconciliation"
+	"github.com/rickyson96/amartha-reconciliation-service/internal/testutils"
+)
+
+func TestProcess(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2025, 03, d, 0, 0, 0, 0, time.UTC) }
+	trxs := []transactions.Transaction{
+		{TrxID: "1", Amount: testutils.NewDecimal(t, 100, 0), Type: transactions.TransactionTypeCredit, TransactionTime: day(1).Add(10 * time.Hour)},
+		{TrxID: "2", Amount: testutils.NewDecimal(t, 30, 0), Type: transactions.TransactionTypeDebit, TransactionTime: day(1).Add(11 * time.Hour)},
+		{TrxID: "3", Amount: testutils.NewDecimal(t, 5, 0), Type: transactions.TransactionTypeFee, TransactionTime: day(3).Add(9 * time.Hour)},
+	}
+	stmts := []reconciliation.StatementFilePair{
+		{Name: "bank1.csv", Statement: statements.Statement{UniqueIdentifier: "a", Amount: testutils.NewDecimal(t, 100, 0), Date: day(1)}},
+		{Name: "bank1.csv", Statement: statements.Statement{UniqueIdentifier: "b", Amount: testutils.NewDecimal(t, -30, 0), Date: day(2)}},
+		{Name: "bank2.csv", Statement: statements.Statement{UniqueIdentifier: "c", Amount: testutils.NewDecimal(t, -5, 0), Date: day(3)}},
+	}
+
+	d := func(v int64) decimal.Decimal { return testutils.NewDecimal(t, v, 0) }
+	want := balance.Report{
+		Opening: d(10),
+		Days: []balance.Day{
+			{Date: day(1), SystemMovement: d(70), SystemBalance: d(80), BankMovement: d(100), BankBalance: d(110), Difference: d(-30)},
+			{Date: day(2), SystemMovement: decimal.Decimal{}, SystemBalance: d(80), BankMovement: d(-30), BankBalance: d(80), Difference: d(0)},
+			{Date: day(3), SystemMovement: d(-5), SystemBalance: d(75), BankMovement: d(-5), BankBalance: d(75), Difference: d(0)},
+			{Date: day(4), SystemMovement: decimal.Decimal{}, SystemBalance: d(75), BankMovement: decimal.Decimal{}, BankBalance: d(75), Difference: d(0)},
+		},
+		FirstDivergence: day(1),
+	}
+
+	got, err := balance.Process(testutils.NewReader(trxs).Read, testutils.NewReader(stmts).Read, d(10), day(1), day(4))
+	if err != nil {
+		t.Fatalf("Process failed: %v", err)
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Process mismatch, (-want,+got):\n%s", diff)
+	}
+	if !got.Diverged() {
+		t.Errorf("Diverged should be true")
+	}
+}
+
+func TestProcessNeverDiverged(t *testing.T) {
+	day := time.Date(2025, 03, 1, 0, 0, 0, 0, time.UTC)
+	got, err := balance.Process(
+		testutils.NewReader([]transactions.Transaction{}).Read,
+		testutils.NewReader([]reconciliation.StatementFilePair{}).Read,
+		testutils.NewDecimal(t, 10, 0), day, day.AddDate(0, 0, 1))
+	if err != nil {
+		t.Fatalf("Process failed: %v", err)
+	}
+	if got.Diverged() {
+		t.Errorf("Diverged should be false, first divergence on %v", got.FirstDivergence)
+	}
+	if len(got.Days) != 2 {
+		t.Errorf("should report 2 days, got %d", len(got.Days))
+	}
+}
+
+func TestProcessReaderError(t *testing.T) {
+	errReader := func() (transactions.Transaction, error) {
+		return transactions.Transaction{}, errors.New("error")
+	}
+
+	day := time.Date(2025, 03, 1, 0, 0, 0, 0, time.UTC)
+	_, err := balance.Process(errReader, testutils.NewReader([]reconciliation.StatementFilePair{}).Read, decimal.Zero, day, day)
+	if err == nil {
+		t.Errorf("Process should fail")
+	}
+}
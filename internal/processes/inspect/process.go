@@ -0,0 +1,171 @@
+package inspect
+
+import (
+	"errors"
+	"io"
+	"maps"
+	"slices"
+	"time"
+
+	"github.com/govalues/decimal"
+	csvparser "github.com/rickyson96/amartha-reconciliation-service/internal/csv_parser"
+	"github.com/rickyson96/amartha-reconciliation-service/internal/parsers/statements"
+	"github.com/rickyson96/amartha-reconciliation-service/internal/parsers/transactions"
+	"github.com/rickyson96/amartha-reconciliation-service/internal/processes/reconciliation"
+)
+
+// Stats is the statistics of a file
+type Stats struct {
+	File   string                                    `json:"file"`
+	Rows   int                                       `json:"rows"`
+	ByType map[transactions.TransactionType]TypeStat `json:"byType"`
+	// First and Last is the date range present on the file
+	First time.Time `json:"first"`
+	Last  time.Time `json:"last"`
+	// PerDay is the histogram of the rows per day
+	PerDay []DayCount `json:"perDay"`
+	// Largest is the rows of the largest absolute amount, descending
+	Largest []Row `json:"largest"`
+	// DistinctKeys counts the distinct type, amount and date key used
+	// for matching, rows sharing the same key are ambiguous to match
+	DistinctKeys int `json:"distinctKeys"`
+}
+
+// TypeStat is the count and sum of the amount of a type
+type TypeStat struct {
+	Count int             `json:"count"`
+	Sum   decimal.Decimal `json:"sum"`
+}
+
+// DayCount is the number of rows of a day
+type DayCount struct {
+	Date  time.Time `json:"date"`
+	Count int       `json:"count"`
+}
+
+// Row is a row of the file
+type Row struct {
+	Line   int                          `json:"line"`
+	ID     string                       `json:"id"`
+	Type   transactions.TransactionType `json:"type"`
+	Amount decimal.Decimal              `json:"amount"`
+	Date   time.Time                    `json:"date"`
+}
+
+type collector struct {
+	stats Stats
+	top   int
+	keys  map[string]bool
+	days  map[time.Time]int
+}
+
+func newCollector(file string, top int) *collector {
+	return &collector{
+		stats: Stats{File: file, ByType: make(map[transactions.TransactionType]TypeStat)},
+		top:   max(top, 0),
+		keys:  make(map[string]bool),
+		days:  make(map[time.Time]int),
+	}
+}
+
+func (c *collector) add(row Row, key string) error {
+	c.stats.Rows++
+
+	stat := c.stats.ByType[row.Type]
+	sum, err := stat.Sum.Add(row.Amount)
+	if err != nil {
+		return err
+	}
+	c.stats.ByType[row.Type] = TypeStat{Count: stat.Count + 1, Sum: sum}
+
+	if c.stats.First.IsZero() || row.Date.Before(c.stats.First) {
+		c.stats.First = row.Date
+	}
+	if c.stats.Last.IsZero() || row.Date.After(c.stats.Last) {
+		c.stats.Last = row.Date
+	}
+
+	c.days[time.Date(row.Date.Year(), row.Date.Month(), row.Date.Day(), 0, 0, 0, 0, time.UTC)]++
+	c.keys[key] = true
+
+	c.stats.Largest = append(c.stats.Largest, row)
+	slices.SortStableFunc(c.stats.Largest, func(a, b Row) int {
+		return b.Amount.Abs().Cmp(a.Amount.Abs())
+	})
+	if len(c.stats.Largest) > c.top {
+		c.stats.Largest = c.stats.Largest[:c.top]
+	}
+
+	return nil
+}
+
+func (c *collector) result() Stats {
+	for _, day := range slices.SortedFunc(maps.Keys(c.days), func(a, b time.Time) int { return a.Compare(b) }) {
+		c.stats.PerDay = append(c.stats.PerDay, DayCount{Date: day, Count: c.days[day]})
+	}
+	c.stats.DistinctKeys = len(c.keys)
+	return c.stats
+}
+
+// Transactions reads through the transaction file rows, keeping the top
+// largest amounts
+func Transactions(file string, read func() (csvparser.Row[transactions.Transaction], error), top int) (Stats, error) {
+	c := newCollector(file, top)
+	matcher := reconciliation.NewKeyMatcher()
+	for {
+		row, err := read()
+		if errors.Is(err, io.EOF) {
+			return c.result(), nil
+		}
+		if err != nil {
+			return Stats{}, err
+		}
+
+		trx := row.Data
+		key, _ := matcher.TransactionKey(trx)
+		err = c.add(Row{
+			Line:   row.Line,
+			ID:     trx.TrxID,
+			Type:   trx.Type,
+			Amount: trx.Amount,
+			Date:   trx.TransactionTime,
+		}, key)
+		if err != nil {
+			return Stats{}, err
+		}
+	}
+}
+
+// Statements reads through the statement file rows, keeping the top
+// largest amounts
+func Statements(file string, read func() (csvparser.Row[statements.Statement], error), top int) (Stats, error) {
+	c := newCollector(file, top)
+	matcher := reconciliation.NewKeyMatcher()
+	for {
+		row, err := read()
+		if errors.Is(err, io.EOF) {
+			return c.result(), nil
+		}
+		if err != nil {
+			return Stats{}, err
+		}
+
+		stmt := row.Data
+		key, _ := matcher.StatementKey(reconciliation.StatementFilePair{Name: file, Statement: stmt})
+		err = c.add(Row{
+			Line:   row.Line,
+			ID:     stmt.UniqueIdentifier,
+			Type:   stmt.BookingType(),
+			Amount: stmt.Amount,
+			Date:   stmt.Date,
+		}, key)
+		if err != nil {
+			return Stats{}, err
+		}
+	}
+}
+
+// SortedTypes returns the types of the stats in order
+func (s Stats) SortedTypes() []transactions.TransactionType {
+	return slices.Sorted(maps.Keys(s.ByType))
+}
@@ -0,0 +1,125 @@
+package inspect_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/rickyson96/amartha-reconciliation-service/internal/parsers/statements"
+	"github.com/rickyson96/amartha-reconciliation-service/internal/parsers/transactions"
+	"github.com/rickyson96/amartha-reconciliation-service/internal/processes/inspect"
+	"github.com/rickyson96/amartha-reconciliation-service/internal/testutils"
+)
+
+var (
+	march1  = time.Date(2025, 03, 1, 0, 0, 0, 0, time.UTC)
+	march31 = time.Date(2025, 03, 31, 0, 0, 0, 0, time.UTC)
+)
+
+func TestTransactions(t *testing.T) {
+	input := "trxID,amount,type,transactionTime\n" +
+		"1,10.00,CREDIT,2025-03-14 10:00:00\n" +
+		"2,10.00,CREDIT,2025-03-14 11:00:00\n" +
+		"3,25.50,DEBIT,2025-03-15 10:00:00\n" +
+		"4,1.00,FEE,2025-03-15 10:00:00\n" +
+		"5,99.00,DEBIT,2025-04-01 10:00:00\n"
+
+	parser := transactions.NewCSVParser(bytes.NewBufferString(input), march1, march31)
+	got, err := inspect.Transactions("trx.csv", parser.ReadRow, 2)
+	if err != nil {
+		t.Fatalf("unwanted error: %v", err)
+	}
+
+	want := inspect.Stats{
+		File: "trx.csv",
+		Rows: 4,
+		ByType: map[transactions.TransactionType]inspect.TypeStat{
+			transactions.TransactionTypeCredit: {Count: 2, Sum: testutils.NewDecimal(t, 2000, 2)},
+			transactions.TransactionTypeDebit:  {Count: 1, Sum: testutils.NewDecimal(t, 2550, 2)},
+			transactions.TransactionTypeFee:    {Count: 1, Sum: testutils.NewDecimal(t, 100, 2)},
+		},
+		First: time.Date(2025, 03, 14, 10, 0, 0, 0, time.UTC),
+		Last:  time.Date(2025, 03, 15, 10, 0, 0, 0, time.UTC),
+		PerDay: []inspect.DayCount{
+			{Date: time.Date(2025, 03, 14, 0, 0, 0, 0, time.UTC), Count: 2},
+			{Date: time.Date(2025, 03, 15, 0, 0, 0, 0, time.UTC), Count: 2},
+		},
+		Largest: []inspect.Row{
+			{Line: 4, ID: "3", Type: transactions.TransactionTypeDebit, Amount: testutils.NewDecimal(t, 2550, 2), Date: time.Date(2025, 03, 15, 10, 0, 0, 0, time.UTC)},
+			{Line: 2, ID: "1", Type: transactions.TransactionTypeCredit, Amount: testutils.NewDecimal(t, 1000, 2), Date: time.Date(2025, 03, 14, 10, 0, 0, 0, time.UTC)},
+		},
+		// both credits share the same key
+		DistinctKeys: 3,
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Transactions mismatch, (-want,+got):\n%s", diff)
+	}
+
+	if diff := cmp.Diff([]transactions.TransactionType{
+		transactions.TransactionTypeCredit,
+		transactions.TransactionTypeDebit,
+		transactions.TransactionTypeFee,
+	}, got.SortedTypes()); diff != "" {
+		t.Errorf("SortedTypes mismatch, (-want,+got):\n%s", diff)
+	}
+
+	data, err := json.Marshal(got)
+	if err != nil {
+		t.Fatalf("failed marshaling stats: %v", err)
+	}
+	var fromJSON inspect.Stats
+	if err := json.Unmarshal(data, &fromJSON); err != nil {
+		t.Fatalf("failed unmarshaling stats: %v", err)
+	}
+	if diff := cmp.Diff(got, fromJSON); diff != "" {
+		t.Errorf("json round trip mismatch, (-want,+got):\n%s", diff)
+	}
+}
+
+func TestTransactionsNegativeTop(t *testing.T) {
+	input := "trxID,amount,type,transactionTime\n1,10.00,CREDIT,2025-03-14 10:00:00\n"
+
+	parser := transactions.NewCSVParser(bytes.NewBufferString(input), march1, march31)
+	got, err := inspect.Transactions("trx.csv", parser.ReadRow, -1)
+	if err != nil {
+		t.Fatalf("unwanted error: %v", err)
+	}
+	if len(got.Largest) != 0 {
+		t.Errorf("want no largest rows, got %v", got.Largest)
+	}
+}
+
+func TestStatements(t *testing.T) {
+	input := "uniqueIdentifier,amount,date\n" +
+		"a,-10.00,2025-03-14\n" +
+		"b,20.00,2025-03-14\n" +
+		"c,-10.00,2025-03-14\n"
+
+	parser := statements.NewCSVParser(bytes.NewBufferString(input), march1, march31)
+	got, err := inspect.Statements("bank1.csv", parser.ReadRow, 5)
+	if err != nil {
+		t.Fatalf("unwanted error: %v", err)
+	}
+
+	if got.Rows != 3 || got.DistinctKeys != 2 {
+		t.Errorf("count mismatch, rows %d, distinct keys %d", got.Rows, got.DistinctKeys)
+	}
+
+	wantByType := map[transactions.TransactionType]inspect.TypeStat{
+		transactions.TransactionTypeCredit: {Count: 1, Sum: testutils.NewDecimal(t, 2000, 2)},
+		transactions.TransactionTypeDebit:  {Count: 2, Sum: testutils.NewDecimal(t, -2000, 2)},
+	}
+	if diff := cmp.Diff(wantByType, got.ByType); diff != "" {
+		t.Errorf("ByType mismatch, (-want,+got):\n%s", diff)
+	}
+
+	var ids []string
+	for _, row := range got.Largest {
+		ids = append(ids, row.ID)
+	}
+	if diff := cmp.Diff([]string{"b", "a", "c"}, ids); diff != "" {
+		t.Errorf("Largest mismatch, (-want,+got):\n%s", diff)
+	}
+}
@@ -0,0 +1,88 @@
+package reconciliation
+
+import (
+	"github.com/govalues/decimal"
+	"github.com/rickyson96/amartha-reconciliation-service/internal/parsers/statements"
+)
+
+// BalanceCheck verifies the statement source lines against its declared
+// balance, where opening + total should equal closing.
+type BalanceCheck struct {
+	Opening decimal.Decimal
+	Closing decimal.Decimal
+	// Total is the sum of the statement lines within the date range, or
+	// of the whole file when the file carries its balance, as its opening
+	// and closing aren't bound to the date range
+	Total decimal.Decimal
+	// Difference is closing - (opening + total), non zero means the
+	// balance breaks
+	Difference decimal.Decimal
+	// Err is set when the balance can't be calculated, e.g. overflow
+	Err error
+}
+
+// IsBreak reports whether the lines don't add up to the closing balance
+func (c BalanceCheck) IsBreak() bool {
+	return c.Err != nil || !c.Difference.IsZero()
+}
+
+// balanceTotals sums up the statement lines per source, leaving out the
+// lines carried forward from the previous runs
+type balanceTotals struct {
+	totals map[string]decimal.Decimal
+	errs   map[string]error
+}
+
+func (b *balanceTotals) add(stmt StatementFilePair) {
+	if stmt.Carried {
+		return
+	}
+
+	if b.totals == nil {
+		b.totals = make(map[string]decimal.Decimal)
+		b.errs = make(map[string]error)
+	}
+
+	if b.errs[stmt.Name] != nil {
+		return
+	}
+
+	total, err := b.totals[stmt.Name].Add(stmt.Statement.Amount)
+	if err != nil {
+		b.errs[stmt.Name] = err
+		return
+	}
+	b.totals[stmt.Name] = total
+}
+
+// checks verifies the declared balances, keyed by the source name
+func (b balanceTotals) checks(balances map[string]statements.Balance) map[string]BalanceCheck {
+	if len(balances) == 0 {
+		return nil
+	}
+
+	checks := make(map[string]BalanceCheck, len(balances))
+	for name, balance := range balances {
+		check := BalanceCheck{
+			Opening: balance.Opening,
+			Closing: balance.Closing,
+			Total:   b.totals[name],
+			Err:     b.errs[name],
+		}
+		if balance.Total != nil {
+			check.Total, check.Err = *balance.Total, nil
+		}
+
+		if check.Err == nil {
+			expected, err := check.Opening.Add(check.Total)
+			if err == nil {
+				check.Difference, err = check.Closing.Sub(expected)
+			}
+			check.Err = err
+		}
+
+		checks[name] = check
+	}
+
+	return checks
+}
@@ -0,0 +1,131 @@
+package reconciliation_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/rickyson96/amartha-reconciliation-service/internal/parsers/statements"
+	"github.com/rickyson96/amartha-reconciliation-service/internal/parsers/transactions"
+	"github.com/rickyson96/amartha-reconciliation-service/internal/processes/reconciliation"
+	"github.com/rickyson96/amartha-reconciliation-service/internal/testutils"
+)
+
+func TestBalanceChecks(t *testing.T) {
+	date := time.Date(2025, 03, 14, 0, 0, 0, 0, time.UTC)
+	stmts := map[string][]statements.Statement{
+		"bank1.csv": {
+			{UniqueIdentifier: "a", Amount: testutils.NewDecimal(t, 10000, 2), Date: date, Type: transactions.TransactionTypeCredit},
+			{UniqueIdentifier: "b", Amount: testutils.NewDecimal(t, -2550, 2), Date: date, Type: transactions.TransactionTypeDebit},
+		},
+		"bank2.csv": {
+			{UniqueIdentifier: "c", Amount: testutils.NewDecimal(t, 10, 0), Date: date, Type: transactions.TransactionTypeCredit},
+		},
+		"bank3.csv": {
+			{UniqueIdentifier: "d", Amount: testutils.NewDecimal(t, 10, 0), Date: date, Type: transactions.TransactionTypeCredit},
+		},
+		"bank4.xml": {
+			{UniqueIdentifier: "e", Amount: testutils.NewDecimal(t, 10, 0), Date: date, Type: transactions.TransactionTypeCredit},
+		},
+	}
+	// the file balance covers the lines out of the date range too
+	fileTotal := testutils.NewDecimal(t, 30, 0)
+	opts := reconciliation.Options{
+		Balances: map[string]statements.Balance{
+			"bank1.csv": {Opening: testutils.NewDecimal(t, 1000, 0), Closing: testutils.NewDecimal(t, 107450, 2)},
+			"bank2.csv": {Opening: testutils.NewDecimal(t, 0, 0), Closing: testutils.NewDecimal(t, 5, 0)},
+			"empty.csv": {Opening: testutils.NewDecimal(t, 1, 0), Closing: testutils.NewDecimal(t, 1, 0)},
+			"bank4.xml": {Opening: testutils.NewDecimal(t, 0, 0), Closing: testutils.NewDecimal(t, 30, 0), Total: &fileTotal},
+		},
+	}
+
+	want := map[string]reconciliation.BalanceCheck{
+		"bank1.csv": {
+			Opening:    testutils.NewDecimal(t, 1000, 0),
+			Closing:    testutils.NewDecimal(t, 107450, 2),
+			Total:      testutils.NewDecimal(t, 7450, 2),
+			Difference: testutils.NewDecimal(t, 0, 2),
+		},
+		"bank2.csv": {
+			Opening:    testutils.NewDecimal(t, 0, 0),
+			Closing:    testutils.NewDecimal(t, 5, 0),
+			Total:      testutils.NewDecimal(t, 10, 0),
+			Difference: testutils.NewDecimal(t, -5, 0),
+		},
+		"empty.csv": {
+			Opening:    testutils.NewDecimal(t, 1, 0),
+			Closing:    testutils.NewDecimal(t, 1, 0),
+			Difference: testutils.NewDecimal(t, 0, 0),
+		},
+		"bank4.xml": {
+			Opening:    testutils.NewDecimal(t, 0, 0),
+			Closing:    testutils.NewDecimal(t, 30, 0),
+			Total:      testutils.NewDecimal(t, 30, 0),
+			Difference: testutils.NewDecimal(t, 0, 0),
+		},
+	}
+	wantBreaks := map[string]bool{"bank1.csv": false, "bank2.csv": true, "empty.csv": false, "bank4.xml": false}
+
+	assert := func(t *testing.T, got reconciliation.Result) {
+		t.Helper()
+		if diff := cmp.Diff(want, got.BalanceChecks); diff != "" {
+			t.Errorf("BalanceChecks mismatch, (-want,+got):\n%s", diff)
+		}
+		for name, check := range got.BalanceChecks {
+			if check.IsBreak() != wantBreaks[name] {
+				t.Errorf("%s IsBreak() = %t, want %t", name, check.IsBreak(), wantBreaks[name])
+			}
+		}
+	}
+
+	t.Run("Process", func(t *testing.T) {
+		assert(t, reconciliation.Process(nil, stmts, opts))
+	})
+
+	t.Run("ProcessConcurrent", func(t *testing.T) {
+		got, err := reconciliation.ProcessConcurrent(testutils.NewReader([]transactions.Transaction{}).Read, testutils.NewReader(fileStatementPairConverter(stmts)).Read, opts)
+		if err != nil {
+			t.Errorf("unwanted error: %v", err)
+		}
+		assert(t, got)
+	})
+}
+
+func TestBalanceChecksCarried(t *testing.T) {
+	date := time.Date(2025, 03, 14, 0, 0, 0, 0, time.UTC)
+	today := statements.Statement{UniqueIdentifier: "a", Amount: testutils.NewDecimal(t, 10, 0), Date: date, Type: transactions.TransactionTypeCredit}
+	// carried from the bank1.csv of the previous day
+	carried := statements.Statement{UniqueIdentifier: "b", Amount: testutils.NewDecimal(t, 20, 0), Date: date.AddDate(0, 0, -1), Type: transactions.TransactionTypeCredit}
+	opts := reconciliation.Options{
+		Balances: map[string]statements.Balance{
+			"bank1.csv": {Opening: testutils.NewDecimal(t, 100, 0), Closing: testutils.NewDecimal(t, 110, 0)},
+		},
+		Carried: map[string][]statements.Statement{"bank1.csv": {carried}},
+	}
+
+	assert := func(t *testing.T, got reconciliation.Result) {
+		t.Helper()
+		if check := got.BalanceChecks["bank1.csv"]; check.IsBreak() {
+			t.Errorf("carried lines shouldn't break the balance, got %+v", check)
+		}
+		if diff := cmp.Diff([]statements.Statement{today, carried}, got.Unmatched.Statements["bank1.csv"]); diff != "" {
+			t.Errorf("unmatched statements mismatch, (-want,+got):\n%s", diff)
+		}
+	}
+
+	t.Run("Process", func(t *testing.T) {
+		assert(t, reconciliation.Process(nil, map[string][]statements.Statement{"bank1.csv": {today}}, opts))
+	})
+
+	t.Run("ProcessConcurrent", func(t *testing.T) {
+		pairs := []reconciliation.StatementFilePair{
+			{Name: "bank1.csv", Statement: today},
+			{Name: "bank1.csv", Statement: carried, Carried: true},
+		}
+		got, err := reconciliation.ProcessConcurrent(testutils.NewReader([]transactions.Transaction{}).Read, testutils.NewReader(pairs).Read, opts)
+		if err != nil {
+			t.Errorf("unwanted error: %v", err)
+		}
+		assert(t, got)
+	})
+}
@@ -0,0 +1,122 @@
+package reconciliation
+
+import (
+	"maps"
+
+	"github.com/rickyson96/amartha-reconciliation-service/internal/parsers/statements"
+	"github.com/rickyson96/amartha-reconciliation-service/internal/parsers/transactions"
+)
+
+const (
+	// RuleKey matches transaction and statement by type, amount and date
+	RuleKey = "key"
+	// RuleReference matches transaction whose TrxID is found on the
+	// statement description, with the same type and amount
+	RuleReference = "reference"
+	// RuleTolerance matches transaction and statement of the same type
+	// and date, whose amount differs within the tolerance
+	RuleTolerance = "tolerance"
+	// RuleDateWindow matches transaction and statement of the same type
+	// and amount, whose date differs within the window
+	RuleDateWindow = "date-window"
+	// RuleGrouping matches multiple transactions of the same type and
+	// date into a single statement of their total amount
+	RuleGrouping = "grouping"
+	// RuleManual matches transaction and statement paired up by a human
+	RuleManual = "manual"
+)
+
+// Matcher is a matching strategy of the reconciliation.
+//
+// Matchers compare the transaction by its booking type, since
+// statements only know about credit and debit.
+type Matcher interface {
+	// Name is the rule name recorded on the matched pairs
+	Name() string
+	// Match pairs up the transactions and statements, and returns what's
+	// left unmatched for the next matcher.
+	Match(trxs []transactions.Transaction, stmts []StatementFilePair) ([]Match, []transactions.Transaction, []StatementFilePair)
+}
+
+// KeyMatcher is the [Matcher] that pairs up transaction and statement
+// with the same key, which allows matching while streaming the data.
+//
+// Data that can't produce a key is left for the next matcher.
+type KeyMatcher interface {
+	Matcher
+	TransactionKey(trx transactions.Transaction) (string, bool)
+	StatementKey(stmt StatementFilePair) (string, bool)
+}
+
+// Options configures how the reconciliation is being processed
+type Options struct {
+	// Matchers is the ordered matching pipeline, each matcher works on
+	// what the previous one left unmatched. Defaults to [NewKeyMatcher].
+	Matchers []Matcher
+	// Netting nets out reversed transactions before matching. Nil means
+	// disabled.
+	Netting *Netting
+	// Balances is the declared balance of statement sources, keyed by
+	// the source name, to be verified against their lines.
+	Balances map[string]statements.Balance
+	// FileBalances returns the balance carried by the statement files,
+	// which is called once the statements are read, as the closing
+	// balance may follow the lines. Balances take precedence.
+	FileBalances func() map[string]statements.Balance
+	// WriteOffs is the items to be written off when they're left
+	// unmatched by the matchers.
+	WriteOffs []WriteOff
+	// Accounts maps the statement sources into their bank account. Lines
+	// of the same UniqueIdentifier across sources of the same account are
+	// read once, see [Overlap].
+	Accounts map[string]string
+	// Carried is the statements carried forward from the previous runs,
+	// keyed by their source, which [Process] reconciles along with the
+	// statement sources while keeping them out of the balance checks.
+	// [ProcessConcurrent] reads them from its reader instead, see
+	// [StatementFilePair.Carried].
+	Carried map[string][]statements.Statement
+}
+
+// balances returns the declared balances along with the file balances
+func (o Options) balances() map[string]statements.Balance {
+	if o.FileBalances == nil {
+		return o.Balances
+	}
+
+	balances := maps.Clone(o.Balances)
+	for source, balance := range o.FileBalances() {
+		if _, ok := balances[source]; ok {
+			continue
+		}
+		if balances == nil {
+			balances = make(map[string]statements.Balance)
+		}
+		balances[source] = balance
+	}
+	return balances
+}
+
+func (o Options) matchers() []Matcher {
+	if len(o.Matchers) == 0 {
+		return []Matcher{NewKeyMatcher()}
+	}
+
+	return o.Matchers
+}
+
+// match runs the matchers pipeline and records the leftover as unmatched,
+// unless they're written off
+func (r *Result) match(matchers []Matcher, writeOffs []WriteOff, trxs []transactions.Transaction, stmts []StatementFilePair) {
+	for _, m := range matchers {
+		matchCount := len(trxs) + len(stmts)
+
+		var matches []Match
+		matches, trxs, stmts = m.Match(trxs, stmts)
+
+		r.addMatches(m.Name(), matchCount-len(trxs)-len(stmts), matches)
+	}
+
+	trxs, stmts = r.writeOff(writeOffs, trxs, stmts)
+	r.addUnmatched(trxs, stmts)
+}
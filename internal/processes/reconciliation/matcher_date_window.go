@@ -0,0 +1,61 @@
+package reconciliation
+
+import (
+	"github.com/rickyson96/amartha-reconciliation-service/internal/parsers/transactions"
+)
+
+type dateWindowMatcher struct {
+	days int
+}
+
+// NewDateWindowMatcher matches transaction and statement of the same type
+// and amount, whose date differs by at most the given days. This covers
+// transactions that got booked by the bank on a later date. The closest
+// date is preferred when there are multiple candidates.
+func NewDateWindowMatcher(days int) Matcher {
+	return dateWindowMatcher{days: max(days, 0)}
+}
+
+func (m dateWindowMatcher) Name() string { return RuleDateWindow }
+
+func (m dateWindowMatcher) Match(trxs []transactions.Transaction, stmts []StatementFilePair) ([]Match, []transactions.Transaction, []StatementFilePair) {
+	var candidates map[string][]int
+	for i, t := range trxs {
+		candidates = appendMapOfSlices(candidates, amountKey(t.BookingType(), t.Amount.Abs()), i)
+	}
+
+	var matches []Match
+	matched := make([]bool, len(trxs))
+	var stmtLeft []StatementFilePair
+	for _, s := range stmts {
+		best, bestDiff := -1, 0
+		for _, i := range candidates[amountKey(s.Statement.BookingType(), s.Statement.Amount.Abs())] {
+			if matched[i] {
+				continue
+			}
+
+			diff := abs(DaysBetween(trxs[i].TransactionTime, s.Statement.Date))
+			if diff > m.days {
+				continue
+			}
+
+			if best == -1 || diff < bestDiff {
+				best, bestDiff = i, diff
+			}
+		}
+
+		if best == -1 {
+			stmtLeft = append(stmtLeft, s)
+			continue
+		}
+
+		matched[best] = true
+		matches = append(matches, Match{
+			Transaction: trxs[best],
+			Statement:   s,
+			Rule:        RuleDateWindow,
+		})
+	}
+
+	return matches, unmatchedTransactions(trxs, matched), stmtLeft
+}
@@ -0,0 +1,128 @@
+package reconciliation
+
+import (
+	"slices"
+
+	"github.com/govalues/decimal"
+	"github.com/rickyson96/amartha-reconciliation-service/internal/parsers/transactions"
+)
+
+// DefaultMaxGroupSize is the most transactions [NewGroupingMatcher] sums
+// up out of a day, unless configured otherwise
+const DefaultMaxGroupSize = 5
+
+// groupingSearchLimit bounds the subsets tried for a statement, so that
+// a busy day can't stall the run
+const groupingSearchLimit = 100_000
+
+type groupingMatcher struct {
+	maxSize int
+}
+
+// NewGroupingMatcher matches transactions of the same type and date into
+// a single statement of their total amount. This covers banks that
+// settle multiple transactions as a single statement line. The whole day
+// is tried first, then the smallest subset of at most maxSize
+// transactions, so unrelated transactions of the day are left out.
+func NewGroupingMatcher(maxSize int) Matcher {
+	if maxSize <= 0 {
+		maxSize = DefaultMaxGroupSize
+	}
+	return groupingMatcher{maxSize: maxSize}
+}
+
+func (m groupingMatcher) Name() string { return RuleGrouping }
+
+func (m groupingMatcher) Match(trxs []transactions.Transaction, stmts []StatementFilePair) ([]Match, []transactions.Transaction, []StatementFilePair) {
+	var groups map[string][]int
+	for i, t := range trxs {
+		groups = appendMapOfSlices(groups, dayKey(t.BookingType(), t.TransactionTime), i)
+	}
+
+	var matches []Match
+	matched := make([]bool, len(trxs))
+	var stmtLeft []StatementFilePair
+	for _, s := range stmts {
+		var group []int
+		for _, i := range groups[dayKey(s.Statement.BookingType(), s.Statement.Date)] {
+			if !matched[i] {
+				group = append(group, i)
+			}
+		}
+
+		subset := m.subset(trxs, group, s.Statement.Amount.Abs())
+		if subset == nil {
+			stmtLeft = append(stmtLeft, s)
+			continue
+		}
+
+		for _, i := range subset {
+			matched[i] = true
+			matches = append(matches, Match{
+				Transaction: trxs[i],
+				Statement:   s,
+				Rule:        RuleGrouping,
+			})
+		}
+	}
+
+	return matches, unmatchedTransactions(trxs, matched), stmtLeft
+}
+
+// subset picks the transactions of the group adding up to the amount
+func (m groupingMatcher) subset(trxs []transactions.Transaction, group []int, amount decimal.Decimal) []int {
+	if len(group) == 0 {
+		return nil
+	}
+
+	total := decimal.Zero
+	for _, i := range group {
+		var err error
+		total, err = total.Add(trxs[i].Amount.Abs())
+		if err != nil {
+			return nil
+		}
+	}
+	if total.Cmp(amount) == 0 {
+		return group
+	}
+
+	budget := groupingSearchLimit
+	for size := 2; size <= min(m.maxSize, len(group)-1) && budget > 0; size++ {
+		if found := searchSubset(trxs, group, size, amount, decimal.Zero, nil, &budget); found != nil {
+			return found
+		}
+	}
+
+	return nil
+}
+
+// searchSubset looks for the size transactions out of the group, in
+// their order, adding up to the amount along with the picked ones
+func searchSubset(trxs []transactions.Transaction, group []int, size int, amount, total decimal.Decimal, picked []int, budget *int) []int {
+	if len(picked) == size {
+		if total.Cmp(amount) == 0 {
+			return slices.Clone(picked)
+		}
+		return nil
+	}
+
+	for j, i := range group {
+		if len(group)-j < size-len(picked) {
+			break
+		}
+		if *budget--; *budget < 0 {
+			return nil
+		}
+
+		next, err := total.Add(trxs[i].Amount.Abs())
+		if err != nil || next.Cmp(amount) > 0 {
+			continue
+		}
+		if found := searchSubset(trxs, group[j+1:], size, amount, next, append(picked, i), budget); found != nil {
+			return found
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,136 @@
+package reconciliation
+
+import (
+	"errors"
+	"regexp"
+
+	"github.com/rickyson96/amartha-reconciliation-service/internal/parsers/transactions"
+)
+
+var ErrNoCaptureGroup = errors.New("reference pattern must contain a capture group")
+
+// ReferenceRule extracts our transaction reference out of the statement
+// description. The first capture group of the pattern is treated as the
+// reference, which is then compared against [transactions.Transaction.TrxID].
+type ReferenceRule struct {
+	pattern *regexp.Regexp
+}
+
+// NewReferenceRule compiles the pattern into a [ReferenceRule].
+func NewReferenceRule(pattern string) (*ReferenceRule, error) {
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, err
+	}
+
+	if re.NumSubexp() < 1 {
+		return nil, ErrNoCaptureGroup
+	}
+
+	return &ReferenceRule{pattern: re}, nil
+}
+
+// Extract returns the reference found on the description
+func (r *ReferenceRule) Extract(description string) (string, bool) {
+	match := r.pattern.FindStringSubmatch(description)
+	if match == nil || match[1] == "" {
+		return "", false
+	}
+
+	return match[1], true
+}
+
+type keyMatcher struct {
+	name           string
+	transactionKey func(trx transactions.Transaction) (string, bool)
+	statementKey   func(stmt StatementFilePair) (string, bool)
+}
+
+// NewKeyMatcher matches transaction and statement by type, amount and date.
+// Transactions are compared by their [transactions.Transaction.BookingType]
+// and the absolute amount.
+func NewKeyMatcher() KeyMatcher {
+	return keyMatcher{
+		name: RuleKey,
+		transactionKey: func(trx transactions.Transaction) (string, bool) {
+			return uniqueID(trx.BookingType(), trx.Amount.Abs(), trx.TransactionTime), true
+		},
+		statementKey: func(stmt StatementFilePair) (string, bool) {
+			return uniqueID(stmt.Statement.BookingType(), stmt.Statement.Amount.Abs(), stmt.Statement.Date), true
+		},
+	}
+}
+
+// NewReferenceMatcher matches transaction whose TrxID is the reference
+// of the statement, or the one extracted out of the statement description
+// when the statement doesn't have any. The booking type and amount should
+// match as well, so a wrong amount is left unmatched as a discrepancy.
+//
+// Without the rule, only the reference of the statement is used.
+func NewReferenceMatcher(rule *ReferenceRule) KeyMatcher {
+	return keyMatcher{
+		name: RuleReference,
+		transactionKey: func(trx transactions.Transaction) (string, bool) {
+			if trx.TrxID == "" {
+				return "", false
+			}
+			return trx.TrxID + ":" + amountKey(trx.BookingType(), trx.Amount.Abs()), true
+		},
+		statementKey: func(stmt StatementFilePair) (string, bool) {
+			reference := stmt.Statement.Reference
+			if reference == "" && rule != nil {
+				reference, _ = rule.Extract(stmt.Statement.Description)
+			}
+			if reference == "" {
+				return "", false
+			}
+			return reference + ":" + amountKey(stmt.Statement.BookingType(), stmt.Statement.Amount.Abs()), true
+		},
+	}
+}
+
+func (m keyMatcher) Name() string { return m.name }
+
+func (m keyMatcher) TransactionKey(trx transactions.Transaction) (string, bool) {
+	return m.transactionKey(trx)
+}
+
+func (m keyMatcher) StatementKey(stmt StatementFilePair) (string, bool) {
+	return m.statementKey(stmt)
+}
+
+func (m keyMatcher) Match(trxs []transactions.Transaction, stmts []StatementFilePair) ([]Match, []transactions.Transaction, []StatementFilePair) {
+	var pending map[string][]int
+	for i, t := range trxs {
+		if key, ok := m.transactionKey(t); ok {
+			pending = appendMapOfSlices(pending, key, i)
+		}
+	}
+
+	var matches []Match
+	matched := make([]bool, len(trxs))
+	var stmtLeft []StatementFilePair
+	for _, s := range stmts {
+		key, ok := m.statementKey(s)
+		if !ok {
+			stmtLeft = append(stmtLeft, s)
+			continue
+		}
+
+		candidates, ok := pending[key]
+		if !ok {
+			stmtLeft = append(stmtLeft, s)
+			continue
+		}
+
+		matched[candidates[0]] = true
+		matches = append(matches, Match{
+			Transaction: trxs[candidates[0]],
+			Statement:   s,
+			Rule:        m.name,
+		})
+		popMapOfSlices(pending, key)
+	}
+
+	return matches, unmatchedTransactions(trxs, matched), stmtLeft
+}
@@ -0,0 +1,181 @@
+package reconciliation_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/rickyson96/amartha-reconciliation-service/internal/parsers/statements"
+	"github.com/rickyson96/amartha-reconciliation-service/internal/parsers/transactions"
+	"github.com/rickyson96/amartha-reconciliation-service/internal/processes/reconciliation"
+	"github.com/rickyson96/amartha-reconciliation-service/internal/testutils"
+	"github.com/rickyson96/amartha-reconciliation-service/internal/testutils/recontest"
+)
+
+func TestNewReferenceRule(t *testing.T) {
+	tests := []struct {
+		pattern string
+		wantErr bool
+	}{
+		{`REF:(\S+)`, false},
+		{`REF:\S+`, true},
+		{`REF:(`, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.pattern, func(t *testing.T) {
+			_, err := reconciliation.NewReferenceRule(test.pattern)
+			if test.wantErr != (err != nil) {
+				t.Errorf("wantErr is %t, but err is %v", test.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestReferenceRule_Extract(t *testing.T) {
+	rule, err := reconciliation.NewReferenceRule(`REF:(\w+)`)
+	if err != nil {
+		t.Fatalf("NewReferenceRule failed: %v", err)
+	}
+
+	tests := []struct {
+		description string
+		want        string
+		wantOk      bool
+	}{
+		{"TRF REF:TRX1 FROM ACME", "TRX1", true},
+		{"REF:TRX2", "TRX2", true},
+		{"TRF FROM ACME", "", false},
+		{"", "", false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			got, ok := rule.Extract(test.description)
+			if ok != test.wantOk || got != test.want {
+				t.Errorf("Extract(%q) = (%q, %t), want (%q, %t)", test.description, got, ok, test.want, test.wantOk)
+			}
+		})
+	}
+}
+
+func TestProcessWithReferenceMatcher(t *testing.T) {
+	rule, err := reconciliation.NewReferenceRule(`REF:(\w+)`)
+	if err != nil {
+		t.Fatalf("NewReferenceRule failed: %v", err)
+	}
+
+	trxs := []transactions.Transaction{{
+		TrxID:           "TRX1",
+		Amount:          testutils.NewDecimal(t, 10, 0),
+		Type:            transactions.TransactionTypeCredit,
+		TransactionTime: time.Date(2025, 03, 14, 10, 10, 10, 10, time.Local),
+	}, {
+		TrxID:           "TRX2",
+		Amount:          testutils.NewDecimal(t, 10, 0),
+		Type:            transactions.TransactionTypeCredit,
+		TransactionTime: time.Date(2025, 03, 14, 10, 10, 10, 10, time.Local),
+	}, {
+		TrxID:           "TRX3",
+		Amount:          testutils.NewDecimal(t, 20, 0),
+		Type:            transactions.TransactionTypeCredit,
+		TransactionTime: time.Date(2025, 03, 14, 10, 10, 10, 10, time.Local),
+	}, {
+		TrxID:           "TRX4",
+		Amount:          testutils.NewDecimal(t, 30, 0),
+		Type:            transactions.TransactionTypeCredit,
+		TransactionTime: time.Date(2025, 03, 14, 10, 10, 10, 10, time.Local),
+	}}
+	stmts := map[string][]statements.Statement{
+		"bank1.csv": {{
+			// matched by key as it doesn't carry any reference
+			UniqueIdentifier: "1",
+			Amount:           testutils.NewDecimal(t, 10, 0),
+			Date:             time.Date(2025, 03, 14, 0, 0, 0, 0, time.Local),
+		}, {
+			// matched by reference, even though the date differs
+			UniqueIdentifier: "2",
+			Amount:           testutils.NewDecimal(t, 10, 0),
+			Date:             time.Date(2025, 03, 15, 0, 0, 0, 0, time.Local),
+			Description:      "TRF REF:TRX1",
+		}, {
+			// matched by the structured reference over the description
+			UniqueIdentifier: "3",
+			Amount:           testutils.NewDecimal(t, 20, 0),
+			Date:             time.Date(2025, 03, 16, 0, 0, 0, 0, time.Local),
+			Description:      "TRF REF:TRX2",
+			Reference:        "TRX3",
+		}, {
+			// left unmatched as the amount differs
+			UniqueIdentifier: "4",
+			Amount:           testutils.NewDecimal(t, 31, 0),
+			Date:             time.Date(2025, 03, 14, 0, 0, 0, 0, time.Local),
+			Reference:        "TRX4",
+		}},
+	}
+
+	want := map[string]string{
+		"TRX1": reconciliation.RuleReference,
+		"TRX2": reconciliation.RuleKey,
+		"TRX3": reconciliation.RuleReference,
+	}
+
+	opts := reconciliation.Options{Matchers: []reconciliation.Matcher{
+		reconciliation.NewReferenceMatcher(rule),
+		reconciliation.NewKeyMatcher(),
+	}}
+	t.Run("Process", func(t *testing.T) {
+		got := reconciliation.Process(trxs, stmts, opts)
+		if diff := cmp.Diff(want, recontest.MatchedRules(got)); diff != "" {
+			t.Errorf("matched rules mismatch, (-want,+got):\n%s", diff)
+		}
+	})
+
+	t.Run("ProcessConcurrent", func(t *testing.T) {
+		got, err := reconciliation.ProcessConcurrent(testutils.NewReader(trxs).Read, testutils.NewReader(fileStatementPairConverter(stmts)).Read, opts)
+		if err != nil {
+			t.Errorf("unwanted error: %v", err)
+		}
+		if diff := cmp.Diff(want, recontest.MatchedRules(got)); diff != "" {
+			t.Errorf("matched rules mismatch, (-want,+got):\n%s", diff)
+		}
+	})
+}
+
+func TestReferenceMatcherWithoutRule(t *testing.T) {
+	matcher := reconciliation.NewReferenceMatcher(nil)
+
+	stmt := reconciliation.StatementFilePair{Statement: statements.Statement{Description: "TRF REF:TRX1"}}
+	if key, ok := matcher.StatementKey(stmt); ok {
+		t.Errorf("StatementKey() = %q, want no key without the reference", key)
+	}
+
+	stmt.Statement.Reference = "TRX1"
+	if _, ok := matcher.StatementKey(stmt); !ok {
+		t.Errorf("StatementKey() should use the reference of the statement")
+	}
+}
+
+func TestKeyMatcherUsesStatementType(t *testing.T) {
+	trxs := []transactions.Transaction{{
+		TrxID:           "1",
+		Amount:          testutils.NewDecimal(t, 10, 0),
+		Type:            transactions.TransactionTypeDebit,
+		TransactionTime: time.Date(2025, 03, 14, 10, 10, 10, 10, time.Local),
+	}}
+	stmts := map[string][]statements.Statement{
+		"bank1.csv": {{
+			// the type is explicitly set by the statement format, so the
+			// amount sign shouldn't matter
+			UniqueIdentifier: "10",
+			Amount:           testutils.NewDecimal(t, 10, 0),
+			Date:             time.Date(2025, 03, 14, 0, 0, 0, 0, time.Local),
+			Type:             transactions.TransactionTypeDebit,
+		}},
+	}
+
+	got := reconciliation.Process(trxs, stmts, reconciliation.Options{})
+	if got.Match != 2 {
+		t.Errorf("Process should match debit statement, got %d matches", got.Match)
+	}
+}
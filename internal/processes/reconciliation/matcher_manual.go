@@ -0,0 +1,57 @@
+package reconciliation
+
+import (
+	"github.com/rickyson96/amartha-reconciliation-service/internal/parsers/transactions"
+)
+
+// ManualMatch is the pair of transaction and statement decided by a
+// human, e.g. when the bank booked it with a wrong amount.
+type ManualMatch struct {
+	TrxID string
+	// Source is the statement source name, i.e. its file
+	Source           string
+	UniqueIdentifier string
+}
+
+type manualMatcher struct {
+	pairs map[string]string
+}
+
+// NewManualMatcher matches the transaction and statement of the manual
+// matches regardless of their type, amount and date. The TrxID shared by
+// multiple transactions is ambiguous, so it's never matched.
+func NewManualMatcher(pairs []ManualMatch) Matcher {
+	m := manualMatcher{pairs: make(map[string]string, len(pairs))}
+	for _, p := range pairs {
+		m.pairs[statementRef(p.Source, p.UniqueIdentifier)] = p.TrxID
+	}
+
+	return m
+}
+
+func (m manualMatcher) Name() string { return RuleManual }
+
+func (m manualMatcher) Match(trxs []transactions.Transaction, stmts []StatementFilePair) ([]Match, []transactions.Transaction, []StatementFilePair) {
+	candidates := indexByID(trxs)
+
+	var matches []Match
+	matched := make([]bool, len(trxs))
+	var stmtLeft []StatementFilePair
+	for _, s := range stmts {
+		trxID, ok := m.pairs[statementRef(s.Name, s.Statement.UniqueIdentifier)]
+		i, found := candidates[trxID]
+		if !ok || !found || i == ambiguousID || matched[i] {
+			stmtLeft = append(stmtLeft, s)
+			continue
+		}
+
+		matched[i] = true
+		matches = append(matches, Match{
+			Transaction: trxs[i],
+			Statement:   s,
+			Rule:        RuleManual,
+		})
+	}
+
+	return matches, unmatchedTransactions(trxs, matched), stmtLeft
+}
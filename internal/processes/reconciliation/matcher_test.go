@@ -0,0 +1,277 @@
+package reconciliation_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/rickyson96/amartha-reconciliation-service/internal/parsers/statements"
+	"github.com/rickyson96/amartha-reconciliation-service/internal/parsers/transactions"
+	"github.com/rickyson96/amartha-reconciliation-service/internal/processes/reconciliation"
+	"github.com/rickyson96/amartha-reconciliation-service/internal/testutils"
+	"github.com/rickyson96/amartha-reconciliation-service/internal/testutils/recontest"
+)
+
+func TestMatchers(t *testing.T) {
+	trx := func(id string, amount int64, trxType transactions.TransactionType, day int) transactions.Transaction {
+		return transactions.Transaction{
+			TrxID:           id,
+			Amount:          testutils.NewDecimal(t, amount, 2),
+			Type:            trxType,
+			TransactionTime: time.Date(2025, 03, day, 10, 10, 10, 10, time.UTC),
+		}
+	}
+	stmt := func(id string, amount int64, day int) statements.Statement {
+		return statements.Statement{
+			UniqueIdentifier: id,
+			Amount:           testutils.NewDecimal(t, amount, 2),
+			Date:             time.Date(2025, 03, day, 0, 0, 0, 0, time.UTC),
+		}
+	}
+
+	tests := []struct {
+		name          string
+		matcher       reconciliation.Matcher
+		trxs          []transactions.Transaction
+		stmts         []statements.Statement
+		wantMatches   map[string]string
+		wantUnmatched int
+	}{
+		{
+			name:    "tolerance picks the closest amount",
+			matcher: reconciliation.NewToleranceMatcher(testutils.NewDecimal(t, 50, 2)),
+			trxs: []transactions.Transaction{
+				trx("1", 1040, transactions.TransactionTypeCredit, 14),
+				trx("2", 1010, transactions.TransactionTypeCredit, 14),
+				trx("3", 1010, transactions.TransactionTypeCredit, 15),
+				trx("4", 2000, transactions.TransactionTypeDebit, 14),
+			},
+			stmts: []statements.Statement{
+				stmt("a", 1000, 14),
+				stmt("b", 1100, 15),
+				stmt("c", -2049, 14),
+			},
+			wantMatches:   map[string]string{"2": "a", "4": "c"},
+			wantUnmatched: 3,
+		},
+		{
+			name:    "date window picks the closest date",
+			matcher: reconciliation.NewDateWindowMatcher(2),
+			trxs: []transactions.Transaction{
+				trx("1", 1000, transactions.TransactionTypeCredit, 10),
+				trx("2", 1000, transactions.TransactionTypeCredit, 13),
+				trx("3", 1000, transactions.TransactionTypeDebit, 14),
+			},
+			stmts: []statements.Statement{
+				stmt("a", 1000, 14),
+				stmt("b", 1000, 20),
+			},
+			wantMatches:   map[string]string{"2": "a"},
+			wantUnmatched: 3,
+		},
+		{
+			name:    "grouping matches the total of the day",
+			matcher: reconciliation.NewGroupingMatcher(0),
+			trxs: []transactions.Transaction{
+				trx("1", 1000, transactions.TransactionTypeDebit, 14),
+				trx("2", 2000, transactions.TransactionTypeDebit, 14),
+				trx("3", 1000, transactions.TransactionTypeCredit, 14),
+				trx("4", 500, transactions.TransactionTypeDebit, 15),
+			},
+			stmts: []statements.Statement{
+				stmt("a", -3000, 14),
+				stmt("b", -600, 15),
+			},
+			wantMatches:   map[string]string{"1": "a", "2": "a"},
+			wantUnmatched: 3,
+		},
+		{
+			name:    "grouping leaves out the unrelated transactions of the day",
+			matcher: reconciliation.NewGroupingMatcher(0),
+			trxs: []transactions.Transaction{
+				trx("1", 1000, transactions.TransactionTypeCredit, 14),
+				trx("2", 2000, transactions.TransactionTypeCredit, 14),
+				trx("3", 500, transactions.TransactionTypeCredit, 14),
+				trx("4", 700, transactions.TransactionTypeCredit, 14),
+				trx("5", 250, transactions.TransactionTypeCredit, 14),
+			},
+			stmts: []statements.Statement{
+				stmt("a", 3000, 14),
+				stmt("b", 1200, 14),
+			},
+			wantMatches:   map[string]string{"1": "a", "2": "a", "3": "b", "4": "b"},
+			wantUnmatched: 1,
+		},
+		{
+			name:    "grouping is bounded by the max group size",
+			matcher: reconciliation.NewGroupingMatcher(2),
+			trxs: []transactions.Transaction{
+				trx("1", 1000, transactions.TransactionTypeCredit, 14),
+				trx("2", 2000, transactions.TransactionTypeCredit, 14),
+				trx("3", 500, transactions.TransactionTypeCredit, 14),
+				trx("4", 250, transactions.TransactionTypeCredit, 14),
+			},
+			stmts: []statements.Statement{
+				stmt("a", 3500, 14),
+			},
+			wantMatches:   map[string]string{},
+			wantUnmatched: 5,
+		},
+		{
+			name: "manual matches regardless of the key",
+			matcher: reconciliation.NewManualMatcher([]reconciliation.ManualMatch{
+				{TrxID: "1", Source: "bank1.csv", UniqueIdentifier: "b"},
+				{TrxID: "2", Source: "bank2.csv", UniqueIdentifier: "a"},
+			}),
+			trxs: []transactions.Transaction{
+				trx("1", 1000, transactions.TransactionTypeCredit, 14),
+				trx("2", 1000, transactions.TransactionTypeCredit, 14),
+			},
+			stmts: []statements.Statement{
+				stmt("a", 1000, 14),
+				stmt("b", -999, 20),
+			},
+			wantMatches:   map[string]string{"1": "b"},
+			wantUnmatched: 2,
+		},
+		{
+			name: "manual refuses the TrxID shared by transactions",
+			matcher: reconciliation.NewManualMatcher([]reconciliation.ManualMatch{
+				{TrxID: "1", Source: "bank1.csv", UniqueIdentifier: "a"},
+			}),
+			trxs: []transactions.Transaction{
+				trx("1", 1000, transactions.TransactionTypeCredit, 14),
+				trx("1", 2000, transactions.TransactionTypeCredit, 15),
+			},
+			stmts: []statements.Statement{
+				stmt("a", 999, 14),
+			},
+			wantMatches:   map[string]string{},
+			wantUnmatched: 3,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			opts := reconciliation.Options{Matchers: []reconciliation.Matcher{test.matcher}}
+			stmts := map[string][]statements.Statement{"bank1.csv": test.stmts}
+
+			assert := func(t *testing.T, got reconciliation.Result) {
+				t.Helper()
+				matches := make(map[string]string)
+				for _, m := range got.Matched {
+					matches[m.Transaction.TrxID] = m.Statement.Statement.UniqueIdentifier
+					if m.Rule != test.matcher.Name() {
+						t.Errorf("rule mismatch, want %s, got %s", test.matcher.Name(), m.Rule)
+					}
+				}
+				if diff := cmp.Diff(test.wantMatches, matches); diff != "" {
+					t.Errorf("matches mismatch, (-want,+got):\n%s", diff)
+				}
+				if got.Processed-got.Match != test.wantUnmatched {
+					t.Errorf("unmatched count mismatch, want %d, got %d", test.wantUnmatched, got.Processed-got.Match)
+				}
+				if got.RuleMatches[test.matcher.Name()] != got.Match {
+					t.Errorf("rule matches %v doesn't add up to %d", got.RuleMatches, got.Match)
+				}
+			}
+
+			t.Run("Process", func(t *testing.T) {
+				assert(t, reconciliation.Process(test.trxs, stmts, opts))
+			})
+
+			t.Run("ProcessConcurrent", func(t *testing.T) {
+				got, err := reconciliation.ProcessConcurrent(testutils.NewReader(test.trxs).Read, testutils.NewReader(fileStatementPairConverter(stmts)).Read, opts)
+				if err != nil {
+					t.Errorf("unwanted error: %v", err)
+				}
+				assert(t, got)
+			})
+		})
+	}
+}
+
+func TestMatchersPipeline(t *testing.T) {
+	trxs := []transactions.Transaction{{
+		TrxID:           "1",
+		Amount:          testutils.NewDecimal(t, 10, 0),
+		Type:            transactions.TransactionTypeCredit,
+		TransactionTime: time.Date(2025, 03, 14, 10, 10, 10, 10, time.UTC),
+	}, {
+		TrxID:           "2",
+		Amount:          testutils.NewDecimal(t, 20, 0),
+		Type:            transactions.TransactionTypeCredit,
+		TransactionTime: time.Date(2025, 03, 14, 10, 10, 10, 10, time.UTC),
+	}, {
+		TrxID:           "3",
+		Amount:          testutils.NewDecimal(t, 30, 0),
+		Type:            transactions.TransactionTypeCredit,
+		TransactionTime: time.Date(2025, 03, 14, 10, 10, 10, 10, time.UTC),
+	}}
+	stmts := map[string][]statements.Statement{
+		"bank1.csv": {{
+			UniqueIdentifier: "a",
+			Amount:           testutils.NewDecimal(t, 10, 0),
+			Date:             time.Date(2025, 03, 14, 0, 0, 0, 0, time.UTC),
+		}, {
+			UniqueIdentifier: "b",
+			Amount:           testutils.NewDecimal(t, 20, 0),
+			Date:             time.Date(2025, 03, 16, 0, 0, 0, 0, time.UTC),
+		}, {
+			UniqueIdentifier: "c",
+			Amount:           testutils.NewDecimal(t, 3001, 2),
+			Date:             time.Date(2025, 03, 14, 0, 0, 0, 0, time.UTC),
+		}},
+	}
+
+	wantRules := map[string]string{
+		"1": reconciliation.RuleKey,
+		"2": reconciliation.RuleDateWindow,
+		"3": reconciliation.RuleTolerance,
+	}
+	wantRuleMatches := map[string]int{
+		reconciliation.RuleKey:        2,
+		reconciliation.RuleDateWindow: 2,
+		reconciliation.RuleTolerance:  2,
+	}
+
+	pipelines := map[string][]reconciliation.Matcher{
+		"streamed key matcher": {
+			reconciliation.NewKeyMatcher(),
+			reconciliation.NewToleranceMatcher(testutils.NewDecimal(t, 1, 1)),
+			reconciliation.NewDateWindowMatcher(3),
+		},
+		// grouping can't match anything as the day total is not listed
+		"non key matcher first": {
+			reconciliation.NewGroupingMatcher(0),
+			reconciliation.NewKeyMatcher(),
+			reconciliation.NewDateWindowMatcher(3),
+			reconciliation.NewToleranceMatcher(testutils.NewDecimal(t, 1, 1)),
+		},
+	}
+
+	for name, matchers := range pipelines {
+		t.Run(name, func(t *testing.T) {
+			opts := reconciliation.Options{Matchers: matchers}
+
+			got := reconciliation.Process(trxs, stmts, opts)
+			if diff := cmp.Diff(wantRules, recontest.MatchedRules(got)); diff != "" {
+				t.Errorf("Process matched rules mismatch, (-want,+got):\n%s", diff)
+			}
+			if diff := cmp.Diff(wantRuleMatches, got.RuleMatches); diff != "" {
+				t.Errorf("Process rule matches mismatch, (-want,+got):\n%s", diff)
+			}
+
+			got, err := reconciliation.ProcessConcurrent(testutils.NewReader(trxs).Read, testutils.NewReader(fileStatementPairConverter(stmts)).Read, opts)
+			if err != nil {
+				t.Errorf("unwanted error: %v", err)
+			}
+			if diff := cmp.Diff(wantRules, recontest.MatchedRules(got)); diff != "" {
+				t.Errorf("ProcessConcurrent matched rules mismatch, (-want,+got):\n%s", diff)
+			}
+			if diff := cmp.Diff(wantRuleMatches, got.RuleMatches); diff != "" {
+				t.Errorf("ProcessConcurrent rule matches mismatch, (-want,+got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,64 @@
+package reconciliation
+
+import (
+	"github.com/govalues/decimal"
+	"github.com/rickyson96/amartha-reconciliation-service/internal/parsers/transactions"
+)
+
+type toleranceMatcher struct {
+	tolerance decimal.Decimal
+}
+
+// NewToleranceMatcher matches transaction and statement of the same type
+// and date, whose amount differs by at most the tolerance. The closest
+// amount is preferred when there are multiple candidates.
+func NewToleranceMatcher(tolerance decimal.Decimal) Matcher {
+	return toleranceMatcher{tolerance: tolerance.Abs()}
+}
+
+func (m toleranceMatcher) Name() string { return RuleTolerance }
+
+func (m toleranceMatcher) Match(trxs []transactions.Transaction, stmts []StatementFilePair) ([]Match, []transactions.Transaction, []StatementFilePair) {
+	var candidates map[string][]int
+	for i, t := range trxs {
+		candidates = appendMapOfSlices(candidates, dayKey(t.BookingType(), t.TransactionTime), i)
+	}
+
+	var matches []Match
+	matched := make([]bool, len(trxs))
+	var stmtLeft []StatementFilePair
+	for _, s := range stmts {
+		amount := s.Statement.Amount.Abs()
+
+		best := -1
+		var bestDiff decimal.Decimal
+		for _, i := range candidates[dayKey(s.Statement.BookingType(), s.Statement.Date)] {
+			if matched[i] {
+				continue
+			}
+
+			diff, err := trxs[i].Amount.Abs().SubAbs(amount)
+			if err != nil || diff.Cmp(m.tolerance) > 0 {
+				continue
+			}
+
+			if best == -1 || diff.Cmp(bestDiff) < 0 {
+				best, bestDiff = i, diff
+			}
+		}
+
+		if best == -1 {
+			stmtLeft = append(stmtLeft, s)
+			continue
+		}
+
+		matched[best] = true
+		matches = append(matches, Match{
+			Transaction: trxs[best],
+			Statement:   s,
+			Rule:        RuleTolerance,
+		})
+	}
+
+	return matches, unmatchedTransactions(trxs, matched), stmtLeft
+}
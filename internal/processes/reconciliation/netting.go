@@ -0,0 +1,110 @@
+package reconciliation
+
+import (
+	"time"
+
+	"github.com/rickyson96/amartha-reconciliation-service/internal/parsers/transactions"
+)
+
+const (
+	// NettingReference nets transaction with the transaction it refers to
+	NettingReference = "reference"
+	// NettingWindow nets transactions of the same amount and opposite
+	// booking type within the time window
+	NettingWindow = "window"
+)
+
+// Netting configures the pre-processing stage that nets out reversed
+// transactions before matching, since the bank shows nothing for them.
+type Netting struct {
+	// ByReference nets transaction whose [transactions.Transaction.Reference]
+	// is the TrxID of another transaction with the same amount and
+	// opposite booking type. References to a TrxID shared by multiple
+	// transactions are ambiguous, so they're never netted.
+	ByReference bool
+	// Window nets transactions of the same amount and opposite booking
+	// type happening within the duration. Zero disables it.
+	Window time.Duration
+}
+
+// NettedPair is the pair of transactions cancelling each other out
+type NettedPair struct {
+	Original transactions.Transaction
+	Reversal transactions.Transaction
+	Rule     string
+}
+
+// net pairs up the transactions cancelling each other out, and returns
+// whatever is left in their original order.
+func (n *Netting) net(trxs []transactions.Transaction) ([]NettedPair, []transactions.Transaction) {
+	if n == nil {
+		return nil, trxs
+	}
+
+	var pairs []NettedPair
+	netted := make([]bool, len(trxs))
+
+	if n.ByReference {
+		byID := indexByID(trxs)
+		for i, t := range trxs {
+			j, ok := byID[t.Reference]
+			if t.Reference == "" || !ok || j == ambiguousID || i == j || netted[i] || netted[j] || !cancels(trxs[j], t) {
+				continue
+			}
+
+			netted[i], netted[j] = true, true
+			pairs = append(pairs, NettedPair{Original: trxs[j], Reversal: t, Rule: NettingReference})
+		}
+	}
+
+	if n.Window > 0 {
+		var candidates map[string][]int
+		for i, t := range trxs {
+			if !netted[i] {
+				candidates = appendMapOfSlices(candidates, t.Amount.Abs().Trim(0).String(), i)
+			}
+		}
+
+		for i, t := range trxs {
+			if netted[i] {
+				continue
+			}
+
+			best := -1
+			var bestDiff time.Duration
+			for _, j := range candidates[t.Amount.Abs().Trim(0).String()] {
+				if netted[j] || !cancels(t, trxs[j]) {
+					continue
+				}
+
+				diff := trxs[j].TransactionTime.Sub(t.TransactionTime).Abs()
+				if diff > n.Window {
+					continue
+				}
+
+				if best == -1 || diff < bestDiff {
+					best, bestDiff = j, diff
+				}
+			}
+
+			if best == -1 {
+				continue
+			}
+
+			original, reversal := t, trxs[best]
+			if reversal.TransactionTime.Before(original.TransactionTime) {
+				original, reversal = reversal, original
+			}
+
+			netted[i], netted[best] = true, true
+			pairs = append(pairs, NettedPair{Original: original, Reversal: reversal, Rule: NettingWindow})
+		}
+	}
+
+	return pairs, unmatchedTransactions(trxs, netted)
+}
+
+// cancels checks whether both transactions cancel each other out
+func cancels(a, b transactions.Transaction) bool {
+	return a.BookingType() != b.BookingType() && a.Amount.Abs().Cmp(b.Amount.Abs()) == 0
+}
@@ -0,0 +1,123 @@
+package reconciliation_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/rickyson96/amartha-reconciliation-service/internal/parsers/statements"
+	"github.com/rickyson96/amartha-reconciliation-service/internal/parsers/transactions"
+	"github.com/rickyson96/amartha-reconciliation-service/internal/processes/reconciliation"
+	"github.com/rickyson96/amartha-reconciliation-service/internal/testutils"
+)
+
+func TestNetting(t *testing.T) {
+	trx := func(id string, amount int64, trxType transactions.TransactionType, hour int, reference string) transactions.Transaction {
+		return transactions.Transaction{
+			TrxID:           id,
+			Amount:          testutils.NewDecimal(t, amount, 0),
+			Type:            trxType,
+			TransactionTime: time.Date(2025, 03, 14, hour, 0, 0, 0, time.UTC),
+			Reference:       reference,
+		}
+	}
+
+	trxs := []transactions.Transaction{
+		trx("1", 10, transactions.TransactionTypeDebit, 1, ""),
+		trx("2", 20, transactions.TransactionTypeDebit, 2, ""),
+		trx("3", 10, transactions.TransactionTypeReversal, 3, "1"),
+		trx("4", 20, transactions.TransactionTypeCredit, 4, ""),
+		// reference to different amount isn't netted
+		trx("5", 30, transactions.TransactionTypeDebit, 5, ""),
+		trx("6", 31, transactions.TransactionTypeReversal, 6, "5"),
+		// outside of the window
+		trx("7", 40, transactions.TransactionTypeDebit, 1, ""),
+		trx("8", 40, transactions.TransactionTypeCredit, 23, ""),
+	}
+	stmts := map[string][]statements.Statement{
+		"bank1.csv": {{
+			UniqueIdentifier: "a",
+			Amount:           testutils.NewDecimal(t, -40, 0),
+			Date:             time.Date(2025, 03, 14, 0, 0, 0, 0, time.UTC),
+			Type:             transactions.TransactionTypeDebit,
+		}},
+	}
+
+	tests := []struct {
+		name          string
+		netting       *reconciliation.Netting
+		wantNetted    map[string]string
+		wantUnmatched int
+	}{
+		{"disabled", nil, map[string]string{}, 7},
+		{"by reference", &reconciliation.Netting{ByReference: true}, map[string]string{"1": "3"}, 5},
+		{"by window", &reconciliation.Netting{Window: 4 * time.Hour}, map[string]string{"1": "3", "2": "4"}, 3},
+		{
+			name:          "by reference and window",
+			netting:       &reconciliation.Netting{ByReference: true, Window: 4 * time.Hour},
+			wantNetted:    map[string]string{"1": "3", "2": "4"},
+			wantUnmatched: 3,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			opts := reconciliation.Options{Netting: test.netting}
+
+			assert := func(t *testing.T, got reconciliation.Result) {
+				t.Helper()
+				netted := make(map[string]string)
+				for _, p := range got.Netted {
+					netted[p.Original.TrxID] = p.Reversal.TrxID
+				}
+				if diff := cmp.Diff(test.wantNetted, netted); diff != "" {
+					t.Errorf("netted mismatch, (-want,+got):\n%s", diff)
+				}
+				if got.UnmatchedCount() != test.wantUnmatched {
+					t.Errorf("unmatched count mismatch, want %d, got %d", test.wantUnmatched, got.UnmatchedCount())
+				}
+				if got.Match != 2 {
+					t.Errorf("trx 7 should match statement a, got %d matches", got.Match)
+				}
+			}
+
+			t.Run("Process", func(t *testing.T) {
+				assert(t, reconciliation.Process(trxs, stmts, opts))
+			})
+
+			t.Run("ProcessConcurrent", func(t *testing.T) {
+				got, err := reconciliation.ProcessConcurrent(testutils.NewReader(trxs).Read, testutils.NewReader(fileStatementPairConverter(stmts)).Read, opts)
+				if err != nil {
+					t.Errorf("unwanted error: %v", err)
+				}
+				assert(t, got)
+			})
+		})
+	}
+}
+
+func TestNettingAmbiguousReference(t *testing.T) {
+	trx := func(id string, trxType transactions.TransactionType, reference string) transactions.Transaction {
+		return transactions.Transaction{
+			TrxID:           id,
+			Amount:          testutils.NewDecimal(t, 10, 0),
+			Type:            trxType,
+			TransactionTime: time.Date(2025, 03, 14, 0, 0, 0, 0, time.UTC),
+			Reference:       reference,
+		}
+	}
+
+	// the reversal can't tell which of the transaction 1 it reverses
+	trxs := []transactions.Transaction{
+		trx("1", transactions.TransactionTypeDebit, ""),
+		trx("1", transactions.TransactionTypeDebit, ""),
+		trx("2", transactions.TransactionTypeReversal, "1"),
+	}
+	got := reconciliation.Process(trxs, nil, reconciliation.Options{Netting: &reconciliation.Netting{ByReference: true}})
+	if len(got.Netted) != 0 {
+		t.Errorf("ambiguous reference shouldn't be netted, got %v", got.Netted)
+	}
+	if got.UnmatchedCount() != 3 {
+		t.Errorf("unmatched count mismatch, want 3, got %d", got.UnmatchedCount())
+	}
+}
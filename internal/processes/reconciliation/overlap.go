@@ -0,0 +1,81 @@
+package reconciliation
+
+// Overlap is the statement line found on multiple sources of the same
+// account, e.g. overlapping exports of the same bank
+type Overlap struct {
+	Account          string
+	UniqueIdentifier string
+	// Source is the source whose line is kept
+	Source string
+	// Dropped is the other sources whose lines are dropped
+	Dropped []string
+}
+
+// overlaps drops statement lines already read from another source of the
+// same account
+type overlaps struct {
+	accounts map[string]string
+	// seen maps the account line into the source it's first read from
+	seen map[string]string
+	// index maps the account line into its overlap on the result
+	index map[string]int
+}
+
+func newOverlaps(accounts map[string]string) *overlaps {
+	return &overlaps{
+		accounts: accounts,
+		seen:     make(map[string]string),
+		index:    make(map[string]int),
+	}
+}
+
+// drop reports whether the statement is a line of another source of the
+// same account, recording the overlap into the result when it's given.
+// Sources without account are never dropped.
+func (o *overlaps) drop(r *Result, stmt StatementFilePair) bool {
+	account, ok := o.accounts[stmt.Name]
+	if !ok || account == "" {
+		return false
+	}
+
+	key := statementRef(account, stmt.Statement.UniqueIdentifier)
+	source, ok := o.seen[key]
+	if !ok {
+		o.seen[key] = stmt.Name
+		return false
+	}
+	if source == stmt.Name {
+		return false
+	}
+	if r == nil {
+		return true
+	}
+
+	i, ok := o.index[key]
+	if !ok {
+		i = len(r.Overlaps)
+		o.index[key] = i
+		r.Overlaps = append(r.Overlaps, Overlap{
+			Account:          account,
+			UniqueIdentifier: stmt.Statement.UniqueIdentifier,
+			Source:           source,
+		})
+	}
+	r.Overlaps[i].Dropped = append(r.Overlaps[i].Dropped, stmt.Name)
+	return true
+}
+
+// OverlapReader drops the statement lines already read from another
+// source of the same account, like [Process] does, for the processes
+// other than the reconciliation, see [Options.Accounts]
+func OverlapReader(r Reader[StatementFilePair], accounts map[string]string) Reader[StatementFilePair] {
+	o := newOverlaps(accounts)
+	return func() (StatementFilePair, error) {
+		for {
+			stmt, err := r()
+			if err != nil || !o.drop(nil, stmt) {
+				return stmt, err
+			}
+		}
+	}
+}
@@ -0,0 +1,93 @@
+package reconciliation_test
+
+import (
+	"maps"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/rickyson96/amartha-reconciliation-service/internal/parsers/statements"
+	"github.com/rickyson96/amartha-reconciliation-service/internal/parsers/transactions"
+	"github.com/rickyson96/amartha-reconciliation-service/internal/processes/reconciliation"
+	"github.com/rickyson96/amartha-reconciliation-service/internal/testutils"
+)
+
+func TestOverlaps(t *testing.T) {
+	stmt := func(id string, amount int64) statements.Statement {
+		return statements.Statement{
+			UniqueIdentifier: id,
+			Amount:           testutils.NewDecimal(t, amount, 0),
+			Date:             time.Date(2025, 03, 14, 0, 0, 0, 0, time.UTC),
+			Type:             transactions.TransactionTypeCredit,
+		}
+	}
+
+	trxs := []transactions.Transaction{{
+		TrxID:           "1",
+		Amount:          testutils.NewDecimal(t, 10, 0),
+		Type:            transactions.TransactionTypeCredit,
+		TransactionTime: time.Date(2025, 03, 14, 10, 10, 10, 10, time.UTC),
+	}}
+	stmts := map[string][]statements.Statement{
+		"bank1_month.csv": {stmt("a", 10), stmt("b", 20)},
+		"bank1_week1.csv": {stmt("a", 10)},
+		"bank1_week2.csv": {stmt("a", 10), stmt("b", 20)},
+		// another bank may reuse the identifier
+		"bank2.csv": {stmt("a", 10)},
+	}
+	opts := reconciliation.Options{Accounts: map[string]string{
+		"bank1_month.csv": "bank1",
+		"bank1_week1.csv": "bank1",
+		"bank1_week2.csv": "bank1",
+	}}
+
+	want := []reconciliation.Overlap{
+		{Account: "bank1", UniqueIdentifier: "a", Source: "bank1_month.csv", Dropped: []string{"bank1_week1.csv", "bank1_week2.csv"}},
+		{Account: "bank1", UniqueIdentifier: "b", Source: "bank1_month.csv", Dropped: []string{"bank1_week2.csv"}},
+	}
+
+	assert := func(t *testing.T, got reconciliation.Result) {
+		t.Helper()
+		if diff := cmp.Diff(want, got.Overlaps); diff != "" {
+			t.Errorf("overlaps mismatch, (-want,+got):\n%s", diff)
+		}
+		// the transaction, bank1 a and b, and bank2 a
+		if got.Processed != 4 || got.Match != 2 || got.UnmatchedCount() != 2 {
+			t.Errorf("count mismatch, processed %d, match %d, unmatched %d", got.Processed, got.Match, got.UnmatchedCount())
+		}
+	}
+
+	t.Run("Process", func(t *testing.T) {
+		assert(t, reconciliation.Process(trxs, stmts, opts))
+	})
+
+	// the first source read is kept, so the files are read in order
+	var pairs []reconciliation.StatementFilePair
+	for _, fileName := range slices.Sorted(maps.Keys(stmts)) {
+		for _, s := range stmts[fileName] {
+			pairs = append(pairs, reconciliation.StatementFilePair{Name: fileName, Statement: s})
+		}
+	}
+
+	t.Run("ProcessConcurrent", func(t *testing.T) {
+		got, err := reconciliation.ProcessConcurrent(testutils.NewReader(trxs).Read, testutils.NewReader(pairs).Read, opts)
+		if err != nil {
+			t.Errorf("unwanted error: %v", err)
+		}
+		assert(t, got)
+	})
+
+	t.Run("OverlapReader", func(t *testing.T) {
+		read := reconciliation.OverlapReader(testutils.NewReader(pairs).Read, opts.Accounts)
+		var got []string
+		for pair, err := read(); err == nil; pair, err = read() {
+			got = append(got, pair.Name+" "+pair.Statement.UniqueIdentifier)
+		}
+
+		want := []string{"bank1_month.csv a", "bank1_month.csv b", "bank2.csv a"}
+		if diff := cmp.Diff(want, got); diff != "" {
+			t.Errorf("OverlapReader mismatch, (-want,+got):\n%s", diff)
+		}
+	})
+}
@@ -2,6 +2,8 @@ package reconciliation
 
 import (
 	"fmt"
+	"maps"
+	"slices"
 	"time"
 
 	"github.com/govalues/decimal"
@@ -12,49 +14,144 @@ import (
 type Result struct {
 	Processed int
 	Match     int
-	Unmatched struct {
+	// RuleMatches counts the matched transactions and statements per rule
+	RuleMatches map[string]int
+	Matched     []Match
+	// Netted is the transactions netted out before matching
+	Netted []NettedPair
+	// BalanceChecks is the balance verification per statement source
+	BalanceChecks map[string]BalanceCheck
+	Unmatched     struct {
 		Transactions []transactions.Transaction
 		Statements   map[string][]statements.Statement
 	}
+	// Overlaps is the statement lines dropped for being read from another
+	// source of the same account
+	Overlaps []Overlap
+	// Pending is the statement lines not booked yet, keyed by the source.
+	// They may still change, so they're neither matched nor part of the
+	// balance check.
+	Pending map[string][]statements.Statement
+	// WrittenOff is the unmatched items written off by [Options.WriteOffs]
+	WrittenOff struct {
+		Transactions []transactions.Transaction
+		Statements   map[string][]statements.Statement
+	}
+}
+
+// Match is the reconciled pair of transaction and statement, along with
+// the rule that matches them
+type Match struct {
+	Transaction transactions.Transaction
+	Statement   StatementFilePair
+	Rule        string
+}
+
+// addMatches records the matches, where count is the number of matched
+// transactions and statements
+func (r *Result) addMatches(rule string, count int, matches []Match) {
+	if count == 0 {
+		return
+	}
+
+	if r.RuleMatches == nil {
+		r.RuleMatches = make(map[string]int)
+	}
+
+	r.Match += count
+	r.RuleMatches[rule] += count
+	r.Matched = append(r.Matched, matches...)
+}
+
+// UnmatchedCount counts the transactions and statements left unmatched
+func (r Result) UnmatchedCount() int {
+	return r.Processed - r.Match - 2*len(r.Netted) - r.WrittenOffCount()
+}
+
+// WrittenOffCount counts the transactions and statements written off
+func (r Result) WrittenOffCount() int {
+	count := len(r.WrittenOff.Transactions)
+	for _, stmts := range r.WrittenOff.Statements {
+		count += len(stmts)
+	}
+	return count
 }
 
+func (r *Result) addUnmatched(trxs []transactions.Transaction, stmts []StatementFilePair) {
+	r.Unmatched.Transactions = append(r.Unmatched.Transactions, trxs...)
+	for _, s := range stmts {
+		r.Unmatched.Statements = appendMapOfSlices(r.Unmatched.Statements, s.Name, s.Statement)
+	}
+}
+
+// uniqueID keys the amount without its trailing zeros, so the amounts
+// of different scales match, e.g. "1000" and "1000.00"
 func uniqueID(trxType transactions.TransactionType, amount decimal.Decimal, date time.Time) string {
-	return fmt.Sprintf("%s:%s:%s", trxType, amount.String(), date.Format(time.DateOnly))
+	return fmt.Sprintf("%s:%s:%s", trxType, amount.Trim(0).String(), date.Format(time.DateOnly))
 }
 
-func Process(trxs []transactions.Transaction, stmtFiles map[string][]statements.Statement) Result {
-	uniqueTransactions := make(map[string][]transactions.Transaction, len(trxs))
+func Process(trxs []transactions.Transaction, stmtFiles map[string][]statements.Statement, opts Options) Result {
 	var result Result
 
-	for _, t := range trxs {
-		result.Processed++
+	var stmts []StatementFilePair
+	var totals balanceTotals
+	overlaps := newOverlaps(opts.Accounts)
+	read := func(files map[string][]statements.Statement, carried bool) {
+		for _, fileName := range slices.Sorted(maps.Keys(files)) {
+			for _, s := range files[fileName] {
+				if s.Pending {
+					result.Pending = appendMapOfSlices(result.Pending, fileName, s)
+					continue
+				}
 
-		id := uniqueID(t.Type, t.Amount, t.TransactionTime)
-		uniqueTransactions = appendMapOfSlices(uniqueTransactions, id, t)
+				pair := StatementFilePair{Name: fileName, Statement: s, Carried: carried}
+				totals.add(pair)
+				if overlaps.drop(&result, pair) {
+					continue
+				}
+				stmts = append(stmts, pair)
+			}
+		}
 	}
+	read(stmtFiles, false)
+	read(opts.Carried, true)
 
-	for fileName, stmts := range stmtFiles {
-		for _, s := range stmts {
-			result.Processed++
+	result.Processed = len(trxs) + len(stmts)
+	result.BalanceChecks = totals.checks(opts.balances())
+	result.Netted, trxs = opts.Netting.net(trxs)
+	result.match(opts.matchers(), opts.WriteOffs, trxs, stmts)
+	return result
+}
 
-			stmtType := transactions.TransactionTypeCredit
-			if s.Amount.IsNeg() {
-				stmtType = transactions.TransactionTypeDebit
-			}
-			id := uniqueID(stmtType, s.Amount.Abs(), s.Date)
-			_, ok := uniqueTransactions[id]
-			if !ok {
-				result.Unmatched.Statements = appendMapOfSlices(result.Unmatched.Statements, fileName, s)
-				continue
-			}
+// TypeSummary is the reconciliation count of a single type
+type TypeSummary struct {
+	MatchedTransactions   int
+	UnmatchedTransactions int
+	UnmatchedStatements   int
+}
 
-			result.Match += 2 // 1 for transaction, 1 for statement
-			popMapOfSlices(uniqueTransactions, id)
-		}
+// ByType groups the reconciliation result by the type. Transactions are
+// grouped by their own type, while statements are grouped by the type
+// set by the statement format.
+func (r Result) ByType() map[transactions.TransactionType]TypeSummary {
+	summary := make(map[transactions.TransactionType]TypeSummary)
+	update := func(trxType transactions.TransactionType, f func(s *TypeSummary)) {
+		s := summary[trxType]
+		f(&s)
+		summary[trxType] = s
 	}
 
-	for _, trxs := range uniqueTransactions {
-		result.Unmatched.Transactions = append(result.Unmatched.Transactions, trxs...)
+	for _, m := range r.Matched {
+		update(m.Transaction.Type, func(s *TypeSummary) { s.MatchedTransactions++ })
 	}
-	return result
+	for _, t := range r.Unmatched.Transactions {
+		update(t.Type, func(s *TypeSummary) { s.UnmatchedTransactions++ })
+	}
+	for _, stmts := range r.Unmatched.Statements {
+		for _, st := range stmts {
+			update(st.BookingType(), func(s *TypeSummary) { s.UnmatchedStatements++ })
+		}
+	}
+
+	return summary
 }
@@ -64,8 +64,8 @@ func generateTestData(b *testing.B, dataCount int) testData {
 //
 // func BenchmarkProcessConcurrent(b *testing.B) {
 // 	testData := generateTestData(b, 10)
-// 	trxReader := newTestReader(testData.transactions)
-// 	stmtReader := newTestReader(fileStatementPairConverter(testData.statements))
+// 	trxReader := testutils.NewReader(testData.transactions)
+// 	stmtReader := testutils.NewReader(fileStatementPairConverter(testData.statements))
 //
 // 	// Reset timer to ignore setup time
 // 	b.ResetTimer()
@@ -82,20 +82,20 @@ func benchmarkProcess(b *testing.B, count int) {
 	b.ResetTimer()
 
 	for b.Loop() {
-		reconciliation.Process(testData.transactions, testData.statements)
+		reconciliation.Process(testData.transactions, testData.statements, reconciliation.Options{})
 	}
 }
 
 func benchmarkProcessConcurrent(b *testing.B, count int) {
 	testData := generateTestData(b, count)
-	trxReader := newTestReader(testData.transactions)
-	stmtReader := newTestReader(fileStatementPairConverter(testData.statements))
+	trxReader := testutils.NewReader(testData.transactions)
+	stmtReader := testutils.NewReader(fileStatementPairConverter(testData.statements))
 
 	// Reset timer to ignore setup time
 	b.ResetTimer()
 
 	for b.Loop() {
-		reconciliation.ProcessConcurrent(trxReader.Read, stmtReader.Read)
+		reconciliation.ProcessConcurrent(trxReader.Read, stmtReader.Read, reconciliation.Options{})
 	}
 }
 
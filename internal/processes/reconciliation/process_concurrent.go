@@ -19,13 +19,26 @@ type Reader[T any] func() (T, error)
 type StatementFilePair struct {
 	Name      string
 	Statement statements.Statement
+	// Carried reports whether the statement is carried forward from the
+	// previous runs rather than read from the source, which keeps it out
+	// of the balance check of the source
+	Carried bool
 }
 
 type workingMap struct {
 	m           sync.Mutex
 	result      Result
+	matcher     KeyMatcher
 	transaction map[string][]transactions.Transaction
 	statements  map[string][]StatementFilePair
+
+	// unkeyed data can't be matched by the streaming matcher, and is
+	// left for the following matchers once the reading is done
+	unkeyedTransactions []transactions.Transaction
+	unkeyedStatements   []StatementFilePair
+
+	totals   balanceTotals
+	overlaps *overlaps
 }
 
 func transactionReader(wm *workingMap, trxCh <-chan transactions.Transaction) {
@@ -40,14 +53,26 @@ func transactionReader(wm *workingMap, trxCh <-chan transactions.Transaction) {
 }
 
 func transactionRead(wm *workingMap, trx transactions.Transaction) {
-	id := uniqueID(trx.Type, trx.Amount, trx.TransactionTime)
+	id, ok := "", false
+	if wm.matcher != nil {
+		id, ok = wm.matcher.TransactionKey(trx)
+	}
 
 	wm.m.Lock()
 	defer wm.m.Unlock()
 
 	wm.result.Processed++
-	if _, ok := wm.statements[id]; ok {
-		wm.result.Match += 2
+	if !ok {
+		wm.unkeyedTransactions = append(wm.unkeyedTransactions, trx)
+		return
+	}
+
+	if stmts, ok := wm.statements[id]; ok {
+		wm.result.addMatches(wm.matcher.Name(), 2, []Match{{
+			Transaction: trx,
+			Statement:   stmts[0],
+			Rule:        wm.matcher.Name(),
+		}})
 		popMapOfSlices(wm.statements, id)
 	} else {
 		wm.transaction = appendMapOfSlices(wm.transaction, id, trx)
@@ -66,19 +91,36 @@ func statementReader(wm *workingMap, stmtCh <-chan StatementFilePair) {
 }
 
 func statementRead(wm *workingMap, stmt StatementFilePair) {
-	stmtType := transactions.TransactionTypeCredit
-	if stmt.Statement.Amount.IsNeg() {
-		stmtType = transactions.TransactionTypeDebit
+	id, ok := "", false
+	if wm.matcher != nil {
+		id, ok = wm.matcher.StatementKey(stmt)
 	}
 
-	id := uniqueID(stmtType, stmt.Statement.Amount.Abs(), stmt.Statement.Date)
-
 	wm.m.Lock()
 	defer wm.m.Unlock()
 
+	if stmt.Statement.Pending {
+		wm.result.Pending = appendMapOfSlices(wm.result.Pending, stmt.Name, stmt.Statement)
+		return
+	}
+
+	wm.totals.add(stmt)
+	if wm.overlaps.drop(&wm.result, stmt) {
+		return
+	}
+
 	wm.result.Processed++
-	if _, ok := wm.transaction[id]; ok {
-		wm.result.Match += 2
+	if !ok {
+		wm.unkeyedStatements = append(wm.unkeyedStatements, stmt)
+		return
+	}
+
+	if trxs, ok := wm.transaction[id]; ok {
+		wm.result.addMatches(wm.matcher.Name(), 2, []Match{{
+			Transaction: trxs[0],
+			Statement:   stmt,
+			Rule:        wm.matcher.Name(),
+		}})
 		popMapOfSlices(wm.transaction, id)
 	} else {
 		wm.statements = appendMapOfSlices(wm.statements, id, stmt)
@@ -127,12 +169,28 @@ func statementWriter(ctx context.Context, stmtReader Reader[StatementFilePair],
 	}
 }
 
-func ProcessConcurrent(trx Reader[transactions.Transaction], stmt Reader[StatementFilePair]) (Result, error) {
+// ProcessConcurrent streams the data through the first matcher when it's
+// a [KeyMatcher], while the rest of the matchers are applied on what's
+// left unmatched after reading.
+//
+// Netting needs to see all transactions before matching, thus nothing is
+// matched while streaming when it's enabled.
+func ProcessConcurrent(trx Reader[transactions.Transaction], stmt Reader[StatementFilePair], opts Options) (Result, error) {
+	matchers := opts.matchers()
+	streamMatcher, ok := matchers[0].(KeyMatcher)
+	if ok && opts.Netting == nil {
+		matchers = matchers[1:]
+	} else {
+		streamMatcher = nil
+	}
+
 	wm := workingMap{
 		m:           sync.Mutex{},
 		result:      Result{},
+		matcher:     streamMatcher,
 		transaction: make(map[string][]transactions.Transaction),
 		statements:  make(map[string][]StatementFilePair),
+		overlaps:    newOverlaps(opts.Accounts),
 	}
 
 	trxCh := make(chan transactions.Transaction)
@@ -154,16 +212,19 @@ func ProcessConcurrent(trx Reader[transactions.Transaction], stmt Reader[Stateme
 		return Result{}, err
 	}
 
-	for _, stmts := range wm.statements {
-		for _, stmtPair := range stmts {
-			wm.result.Unmatched.Statements = appendMapOfSlices(wm.result.Unmatched.Statements, stmtPair.Name, stmtPair.Statement)
-		}
+	trxs := wm.unkeyedTransactions
+	for _, t := range wm.transaction {
+		trxs = append(trxs, t...)
 	}
 
-	for _, trxs := range wm.transaction {
-		wm.result.Unmatched.Transactions = append(wm.result.Unmatched.Transactions, trxs...)
+	stmts := wm.unkeyedStatements
+	for _, s := range wm.statements {
+		stmts = append(stmts, s...)
 	}
 
+	wm.result.BalanceChecks = wm.totals.checks(opts.balances())
+	wm.result.Netted, trxs = opts.Netting.net(trxs)
+	wm.result.match(matchers, opts.WriteOffs, trxs, stmts)
 	return wm.result, nil
 }
 
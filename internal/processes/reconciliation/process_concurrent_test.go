@@ -1,46 +1,28 @@
 package reconciliation_test
 
 import (
-	"io"
 	"testing"
 	"time"
 
 	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
 	"github.com/rickyson96/amartha-reconciliation-service/internal/parsers/statements"
 	"github.com/rickyson96/amartha-reconciliation-service/internal/parsers/transactions"
 	"github.com/rickyson96/amartha-reconciliation-service/internal/processes/reconciliation"
 	"github.com/rickyson96/amartha-reconciliation-service/internal/testutils"
 )
 
-type testReader[T any] struct {
-	data   []T
-	offset int
-}
-
-func newTestReader[S ~[]T, T any](data S) *testReader[T] {
-	return &testReader[T]{data, 0}
-}
-
 func fileStatementPairConverter(from map[string][]statements.Statement) []reconciliation.StatementFilePair {
 	statements := []reconciliation.StatementFilePair{}
 	for file, stmts := range from {
 		for _, s := range stmts {
-			statements = append(statements, reconciliation.StatementFilePair{file, s})
+			statements = append(statements, reconciliation.StatementFilePair{Name: file, Statement: s})
 		}
 	}
 
 	return statements
 }
 
-func (r *testReader[T]) Read() (T, error) {
-	if r.offset >= len(r.data) {
-		return *new(T), io.EOF
-	}
-	data := r.data[r.offset]
-	r.offset++
-	return data, nil
-}
-
 func TestProcessConcurrent(t *testing.T) {
 	tests := []struct {
 		name        string
@@ -79,8 +61,26 @@ func TestProcessConcurrent(t *testing.T) {
 				}},
 			},
 			result: reconciliation.Result{
-				Processed: 2,
-				Match:     2,
+				Processed:   2,
+				Match:       2,
+				RuleMatches: map[string]int{reconciliation.RuleKey: 2},
+				Matched: []reconciliation.Match{{
+					Transaction: transactions.Transaction{
+						TrxID:           "1",
+						Amount:          testutils.NewDecimal(t, 10, 0),
+						Type:            transactions.TransactionTypeCredit,
+						TransactionTime: time.Date(2025, 03, 14, 10, 10, 10, 10, time.Local),
+					},
+					Statement: reconciliation.StatementFilePair{
+						Name: "bank1.csv",
+						Statement: statements.Statement{
+							UniqueIdentifier: "10",
+							Amount:           testutils.NewDecimal(t, 10, 0),
+							Date:             time.Date(2025, 03, 14, 10, 10, 10, 10, time.Local),
+						},
+					},
+					Rule: reconciliation.RuleKey,
+				}},
 				Unmatched: struct {
 					Transactions []transactions.Transaction
 					Statements   map[string][]statements.Statement
@@ -108,8 +108,26 @@ func TestProcessConcurrent(t *testing.T) {
 				}},
 			},
 			result: reconciliation.Result{
-				Processed: 2,
-				Match:     2,
+				Processed:   2,
+				Match:       2,
+				RuleMatches: map[string]int{reconciliation.RuleKey: 2},
+				Matched: []reconciliation.Match{{
+					Transaction: transactions.Transaction{
+						TrxID:           "1",
+						Amount:          testutils.NewDecimal(t, 10, 0),
+						Type:            transactions.TransactionTypeCredit,
+						TransactionTime: time.Date(2025, 03, 14, 10, 10, 10, 10, time.Local),
+					},
+					Statement: reconciliation.StatementFilePair{
+						Name: "bank1.csv",
+						Statement: statements.Statement{
+							UniqueIdentifier: "10",
+							Amount:           testutils.NewDecimal(t, 10, 0),
+							Date:             time.Date(2025, 03, 14, 0, 0, 0, 0, time.Local),
+						},
+					},
+					Rule: reconciliation.RuleKey,
+				}},
 				Unmatched: struct {
 					Transactions []transactions.Transaction
 					Statements   map[string][]statements.Statement
@@ -147,8 +165,42 @@ func TestProcessConcurrent(t *testing.T) {
 				}},
 			},
 			result: reconciliation.Result{
-				Processed: 4,
-				Match:     4,
+				Processed:   4,
+				Match:       4,
+				RuleMatches: map[string]int{reconciliation.RuleKey: 4},
+				Matched: []reconciliation.Match{{
+					Transaction: transactions.Transaction{
+						TrxID:           "1",
+						Amount:          testutils.NewDecimal(t, 10, 0),
+						Type:            transactions.TransactionTypeDebit,
+						TransactionTime: time.Date(2025, 03, 14, 10, 10, 10, 10, time.Local),
+					},
+					Statement: reconciliation.StatementFilePair{
+						Name: "bank1.csv",
+						Statement: statements.Statement{
+							UniqueIdentifier: "10",
+							Amount:           testutils.NewDecimal(t, -10, 0),
+							Date:             time.Date(2025, 03, 14, 0, 0, 0, 0, time.Local),
+						},
+					},
+					Rule: reconciliation.RuleKey,
+				}, {
+					Transaction: transactions.Transaction{
+						TrxID:           "2",
+						Amount:          testutils.NewDecimal(t, 100, 0),
+						Type:            transactions.TransactionTypeCredit,
+						TransactionTime: time.Date(2025, 03, 14, 10, 10, 10, 10, time.Local),
+					},
+					Statement: reconciliation.StatementFilePair{
+						Name: "bank1.csv",
+						Statement: statements.Statement{
+							UniqueIdentifier: "100",
+							Amount:           testutils.NewDecimal(t, 100, 0),
+							Date:             time.Date(2025, 03, 14, 0, 0, 0, 0, time.Local),
+						},
+					},
+					Rule: reconciliation.RuleKey,
+				}},
 				Unmatched: struct {
 					Transactions []transactions.Transaction
 					Statements   map[string][]statements.Statement
@@ -182,8 +234,26 @@ func TestProcessConcurrent(t *testing.T) {
 				}},
 			},
 			result: reconciliation.Result{
-				Processed: 3,
-				Match:     2,
+				Processed:   3,
+				Match:       2,
+				RuleMatches: map[string]int{reconciliation.RuleKey: 2},
+				Matched: []reconciliation.Match{{
+					Transaction: transactions.Transaction{
+						TrxID:           "1",
+						Amount:          testutils.NewDecimal(t, 10, 0),
+						Type:            transactions.TransactionTypeCredit,
+						TransactionTime: time.Date(2025, 03, 14, 10, 10, 10, 10, time.Local),
+					},
+					Statement: reconciliation.StatementFilePair{
+						Name: "bank1.csv",
+						Statement: statements.Statement{
+							UniqueIdentifier: "10",
+							Amount:           testutils.NewDecimal(t, 10, 0),
+							Date:             time.Date(2025, 03, 14, 0, 0, 0, 0, time.Local),
+						},
+					},
+					Rule: reconciliation.RuleKey,
+				}},
 				Unmatched: struct {
 					Transactions []transactions.Transaction
 					Statements   map[string][]statements.Statement
@@ -306,15 +376,18 @@ func TestProcessConcurrent(t *testing.T) {
 
 	for _, test := range tests {
 		t.Run(test.name, func(t *testing.T) {
-			transactionReader := newTestReader(test.trancations)
-			statementReader := newTestReader(fileStatementPairConverter(test.statements))
+			transactionReader := testutils.NewReader(test.trancations)
+			statementReader := testutils.NewReader(fileStatementPairConverter(test.statements))
 
-			got, err := reconciliation.ProcessConcurrent(transactionReader.Read, statementReader.Read)
+			got, err := reconciliation.ProcessConcurrent(transactionReader.Read, statementReader.Read, reconciliation.Options{})
 			if err != nil {
 				t.Errorf("unwanted error: %v", err)
 			}
-			if diff := cmp.Diff(test.result, got); diff != "" {
-				t.Errorf("Process(%s, %s) mismatch, (-want,+got):\n%s", test.trancations, test.statements, diff)
+			sortMatches := cmpopts.SortSlices(func(a, b reconciliation.Match) bool {
+				return a.Transaction.TrxID < b.Transaction.TrxID
+			})
+			if diff := cmp.Diff(test.result, got, sortMatches); diff != "" {
+				t.Errorf("Process(%v, %v) mismatch, (-want,+got):\n%s", test.trancations, test.statements, diff)
 			}
 		})
 	}
@@ -49,8 +49,26 @@ func TestProcess(t *testing.T) {
 				}},
 			},
 			result: reconciliation.Result{
-				Processed: 2,
-				Match:     2,
+				Processed:   2,
+				Match:       2,
+				RuleMatches: map[string]int{reconciliation.RuleKey: 2},
+				Matched: []reconciliation.Match{{
+					Transaction: transactions.Transaction{
+						TrxID:           "1",
+						Amount:          testutils.NewDecimal(t, 10, 0),
+						Type:            transactions.TransactionTypeCredit,
+						TransactionTime: time.Date(2025, 03, 14, 10, 10, 10, 10, time.Local),
+					},
+					Statement: reconciliation.StatementFilePair{
+						Name: "bank1.csv",
+						Statement: statements.Statement{
+							UniqueIdentifier: "10",
+							Amount:           testutils.NewDecimal(t, 10, 0),
+							Date:             time.Date(2025, 03, 14, 10, 10, 10, 10, time.Local),
+						},
+					},
+					Rule: reconciliation.RuleKey,
+				}},
 				Unmatched: struct {
 					Transactions []transactions.Transaction
 					Statements   map[string][]statements.Statement
@@ -78,8 +96,26 @@ func TestProcess(t *testing.T) {
 				}},
 			},
 			result: reconciliation.Result{
-				Processed: 2,
-				Match:     2,
+				Processed:   2,
+				Match:       2,
+				RuleMatches: map[string]int{reconciliation.RuleKey: 2},
+				Matched: []reconciliation.Match{{
+					Transaction: transactions.Transaction{
+						TrxID:           "1",
+						Amount:          testutils.NewDecimal(t, 10, 0),
+						Type:            transactions.TransactionTypeCredit,
+						TransactionTime: time.Date(2025, 03, 14, 10, 10, 10, 10, time.Local),
+					},
+					Statement: reconciliation.StatementFilePair{
+						Name: "bank1.csv",
+						Statement: statements.Statement{
+							UniqueIdentifier: "10",
+							Amount:           testutils.NewDecimal(t, 10, 0),
+							Date:             time.Date(2025, 03, 14, 0, 0, 0, 0, time.Local),
+						},
+					},
+					Rule: reconciliation.RuleKey,
+				}},
 				Unmatched: struct {
 					Transactions []transactions.Transaction
 					Statements   map[string][]statements.Statement
@@ -117,8 +153,42 @@ func TestProcess(t *testing.T) {
 				}},
 			},
 			result: reconciliation.Result{
-				Processed: 4,
-				Match:     4,
+				Processed:   4,
+				Match:       4,
+				RuleMatches: map[string]int{reconciliation.RuleKey: 4},
+				Matched: []reconciliation.Match{{
+					Transaction: transactions.Transaction{
+						TrxID:           "1",
+						Amount:          testutils.NewDecimal(t, 10, 0),
+						Type:            transactions.TransactionTypeDebit,
+						TransactionTime: time.Date(2025, 03, 14, 10, 10, 10, 10, time.Local),
+					},
+					Statement: reconciliation.StatementFilePair{
+						Name: "bank1.csv",
+						Statement: statements.Statement{
+							UniqueIdentifier: "10",
+							Amount:           testutils.NewDecimal(t, -10, 0),
+							Date:             time.Date(2025, 03, 14, 0, 0, 0, 0, time.Local),
+						},
+					},
+					Rule: reconciliation.RuleKey,
+				}, {
+					Transaction: transactions.Transaction{
+						TrxID:           "2",
+						Amount:          testutils.NewDecimal(t, 100, 0),
+						Type:            transactions.TransactionTypeCredit,
+						TransactionTime: time.Date(2025, 03, 14, 10, 10, 10, 10, time.Local),
+					},
+					Statement: reconciliation.StatementFilePair{
+						Name: "bank1.csv",
+						Statement: statements.Statement{
+							UniqueIdentifier: "100",
+							Amount:           testutils.NewDecimal(t, 100, 0),
+							Date:             time.Date(2025, 03, 14, 0, 0, 0, 0, time.Local),
+						},
+					},
+					Rule: reconciliation.RuleKey,
+				}},
 				Unmatched: struct {
 					Transactions []transactions.Transaction
 					Statements   map[string][]statements.Statement
@@ -152,8 +222,26 @@ func TestProcess(t *testing.T) {
 				}},
 			},
 			result: reconciliation.Result{
-				Processed: 3,
-				Match:     2,
+				Processed:   3,
+				Match:       2,
+				RuleMatches: map[string]int{reconciliation.RuleKey: 2},
+				Matched: []reconciliation.Match{{
+					Transaction: transactions.Transaction{
+						TrxID:           "1",
+						Amount:          testutils.NewDecimal(t, 10, 0),
+						Type:            transactions.TransactionTypeCredit,
+						TransactionTime: time.Date(2025, 03, 14, 10, 10, 10, 10, time.Local),
+					},
+					Statement: reconciliation.StatementFilePair{
+						Name: "bank1.csv",
+						Statement: statements.Statement{
+							UniqueIdentifier: "10",
+							Amount:           testutils.NewDecimal(t, 10, 0),
+							Date:             time.Date(2025, 03, 14, 0, 0, 0, 0, time.Local),
+						},
+					},
+					Rule: reconciliation.RuleKey,
+				}},
 				Unmatched: struct {
 					Transactions []transactions.Transaction
 					Statements   map[string][]statements.Statement
@@ -276,9 +364,94 @@ func TestProcess(t *testing.T) {
 
 	for _, test := range tests {
 		t.Run(test.name, func(t *testing.T) {
-			got := reconciliation.Process(test.trancations, test.statements)
+			got := reconciliation.Process(test.trancations, test.statements, reconciliation.Options{})
 			if diff := cmp.Diff(test.result, got); diff != "" {
-				t.Errorf("Process(%s, %s) mismatch, (-want,+got):\n%s", test.trancations, test.statements, diff)
+				t.Errorf("Process(%v, %v) mismatch, (-want,+got):\n%s", test.trancations, test.statements, diff)
+			}
+		})
+	}
+}
+
+func TestProcessTransactionTypes(t *testing.T) {
+	date := time.Date(2025, 03, 14, 0, 0, 0, 0, time.UTC)
+	trxs := []transactions.Transaction{
+		{TrxID: "1", Amount: testutils.NewDecimal(t, 10, 0), Type: transactions.TransactionTypeReversal, TransactionTime: date},
+		{TrxID: "2", Amount: testutils.NewDecimal(t, 2, 0), Type: transactions.TransactionTypeFee, TransactionTime: date},
+		{TrxID: "3", Amount: testutils.NewDecimal(t, 5, 0), Type: transactions.TransactionTypeRefund, TransactionTime: date},
+		{TrxID: "4", Amount: testutils.NewDecimal(t, 7, 0), Type: transactions.TransactionTypeRefund, TransactionTime: date},
+		// reversal of a credit takes the money out
+		{TrxID: "5", Amount: testutils.NewDecimal(t, -8, 0), Type: transactions.TransactionTypeReversal, TransactionTime: date},
+	}
+	stmts := map[string][]statements.Statement{
+		"bank1.csv": {
+			{UniqueIdentifier: "a", Amount: testutils.NewDecimal(t, 10, 0), Date: date, Type: transactions.TransactionTypeCredit},
+			{UniqueIdentifier: "b", Amount: testutils.NewDecimal(t, -2, 0), Date: date, Type: transactions.TransactionTypeDebit},
+			{UniqueIdentifier: "c", Amount: testutils.NewDecimal(t, -5, 0), Date: date, Type: transactions.TransactionTypeDebit},
+			{UniqueIdentifier: "d", Amount: testutils.NewDecimal(t, 5, 0), Date: date, Type: transactions.TransactionTypeCredit},
+			{UniqueIdentifier: "e", Amount: testutils.NewDecimal(t, -8, 0), Date: date, Type: transactions.TransactionTypeDebit},
+		},
+	}
+
+	got := reconciliation.Process(trxs, stmts, reconciliation.Options{})
+
+	matches := make(map[string]string)
+	for _, m := range got.Matched {
+		matches[m.Transaction.TrxID] = m.Statement.Statement.UniqueIdentifier
+	}
+	wantMatches := map[string]string{"1": "a", "2": "b", "3": "c", "5": "e"}
+	if diff := cmp.Diff(wantMatches, matches); diff != "" {
+		t.Errorf("Process matches mismatch, (-want,+got):\n%s", diff)
+	}
+
+	wantByType := map[transactions.TransactionType]reconciliation.TypeSummary{
+		transactions.TransactionTypeReversal: {MatchedTransactions: 2},
+		transactions.TransactionTypeFee:      {MatchedTransactions: 1},
+		transactions.TransactionTypeRefund:   {MatchedTransactions: 1, UnmatchedTransactions: 1},
+		transactions.TransactionTypeCredit:   {UnmatchedStatements: 1},
+	}
+	if diff := cmp.Diff(wantByType, got.ByType()); diff != "" {
+		t.Errorf("ByType mismatch, (-want,+got):\n%s", diff)
+	}
+}
+
+func TestProcessAmountScales(t *testing.T) {
+	date := time.Date(2025, 03, 14, 0, 0, 0, 0, time.UTC)
+	trxs := []transactions.Transaction{
+		{TrxID: "1", Amount: testutils.NewDecimal(t, 1000, 0), Type: transactions.TransactionTypeCredit, TransactionTime: date},
+		{TrxID: "2", Amount: testutils.NewDecimal(t, 2500, 1), Type: transactions.TransactionTypeDebit, TransactionTime: date},
+	}
+	stmts := map[string][]statements.Statement{
+		"bank1.csv": {
+			{UniqueIdentifier: "a", Amount: testutils.NewDecimal(t, 100000, 2), Date: date, Type: transactions.TransactionTypeCredit},
+			{UniqueIdentifier: "b", Amount: testutils.NewDecimal(t, -250, 0), Date: date, Type: transactions.TransactionTypeDebit},
+		},
+	}
+
+	processes := map[string]func() (reconciliation.Result, error){
+		"sync": func() (reconciliation.Result, error) {
+			return reconciliation.Process(trxs, stmts, reconciliation.Options{}), nil
+		},
+		"concurrent": func() (reconciliation.Result, error) {
+			var pairs []reconciliation.StatementFilePair
+			for _, s := range stmts["bank1.csv"] {
+				pairs = append(pairs, reconciliation.StatementFilePair{Name: "bank1.csv", Statement: s})
+			}
+			return reconciliation.ProcessConcurrent(testutils.NewReader(trxs).Read, testutils.NewReader(pairs).Read, reconciliation.Options{})
+		},
+	}
+	for name, process := range processes {
+		t.Run(name, func(t *testing.T) {
+			got, err := process()
+			if err != nil {
+				t.Fatalf("unwanted error: %v", err)
+			}
+
+			matches := make(map[string]string)
+			for _, m := range got.Matched {
+				matches[m.Transaction.TrxID] = m.Statement.Statement.UniqueIdentifier
+			}
+			if diff := cmp.Diff(map[string]string{"1": "a", "2": "b"}, matches); diff != "" {
+				t.Errorf("matches mismatch, (-want,+got):\n%s", diff)
 			}
 		})
 	}
@@ -1,5 +1,13 @@
 package reconciliation
 
+import (
+	"fmt"
+	"time"
+
+	"github.com/govalues/decimal"
+	"github.com/rickyson96/amartha-reconciliation-service/internal/parsers/transactions"
+)
+
 // appendMapOfSlices appends to slices in a map.
 // BEWARE: this behaves like usual append
 // it will always modify current map, unless it's nil, which then
@@ -44,3 +52,59 @@ func popMapOfSlices[M ~map[K]S, K comparable, S ~[]E, E any](m M, key K) {
 
 	m[key] = m[key][1:]
 }
+
+// unmatchedTransactions returns the transactions that are not flagged
+// as matched, keeping their order
+func unmatchedTransactions(trxs []transactions.Transaction, matched []bool) []transactions.Transaction {
+	var left []transactions.Transaction
+	for i, t := range trxs {
+		if !matched[i] {
+			left = append(left, t)
+		}
+	}
+	return left
+}
+
+func dayKey(trxType transactions.TransactionType, date time.Time) string {
+	return fmt.Sprintf("%s:%s", trxType, date.Format(time.DateOnly))
+}
+
+func amountKey(trxType transactions.TransactionType, amount decimal.Decimal) string {
+	return fmt.Sprintf("%s:%s", trxType, amount.Trim(0).String())
+}
+
+// DaysBetween counts the calendar days from a to b, ignoring the time
+func DaysBetween(a, b time.Time) int {
+	dateA := time.Date(a.Year(), a.Month(), a.Day(), 0, 0, 0, 0, time.UTC)
+	dateB := time.Date(b.Year(), b.Month(), b.Day(), 0, 0, 0, 0, time.UTC)
+	return int(dateB.Sub(dateA).Hours() / 24)
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+// statementRef identifies the statement by its source
+func statementRef(source, uniqueIdentifier string) string {
+	return source + "\x00" + uniqueIdentifier
+}
+
+// ambiguousID is the index of the TrxID shared by multiple transactions
+const ambiguousID = -1
+
+// indexByID maps the TrxID into the index of its transaction, or
+// [ambiguousID] when multiple transactions share it
+func indexByID(trxs []transactions.Transaction) map[string]int {
+	byID := make(map[string]int, len(trxs))
+	for i, t := range trxs {
+		if _, ok := byID[t.TrxID]; ok {
+			byID[t.TrxID] = ambiguousID
+			continue
+		}
+		byID[t.TrxID] = i
+	}
+	return byID
+}
@@ -0,0 +1,50 @@
+package reconciliation
+
+import (
+	"github.com/rickyson96/amartha-reconciliation-service/internal/parsers/transactions"
+)
+
+// WriteOff refers to the item to be written off, either a transaction by
+// its TrxID, or a statement by its source and UniqueIdentifier.
+type WriteOff struct {
+	TrxID            string
+	Source           string
+	UniqueIdentifier string
+}
+
+// writeOff moves the written off items out of the leftover
+func (r *Result) writeOff(writeOffs []WriteOff, trxs []transactions.Transaction, stmts []StatementFilePair) ([]transactions.Transaction, []StatementFilePair) {
+	if len(writeOffs) == 0 {
+		return trxs, stmts
+	}
+
+	trxRefs := make(map[string]bool)
+	stmtRefs := make(map[string]bool)
+	for _, w := range writeOffs {
+		if w.TrxID != "" {
+			trxRefs[w.TrxID] = true
+			continue
+		}
+		stmtRefs[statementRef(w.Source, w.UniqueIdentifier)] = true
+	}
+
+	var trxLeft []transactions.Transaction
+	for _, t := range trxs {
+		if !trxRefs[t.TrxID] {
+			trxLeft = append(trxLeft, t)
+			continue
+		}
+		r.WrittenOff.Transactions = append(r.WrittenOff.Transactions, t)
+	}
+
+	var stmtLeft []StatementFilePair
+	for _, s := range stmts {
+		if !stmtRefs[statementRef(s.Name, s.Statement.UniqueIdentifier)] {
+			stmtLeft = append(stmtLeft, s)
+			continue
+		}
+		r.WrittenOff.Statements = appendMapOfSlices(r.WrittenOff.Statements, s.Name, s.Statement)
+	}
+
+	return trxLeft, stmtLeft
+}
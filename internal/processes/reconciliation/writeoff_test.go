@@ -0,0 +1,92 @@
+package reconciliation_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/rickyson96/amartha-reconciliation-service/internal/parsers/statements"
+	"github.com/rickyson96/amartha-reconciliation-service/internal/parsers/transactions"
+	"github.com/rickyson96/amartha-reconciliation-service/internal/processes/reconciliation"
+	"github.com/rickyson96/amartha-reconciliation-service/internal/testutils"
+)
+
+func TestWriteOffs(t *testing.T) {
+	day := time.Date(2025, 03, 14, 0, 0, 0, 0, time.UTC)
+	trxs := []transactions.Transaction{{
+		TrxID:           "1",
+		Amount:          testutils.NewDecimal(t, 10, 0),
+		Type:            transactions.TransactionTypeCredit,
+		TransactionTime: day,
+	}, {
+		TrxID:           "2",
+		Amount:          testutils.NewDecimal(t, 20, 0),
+		Type:            transactions.TransactionTypeCredit,
+		TransactionTime: day,
+	}, {
+		TrxID:           "3",
+		Amount:          testutils.NewDecimal(t, 30, 0),
+		Type:            transactions.TransactionTypeCredit,
+		TransactionTime: day,
+	}}
+	stmts := map[string][]statements.Statement{
+		"bank1.csv": {{
+			UniqueIdentifier: "a",
+			Amount:           testutils.NewDecimal(t, 10, 0),
+			Date:             day,
+			Type:             transactions.TransactionTypeCredit,
+		}, {
+			UniqueIdentifier: "b",
+			Amount:           testutils.NewDecimal(t, -5, 0),
+			Date:             day,
+			Type:             transactions.TransactionTypeDebit,
+		}},
+	}
+
+	// transaction 1 is matched, so its write off doesn't apply
+	opts := reconciliation.Options{WriteOffs: []reconciliation.WriteOff{
+		{TrxID: "1"},
+		{TrxID: "2"},
+		{Source: "bank1.csv", UniqueIdentifier: "b"},
+		{Source: "bank2.csv", UniqueIdentifier: "a"},
+	}}
+
+	wantTrxs := []string{"2"}
+	wantStmts := map[string][]string{"bank1.csv": {"b"}}
+
+	assert := func(t *testing.T, got reconciliation.Result) {
+		t.Helper()
+		var gotTrxs []string
+		for _, trx := range got.WrittenOff.Transactions {
+			gotTrxs = append(gotTrxs, trx.TrxID)
+		}
+		gotStmts := make(map[string][]string)
+		for source, stmts := range got.WrittenOff.Statements {
+			for _, s := range stmts {
+				gotStmts[source] = append(gotStmts[source], s.UniqueIdentifier)
+			}
+		}
+
+		if diff := cmp.Diff(wantTrxs, gotTrxs); diff != "" {
+			t.Errorf("written off transactions mismatch, (-want,+got):\n%s", diff)
+		}
+		if diff := cmp.Diff(wantStmts, gotStmts); diff != "" {
+			t.Errorf("written off statements mismatch, (-want,+got):\n%s", diff)
+		}
+		if got.WrittenOffCount() != 2 || got.UnmatchedCount() != 1 {
+			t.Errorf("count mismatch, written off %d, unmatched %d", got.WrittenOffCount(), got.UnmatchedCount())
+		}
+	}
+
+	t.Run("Process", func(t *testing.T) {
+		assert(t, reconciliation.Process(trxs, stmts, opts))
+	})
+
+	t.Run("ProcessConcurrent", func(t *testing.T) {
+		got, err := reconciliation.ProcessConcurrent(testutils.NewReader(trxs).Read, testutils.NewReader(fileStatementPairConverter(stmts)).Read, opts)
+		if err != nil {
+			t.Errorf("unwanted error: %v", err)
+		}
+		assert(t, got)
+	})
+}
@@ -0,0 +1,136 @@
+package sink
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+// migrations are the schema changes, applied in order once. The version
+// of a migration is its index plus one, so migrations are only appended.
+var migrations = []string{
+	`CREATE TABLE recon_runs (
+		run_id TEXT PRIMARY KEY,
+		run_at TIMESTAMP NOT NULL,
+		start_date DATE NOT NULL,
+		end_date DATE NOT NULL,
+		transaction_source TEXT NOT NULL,
+		statement_sources TEXT NOT NULL,
+		processed INTEGER NOT NULL,
+		matched INTEGER NOT NULL,
+		netted INTEGER NOT NULL,
+		written_off INTEGER NOT NULL,
+		unmatched INTEGER NOT NULL
+	);
+	CREATE TABLE recon_matches (
+		run_id TEXT NOT NULL REFERENCES recon_runs (run_id),
+		rule TEXT NOT NULL,
+		trx_id TEXT NOT NULL,
+		trx_type TEXT NOT NULL,
+		trx_amount NUMERIC NOT NULL,
+		trx_time TIMESTAMP NOT NULL,
+		statement_source TEXT NOT NULL,
+		statement_id TEXT NOT NULL,
+		statement_type TEXT NOT NULL,
+		statement_amount NUMERIC NOT NULL,
+		statement_date DATE NOT NULL
+	);
+	CREATE TABLE recon_unmatched_transactions (
+		run_id TEXT NOT NULL REFERENCES recon_runs (run_id),
+		trx_id TEXT NOT NULL,
+		trx_type TEXT NOT NULL,
+		amount NUMERIC NOT NULL,
+		trx_time TIMESTAMP NOT NULL,
+		reference TEXT NOT NULL
+	);
+	CREATE TABLE recon_unmatched_statements (
+		run_id TEXT NOT NULL REFERENCES recon_runs (run_id),
+		source TEXT NOT NULL,
+		unique_identifier TEXT NOT NULL,
+		statement_type TEXT NOT NULL,
+		amount NUMERIC NOT NULL,
+		statement_date DATE NOT NULL,
+		description TEXT NOT NULL,
+		reference TEXT NOT NULL
+	);
+	CREATE TABLE recon_totals (
+		run_id TEXT NOT NULL REFERENCES recon_runs (run_id),
+		trx_type TEXT NOT NULL,
+		matched_transactions INTEGER NOT NULL,
+		matched_amount NUMERIC NOT NULL,
+		unmatched_transactions INTEGER NOT NULL,
+		unmatched_transaction_amount NUMERIC NOT NULL,
+		unmatched_statements INTEGER NOT NULL,
+		unmatched_statement_amount NUMERIC NOT NULL,
+		PRIMARY KEY (run_id, trx_type)
+	);
+	CREATE TABLE recon_netted (
+		run_id TEXT NOT NULL REFERENCES recon_runs (run_id),
+		rule TEXT NOT NULL,
+		original_trx_id TEXT NOT NULL,
+		original_type TEXT NOT NULL,
+		original_amount NUMERIC NOT NULL,
+		original_time TIMESTAMP NOT NULL,
+		reversal_trx_id TEXT NOT NULL,
+		reversal_type TEXT NOT NULL,
+		reversal_amount NUMERIC NOT NULL,
+		reversal_time TIMESTAMP NOT NULL
+	);
+	CREATE TABLE recon_written_off_transactions (
+		run_id TEXT NOT NULL REFERENCES recon_runs (run_id),
+		trx_id TEXT NOT NULL,
+		trx_type TEXT NOT NULL,
+		amount NUMERIC NOT NULL,
+		trx_time TIMESTAMP NOT NULL,
+		reference TEXT NOT NULL
+	);
+	CREATE TABLE recon_written_off_statements (
+		run_id TEXT NOT NULL REFERENCES recon_runs (run_id),
+		source TEXT NOT NULL,
+		unique_identifier TEXT NOT NULL,
+		statement_type TEXT NOT NULL,
+		amount NUMERIC NOT NULL,
+		statement_date DATE NOT NULL,
+		description TEXT NOT NULL,
+		reference TEXT NOT NULL
+	);
+	CREATE INDEX recon_matches_run ON recon_matches (run_id);
+	CREATE INDEX recon_unmatched_transactions_run ON recon_unmatched_transactions (run_id);
+	CREATE INDEX recon_unmatched_statements_run ON recon_unmatched_statements (run_id);
+	CREATE INDEX recon_netted_run ON recon_netted (run_id);
+	CREATE INDEX recon_written_off_transactions_run ON recon_written_off_transactions (run_id);
+	CREATE INDEX recon_written_off_statements_run ON recon_written_off_statements (run_id);`,
+}
+
+// Migrate applies the migrations not applied yet, recording their
+// version on the schema_migrations table
+func (s *Sink) Migrate(ctx context.Context) error {
+	_, err := s.db.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS schema_migrations (version INTEGER PRIMARY KEY)`)
+	if err != nil {
+		return fmt.Errorf("migrate: %w", err)
+	}
+
+	var version int
+	err = s.db.QueryRowContext(ctx, `SELECT COALESCE(MAX(version), 0) FROM schema_migrations`).Scan(&version)
+	if err != nil {
+		return fmt.Errorf("migrate: %w", err)
+	}
+
+	for i := version; i < len(migrations); i++ {
+		err := s.inTx(ctx, func(tx *sql.Tx) error {
+			// some drivers execute a single statement at a time
+			for _, stmt := range splitStatements(migrations[i]) {
+				if _, err := tx.ExecContext(ctx, stmt); err != nil {
+					return err
+				}
+			}
+			_, err := tx.ExecContext(ctx, s.rebind(`INSERT INTO schema_migrations (version) VALUES (?)`), i+1)
+			return err
+		})
+		if err != nil {
+			return fmt.Errorf("migrate to version %d: %w", i+1, err)
+		}
+	}
+
+	return nil
+}
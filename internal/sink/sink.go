@@ -0,0 +1,254 @@
+// Package sink writes the reconciliation results into relational tables,
+// e.g. read by the BI out of the warehouse, besides the file reports.
+package sink
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/govalues/decimal"
+	"github.com/rickyson96/amartha-reconciliation-service/internal/parsers/transactions"
+	"github.com/rickyson96/amartha-reconciliation-service/internal/processes/reconciliation"
+)
+
+const (
+	DriverSQLite   = "sqlite3"
+	DriverPostgres = "postgres"
+)
+
+var ErrUnsupportedDriver = errors.New("unsupported sink driver")
+
+// Sink writes the reconciliation runs into the database
+type Sink struct {
+	db     *sql.DB
+	driver string
+}
+
+// Run is the metadata of the reconciliation run
+type Run struct {
+	ID                string
+	At                time.Time
+	StartDate         time.Time
+	EndDate           time.Time
+	TransactionSource string
+	StatementSources  []string
+}
+
+// New creates the sink of the database opened with the driver, either
+// [DriverSQLite] or [DriverPostgres], migrating its schema
+func New(ctx context.Context, db *sql.DB, driver string) (*Sink, error) {
+	if driver != DriverSQLite && driver != DriverPostgres {
+		return nil, fmt.Errorf("%q is %w", driver, ErrUnsupportedDriver)
+	}
+
+	s := &Sink{db: db, driver: driver}
+	if err := s.Migrate(ctx); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Write writes the result of the run. Writing the same run id again
+// replaces the rows of the previous write, so retried runs aren't
+// counted twice.
+func (s *Sink) Write(ctx context.Context, run Run, result reconciliation.Result) error {
+	return s.inTx(ctx, func(tx *sql.Tx) error {
+		// children first, as they reference the run
+		for _, table := range []string{"recon_written_off_statements", "recon_written_off_transactions", "recon_netted", "recon_totals", "recon_unmatched_statements", "recon_unmatched_transactions", "recon_matches", "recon_runs"} {
+			if _, err := tx.ExecContext(ctx, s.rebind("DELETE FROM "+table+" WHERE run_id = ?"), run.ID); err != nil {
+				return fmt.Errorf("%s: %w", table, err)
+			}
+		}
+
+		_, err := tx.ExecContext(ctx, s.rebind(`INSERT INTO recon_runs
+			(run_id, run_at, start_date, end_date, transaction_source, statement_sources, processed, matched, netted, written_off, unmatched)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`),
+			run.ID, run.At, run.StartDate, run.EndDate, run.TransactionSource, strings.Join(run.StatementSources, ","),
+			result.Processed, result.Match, 2*len(result.Netted), result.WrittenOffCount(), result.UnmatchedCount())
+		if err != nil {
+			return fmt.Errorf("recon_runs: %w", err)
+		}
+
+		for _, m := range result.Matched {
+			trx, stmt := m.Transaction, m.Statement.Statement
+			_, err := tx.ExecContext(ctx, s.rebind(`INSERT INTO recon_matches
+				(run_id, rule, trx_id, trx_type, trx_amount, trx_time, statement_source, statement_id, statement_type, statement_amount, statement_date)
+				VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`),
+				run.ID, m.Rule, trx.TrxID, trx.Type.String(), trx.Amount.String(), trx.TransactionTime,
+				m.Statement.Name, stmt.UniqueIdentifier, stmt.BookingType().String(), stmt.Amount.String(), stmt.Date)
+			if err != nil {
+				return fmt.Errorf("recon_matches: %w", err)
+			}
+		}
+
+		for _, trx := range result.Unmatched.Transactions {
+			_, err := tx.ExecContext(ctx, s.rebind(`INSERT INTO recon_unmatched_transactions
+				(run_id, trx_id, trx_type, amount, trx_time, reference)
+				VALUES (?, ?, ?, ?, ?, ?)`),
+				run.ID, trx.TrxID, trx.Type.String(), trx.Amount.String(), trx.TransactionTime, trx.Reference)
+			if err != nil {
+				return fmt.Errorf("recon_unmatched_transactions: %w", err)
+			}
+		}
+
+		for _, source := range slices.Sorted(maps.Keys(result.Unmatched.Statements)) {
+			for _, stmt := range result.Unmatched.Statements[source] {
+				_, err := tx.ExecContext(ctx, s.rebind(`INSERT INTO recon_unmatched_statements
+					(run_id, source, unique_identifier, statement_type, amount, statement_date, description, reference)
+					VALUES (?, ?, ?, ?, ?, ?, ?, ?)`),
+					run.ID, source, stmt.UniqueIdentifier, stmt.BookingType().String(), stmt.Amount.String(), stmt.Date, stmt.Description, stmt.Reference)
+				if err != nil {
+					return fmt.Errorf("recon_unmatched_statements: %w", err)
+				}
+			}
+		}
+
+		for _, pair := range result.Netted {
+			original, reversal := pair.Original, pair.Reversal
+			_, err := tx.ExecContext(ctx, s.rebind(`INSERT INTO recon_netted
+				(run_id, rule, original_trx_id, original_type, original_amount, original_time, reversal_trx_id, reversal_type, reversal_amount, reversal_time)
+				VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`),
+				run.ID, pair.Rule, original.TrxID, original.Type.String(), original.Amount.String(), original.TransactionTime,
+				reversal.TrxID, reversal.Type.String(), reversal.Amount.String(), reversal.TransactionTime)
+			if err != nil {
+				return fmt.Errorf("recon_netted: %w", err)
+			}
+		}
+
+		for _, trx := range result.WrittenOff.Transactions {
+			_, err := tx.ExecContext(ctx, s.rebind(`INSERT INTO recon_written_off_transactions
+				(run_id, trx_id, trx_type, amount, trx_time, reference)
+				VALUES (?, ?, ?, ?, ?, ?)`),
+				run.ID, trx.TrxID, trx.Type.String(), trx.Amount.String(), trx.TransactionTime, trx.Reference)
+			if err != nil {
+				return fmt.Errorf("recon_written_off_transactions: %w", err)
+			}
+		}
+
+		for _, source := range slices.Sorted(maps.Keys(result.WrittenOff.Statements)) {
+			for _, stmt := range result.WrittenOff.Statements[source] {
+				_, err := tx.ExecContext(ctx, s.rebind(`INSERT INTO recon_written_off_statements
+					(run_id, source, unique_identifier, statement_type, amount, statement_date, description, reference)
+					VALUES (?, ?, ?, ?, ?, ?, ?, ?)`),
+					run.ID, source, stmt.UniqueIdentifier, stmt.BookingType().String(), stmt.Amount.String(), stmt.Date, stmt.Description, stmt.Reference)
+				if err != nil {
+					return fmt.Errorf("recon_written_off_statements: %w", err)
+				}
+			}
+		}
+
+		totals, err := typeTotals(result)
+		if err != nil {
+			return err
+		}
+		for _, trxType := range slices.Sorted(maps.Keys(totals)) {
+			t := totals[trxType]
+			_, err := tx.ExecContext(ctx, s.rebind(`INSERT INTO recon_totals
+				(run_id, trx_type, matched_transactions, matched_amount, unmatched_transactions, unmatched_transaction_amount, unmatched_statements, unmatched_statement_amount)
+				VALUES (?, ?, ?, ?, ?, ?, ?, ?)`),
+				run.ID, trxType.String(), t.MatchedTransactions, t.matchedAmount.String(),
+				t.UnmatchedTransactions, t.unmatchedTransactionAmount.String(),
+				t.UnmatchedStatements, t.unmatchedStatementAmount.String())
+			if err != nil {
+				return fmt.Errorf("recon_totals: %w", err)
+			}
+		}
+
+		return nil
+	})
+}
+
+// typeTotal is [reconciliation.TypeSummary] along with the amounts
+type typeTotal struct {
+	reconciliation.TypeSummary
+	matchedAmount              decimal.Decimal
+	unmatchedTransactionAmount decimal.Decimal
+	unmatchedStatementAmount   decimal.Decimal
+}
+
+// typeTotals sums the amounts by the type, following
+// [reconciliation.Result.ByType]
+func typeTotals(result reconciliation.Result) (map[transactions.TransactionType]typeTotal, error) {
+	totals := make(map[transactions.TransactionType]typeTotal)
+	for trxType, summary := range result.ByType() {
+		totals[trxType] = typeTotal{TypeSummary: summary}
+	}
+
+	var err error
+	for _, m := range result.Matched {
+		t := totals[m.Transaction.Type]
+		if t.matchedAmount, err = t.matchedAmount.Add(m.Transaction.Amount); err != nil {
+			return nil, err
+		}
+		totals[m.Transaction.Type] = t
+	}
+	for _, trx := range result.Unmatched.Transactions {
+		t := totals[trx.Type]
+		if t.unmatchedTransactionAmount, err = t.unmatchedTransactionAmount.Add(trx.Amount); err != nil {
+			return nil, err
+		}
+		totals[trx.Type] = t
+	}
+	for _, stmts := range result.Unmatched.Statements {
+		for _, stmt := range stmts {
+			t := totals[stmt.BookingType()]
+			if t.unmatchedStatementAmount, err = t.unmatchedStatementAmount.Add(stmt.Amount); err != nil {
+				return nil, err
+			}
+			totals[stmt.BookingType()] = t
+		}
+	}
+
+	return totals, nil
+}
+
+// inTx runs f within a transaction, which is committed when f succeeds
+func (s *Sink) inTx(ctx context.Context, f func(tx *sql.Tx) error) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	if err := f(tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+// rebind replaces the ? placeholders with the $n of Postgres
+func (s *Sink) rebind(query string) string {
+	if s.driver != DriverPostgres {
+		return query
+	}
+
+	var b strings.Builder
+	n := 0
+	for _, r := range query {
+		if r != '?' {
+			b.WriteRune(r)
+			continue
+		}
+		n++
+		b.WriteString("$" + strconv.Itoa(n))
+	}
+	return b.String()
+}
+
+// splitStatements splits the migration into its statements
+func splitStatements(migration string) []string {
+	var stmts []string
+	for _, stmt := range strings.Split(migration, ";") {
+		if stmt = strings.TrimSpace(stmt); stmt != "" {
+			stmts = append(stmts, stmt)
+		}
+	}
+	return stmts
+}
@@ -0,0 +1,155 @@
+package sink_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/rickyson96/amartha-reconciliation-service/internal/parsers/statements"
+	"github.com/rickyson96/amartha-reconciliation-service/internal/parsers/transactions"
+	"github.com/rickyson96/amartha-reconciliation-service/internal/processes/reconciliation"
+	"github.com/rickyson96/amartha-reconciliation-service/internal/sink"
+	"github.com/rickyson96/amartha-reconciliation-service/internal/testutils"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+func TestSinkWrite(t *testing.T) {
+	ctx := context.Background()
+	db, err := sql.Open(sink.DriverSQLite, filepath.Join(t.TempDir(), "results.db"))
+	if err != nil {
+		t.Fatalf("failed opening database: %v", err)
+	}
+	defer db.Close()
+
+	march10 := time.Date(2025, 3, 10, 0, 0, 0, 0, time.UTC)
+	matched := transactions.Transaction{TrxID: "TRX1", Amount: testutils.NewDecimal(t, 100, 0), Type: transactions.TransactionTypeCredit, TransactionTime: march10}
+	pending := transactions.Transaction{TrxID: "TRX2", Amount: testutils.NewDecimal(t, 50, 0), Type: transactions.TransactionTypeCredit, TransactionTime: march10}
+	fee := statements.Statement{UniqueIdentifier: "B2", Amount: testutils.NewDecimal(t, -5, 0), Date: march10, Type: transactions.TransactionTypeDebit, Description: "ADMIN FEE"}
+
+	result := reconciliation.Result{Processed: 4, Match: 2}
+	result.Matched = []reconciliation.Match{{
+		Transaction: matched,
+		Statement: reconciliation.StatementFilePair{Name: "bank1.csv", Statement: statements.Statement{
+			UniqueIdentifier: "B1", Amount: testutils.NewDecimal(t, 100, 0), Date: march10, Type: transactions.TransactionTypeCredit,
+		}},
+		Rule: "key",
+	}}
+	result.Unmatched.Transactions = []transactions.Transaction{pending}
+	result.Unmatched.Statements = map[string][]statements.Statement{"bank1.csv": {fee}}
+	result.Netted = []reconciliation.NettedPair{{
+		Original: transactions.Transaction{TrxID: "TRX3", Amount: testutils.NewDecimal(t, 20, 0), Type: transactions.TransactionTypeCredit, TransactionTime: march10},
+		Reversal: transactions.Transaction{TrxID: "TRX4", Amount: testutils.NewDecimal(t, 20, 0), Type: transactions.TransactionTypeReversal, TransactionTime: march10, Reference: "TRX3"},
+		Rule:     "reference",
+	}}
+	result.WrittenOff.Transactions = []transactions.Transaction{{TrxID: "TRX5", Amount: testutils.NewDecimal(t, 1, 0), Type: transactions.TransactionTypeDebit, TransactionTime: march10}}
+	result.WrittenOff.Statements = map[string][]statements.Statement{"bank1.csv": {{UniqueIdentifier: "B3", Amount: testutils.NewDecimal(t, 2, 0), Date: march10, Type: transactions.TransactionTypeCredit, Description: "INTEREST"}}}
+
+	run := sink.Run{
+		ID:                "run-1",
+		At:                march10.Add(time.Hour),
+		StartDate:         march10,
+		EndDate:           march10,
+		TransactionSource: "transactions.csv",
+		StatementSources:  []string{"bank1.csv"},
+	}
+
+	// writing the same run twice, along with migrating twice, keeps a
+	// single copy of the results
+	for range 2 {
+		s, err := sink.New(ctx, db, sink.DriverSQLite)
+		if err != nil {
+			t.Fatalf("New failed: %v", err)
+		}
+		if err := s.Write(ctx, run, result); err != nil {
+			t.Fatalf("Write failed: %v", err)
+		}
+	}
+
+	counts := map[string]int{}
+	for _, table := range []string{"recon_runs", "recon_matches", "recon_unmatched_transactions", "recon_unmatched_statements", "recon_totals", "recon_netted", "recon_written_off_transactions", "recon_written_off_statements", "schema_migrations"} {
+		var count int
+		if err := db.QueryRow("SELECT COUNT(*) FROM " + table).Scan(&count); err != nil {
+			t.Fatalf("failed counting %s: %v", table, err)
+		}
+		counts[table] = count
+	}
+	wantCounts := map[string]int{
+		"recon_runs":                     1,
+		"recon_matches":                  1,
+		"recon_unmatched_transactions":   1,
+		"recon_unmatched_statements":     1,
+		"recon_totals":                   2,
+		"recon_netted":                   1,
+		"recon_written_off_transactions": 1,
+		"recon_written_off_statements":   1,
+		"schema_migrations":              1,
+	}
+	if diff := cmp.Diff(wantCounts, counts); diff != "" {
+		t.Errorf("row counts mismatch, (-want,+got):\n%s", diff)
+	}
+
+	var source, description string
+	err = db.QueryRow("SELECT source, description FROM recon_unmatched_statements WHERE run_id = 'run-1'").Scan(&source, &description)
+	if err != nil {
+		t.Fatalf("failed reading unmatched statements: %v", err)
+	}
+	if source != "bank1.csv" || description != "ADMIN FEE" {
+		t.Errorf("unmatched statement = %q %q, want bank1.csv ADMIN FEE", source, description)
+	}
+
+	var original, reversal, rule string
+	err = db.QueryRow("SELECT original_trx_id, reversal_trx_id, rule FROM recon_netted WHERE run_id = 'run-1'").Scan(&original, &reversal, &rule)
+	if err != nil {
+		t.Fatalf("failed reading netted pairs: %v", err)
+	}
+	if original != "TRX3" || reversal != "TRX4" || rule != "reference" {
+		t.Errorf("netted pair = %q %q %q, want TRX3 TRX4 reference", original, reversal, rule)
+	}
+
+	err = db.QueryRow("SELECT source, description FROM recon_written_off_statements WHERE run_id = 'run-1'").Scan(&source, &description)
+	if err != nil {
+		t.Fatalf("failed reading written off statements: %v", err)
+	}
+	if source != "bank1.csv" || description != "INTEREST" {
+		t.Errorf("written off statement = %q %q, want bank1.csv INTEREST", source, description)
+	}
+
+	type total struct {
+		Type                           string
+		Matched, Unmatched, Statements int
+		MatchedAmount, UnmatchedAmount float64
+		StatementAmount                float64
+	}
+	rows, err := db.Query(`SELECT trx_type, matched_transactions, matched_amount, unmatched_transactions, unmatched_transaction_amount, unmatched_statements, unmatched_statement_amount
+		FROM recon_totals WHERE run_id = 'run-1' ORDER BY trx_type`)
+	if err != nil {
+		t.Fatalf("failed reading totals: %v", err)
+	}
+	defer rows.Close()
+	var totals []total
+	for rows.Next() {
+		var tt total
+		if err := rows.Scan(&tt.Type, &tt.Matched, &tt.MatchedAmount, &tt.Unmatched, &tt.UnmatchedAmount, &tt.Statements, &tt.StatementAmount); err != nil {
+			t.Fatalf("failed scanning totals: %v", err)
+		}
+		totals = append(totals, tt)
+	}
+	wantTotals := []total{
+		{Type: "CREDIT", Matched: 1, MatchedAmount: 100, Unmatched: 1, UnmatchedAmount: 50},
+		{Type: "DEBIT", Statements: 1, StatementAmount: -5},
+	}
+	if diff := cmp.Diff(wantTotals, totals); diff != "" {
+		t.Errorf("totals mismatch, (-want,+got):\n%s", diff)
+	}
+}
+
+func TestNewUnsupportedDriver(t *testing.T) {
+	if _, err := sink.New(context.Background(), nil, "mysql"); !errors.Is(err, sink.ErrUnsupportedDriver) {
+		t.Errorf("New should fail with ErrUnsupportedDriver, got %v", err)
+	}
+}
@@ -0,0 +1,188 @@
+package store
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/rickyson96/amartha-reconciliation-service/internal/processes/reconciliation"
+)
+
+const (
+	// DecisionMatch force-matches the transaction to the statement
+	DecisionMatch = "match"
+	// DecisionWriteOff writes off the item, it's no longer reported as
+	// unmatched
+	DecisionWriteOff = "written-off"
+	// DecisionInvestigating marks the item as being investigated, it's
+	// still reported as unmatched
+	DecisionInvestigating = "investigating"
+)
+
+var (
+	ErrUnknownDecision = errors.New("unknown decision")
+	ErrInvalidDecision = errors.New("invalid decision")
+	ErrNotOpen         = errors.New("item is not open")
+)
+
+// Decision is the manual decision over open items made by a human. The
+// last decision of an item takes precedence over the previous ones, which
+// are kept as the audit trail.
+type Decision struct {
+	Kind string `json:"kind"`
+	// TrxID refers to the transaction, required on match
+	TrxID string `json:"trxID,omitempty"`
+	// Source and UniqueIdentifier refer to the statement, required on
+	// match
+	Source           string    `json:"source,omitempty"`
+	UniqueIdentifier string    `json:"uniqueIdentifier,omitempty"`
+	Comment          string    `json:"comment"`
+	User             string    `json:"user"`
+	At               time.Time `json:"at"`
+}
+
+// keys returns the items the decision refers to
+func (d Decision) keys() []string {
+	var keys []string
+	if d.TrxID != "" {
+		keys = append(keys, d.TrxID)
+	}
+	if d.Source != "" || d.UniqueIdentifier != "" {
+		keys = append(keys, statementRef(d.Source, d.UniqueIdentifier))
+	}
+	return keys
+}
+
+func (d Decision) validate() error {
+	hasTrx := d.TrxID != ""
+	hasStmt := d.Source != "" && d.UniqueIdentifier != ""
+
+	switch d.Kind {
+	case DecisionMatch:
+		if !hasTrx || !hasStmt {
+			return fmt.Errorf("%w: match needs both transaction and statement", ErrInvalidDecision)
+		}
+	case DecisionWriteOff, DecisionInvestigating:
+		if hasTrx == hasStmt {
+			return fmt.Errorf("%w: %s needs either transaction or statement", ErrInvalidDecision, d.Kind)
+		}
+	default:
+		return fmt.Errorf("%w: %q", ErrUnknownDecision, d.Kind)
+	}
+
+	return nil
+}
+
+// Decide records the decision over the open items
+func (s *Store) Decide(d Decision) error {
+	if err := d.validate(); err != nil {
+		return err
+	}
+
+	open := make(map[string]bool)
+	for _, t := range s.State.OpenTransactions {
+		open[transactionKey(t.Transaction)] = true
+	}
+	for _, st := range s.State.OpenStatements {
+		open[statementKey(st.Source, st.Statement)] = true
+	}
+
+	for _, key := range d.keys() {
+		if !open[key] {
+			return fmt.Errorf("%w: %q", ErrNotOpen, strings.Replace(key, "\x00", "/", 1))
+		}
+	}
+
+	s.State.Decisions = append(s.State.Decisions, d)
+	return nil
+}
+
+// activeDecisions returns the decisions that still take precedence over
+// all of their items
+func (s *Store) activeDecisions() []Decision {
+	latest := make(map[string]int)
+	for i, d := range s.State.Decisions {
+		for _, key := range d.keys() {
+			latest[key] = i
+		}
+	}
+
+	var active []Decision
+	for i, d := range s.State.Decisions {
+		isActive := true
+		for _, key := range d.keys() {
+			isActive = isActive && latest[key] == i
+		}
+		if isActive {
+			active = append(active, d)
+		}
+	}
+
+	return active
+}
+
+// Apply adds the decisions into the reconciliation options, manual
+// matches go first on the matching pipeline, and written off items are
+// excluded from the unmatched ones.
+func (s *Store) Apply(opts reconciliation.Options) reconciliation.Options {
+	var manuals []reconciliation.ManualMatch
+	var writeOffs []reconciliation.WriteOff
+	for _, d := range s.activeDecisions() {
+		switch d.Kind {
+		case DecisionMatch:
+			manuals = append(manuals, reconciliation.ManualMatch{TrxID: d.TrxID, Source: d.Source, UniqueIdentifier: d.UniqueIdentifier})
+		case DecisionWriteOff:
+			writeOffs = append(writeOffs, reconciliation.WriteOff{TrxID: d.TrxID, Source: d.Source, UniqueIdentifier: d.UniqueIdentifier})
+		}
+	}
+
+	if len(manuals) > 0 {
+		matchers := opts.Matchers
+		if len(matchers) == 0 {
+			matchers = []reconciliation.Matcher{reconciliation.NewKeyMatcher()}
+		}
+		opts.Matchers = append([]reconciliation.Matcher{reconciliation.NewManualMatcher(manuals)}, matchers...)
+	}
+	opts.WriteOffs = append(opts.WriteOffs, writeOffs...)
+
+	return opts
+}
+
+// AuditTrail returns the decisions over the items of the result, in the
+// order they're made
+func (s *Store) AuditTrail(result reconciliation.Result) []Decision {
+	items := make(map[string]bool)
+	for _, m := range result.Matched {
+		items[transactionKey(m.Transaction)] = true
+		items[statementKey(m.Statement.Name, m.Statement.Statement)] = true
+	}
+	for _, t := range result.Unmatched.Transactions {
+		items[transactionKey(t)] = true
+	}
+	for _, t := range result.WrittenOff.Transactions {
+		items[transactionKey(t)] = true
+	}
+	for source, stmts := range result.Unmatched.Statements {
+		for _, st := range stmts {
+			items[statementKey(source, st)] = true
+		}
+	}
+	for source, stmts := range result.WrittenOff.Statements {
+		for _, st := range stmts {
+			items[statementKey(source, st)] = true
+		}
+	}
+
+	var trail []Decision
+	for _, d := range s.State.Decisions {
+		for _, key := range d.keys() {
+			if items[key] {
+				trail = append(trail, d)
+				break
+			}
+		}
+	}
+
+	return trail
+}
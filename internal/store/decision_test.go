@@ -0,0 +1,100 @@
+package store_test
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/rickyson96/amartha-reconciliation-service/internal/parsers/statements"
+	"github.com/rickyson96/amartha-reconciliation-service/internal/parsers/transactions"
+	"github.com/rickyson96/amartha-reconciliation-service/internal/processes/reconciliation"
+	"github.com/rickyson96/amartha-reconciliation-service/internal/store"
+	"github.com/rickyson96/amartha-reconciliation-service/internal/testutils"
+	"github.com/rickyson96/amartha-reconciliation-service/internal/testutils/recontest"
+)
+
+func TestStoreDecisions(t *testing.T) {
+	s, err := store.Open(filepath.Join(t.TempDir(), "store.json"))
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+
+	date := time.Date(2025, 03, 31, 0, 0, 0, 0, time.UTC)
+	trx := func(id string, amount int64) transactions.Transaction {
+		return transactions.Transaction{
+			TrxID:           id,
+			Amount:          testutils.NewDecimal(t, amount, 0),
+			Type:            transactions.TransactionTypeCredit,
+			TransactionTime: date,
+		}
+	}
+	stmt := func(id string, amount int64) statements.Statement {
+		return statements.Statement{
+			UniqueIdentifier: id,
+			Amount:           testutils.NewDecimal(t, amount, 0),
+			Date:             date,
+			Type:             transactions.TransactionTypeCredit,
+		}
+	}
+
+	s.Record(store.Run{ID: "march"}, reconciliation.Process(
+		[]transactions.Transaction{trx("1", 10), trx("2", 20), trx("3", 30)},
+		map[string][]statements.Statement{"bank1.csv": {stmt("a", 11), stmt("b", 5)}},
+		reconciliation.Options{}))
+
+	// the bank booked transaction 1 with a wrong amount
+	forceMatch := store.Decision{Kind: store.DecisionMatch, TrxID: "1", Source: "bank1.csv", UniqueIdentifier: "a", Comment: "bank error", User: "alice"}
+	writeOff := store.Decision{Kind: store.DecisionWriteOff, TrxID: "2", Comment: "test transaction", User: "bob"}
+	investigate := store.Decision{Kind: store.DecisionInvestigating, TrxID: "3", Comment: "asked the bank", User: "alice"}
+
+	invalids := map[store.Decision]error{
+		{Kind: "approve", TrxID: "1"}:                                                   store.ErrUnknownDecision,
+		{Kind: store.DecisionMatch, TrxID: "1"}:                                         store.ErrInvalidDecision,
+		{Kind: store.DecisionWriteOff}:                                                  store.ErrInvalidDecision,
+		{Kind: store.DecisionWriteOff, TrxID: "9"}:                                      store.ErrNotOpen,
+		{Kind: store.DecisionInvestigating, Source: "bank2.csv", UniqueIdentifier: "a"}: store.ErrNotOpen,
+	}
+	for d, wantErr := range invalids {
+		if err := s.Decide(d); !errors.Is(err, wantErr) {
+			t.Errorf("Decide(%+v) error mismatch, want %v, got %v", d, wantErr, err)
+		}
+	}
+
+	for _, d := range []store.Decision{forceMatch, writeOff, investigate} {
+		if err := s.Decide(d); err != nil {
+			t.Fatalf("Decide(%+v) failed: %v", d, err)
+		}
+	}
+
+	trxs, carried := s.CarryForward(nil, nil)
+	result := reconciliation.Process(trxs, nil, s.Apply(reconciliation.Options{Carried: carried}))
+	april := s.Record(store.Run{ID: "april"}, result)
+
+	if diff := cmp.Diff(map[string]string{"1": reconciliation.RuleManual}, recontest.MatchedRules(result)); diff != "" {
+		t.Errorf("matched rules mismatch, (-want,+got):\n%s", diff)
+	}
+	if april.WrittenOff != 1 || april.Unmatched != 2 || april.Cleared != 2 {
+		t.Errorf("april run mismatch: %+v", april)
+	}
+	if diff := cmp.Diff([]store.Decision{forceMatch, writeOff, investigate}, s.AuditTrail(result)); diff != "" {
+		t.Errorf("audit trail mismatch, (-want,+got):\n%s", diff)
+	}
+
+	// the last decision takes precedence
+	writeOff3 := store.Decision{Kind: store.DecisionWriteOff, TrxID: "3", Comment: "bank can't find it", User: "alice"}
+	if err := s.Decide(writeOff3); err != nil {
+		t.Fatalf("Decide failed: %v", err)
+	}
+
+	trxs, carried = s.CarryForward(nil, nil)
+	result = reconciliation.Process(trxs, nil, s.Apply(reconciliation.Options{Carried: carried}))
+	may := s.Record(store.Run{ID: "may"}, result)
+	if may.WrittenOff != 1 || may.Unmatched != 1 {
+		t.Errorf("may run mismatch: %+v", may)
+	}
+	if diff := cmp.Diff([]store.Decision{investigate, writeOff3}, s.AuditTrail(result)); diff != "" {
+		t.Errorf("audit trail mismatch, (-want,+got):\n%s", diff)
+	}
+}
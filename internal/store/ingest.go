@@ -0,0 +1,135 @@
+package store
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"time"
+
+	"github.com/rickyson96/amartha-reconciliation-service/internal/input"
+	"github.com/rickyson96/amartha-reconciliation-service/internal/parsers/transactions"
+	"github.com/rickyson96/amartha-reconciliation-service/internal/processes/reconciliation"
+)
+
+// Checksum computes the sha256 checksum of the file content, identifying
+// the ingested statement files regardless of their name. Compressed
+// files are checksummed by their decompressed content, see [input.Open].
+func Checksum(path string) (string, error) {
+	f, err := input.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// IngestedBy returns the run that ingested the file of the checksum
+func (s *Store) IngestedBy(checksum string) (Run, bool) {
+	for _, run := range s.State.Runs {
+		for _, c := range run.Checksums {
+			if c == checksum {
+				return run, true
+			}
+		}
+	}
+
+	return Run{}, false
+}
+
+// NewTransactions filters out the transactions ingested by the previous
+// runs, the open ones are still carried by [Store.CarryForward]
+func (s *Store) NewTransactions(trxs []transactions.Transaction) []transactions.Transaction {
+	var filtered []transactions.Transaction
+	for _, t := range trxs {
+		if _, ok := s.State.IngestedTransactions[transactionKey(t)]; !ok {
+			filtered = append(filtered, t)
+		}
+	}
+
+	return filtered
+}
+
+// NewTransactionsReader is the reader version of [Store.NewTransactions]
+func (s *Store) NewTransactionsReader(r reconciliation.Reader[transactions.Transaction]) reconciliation.Reader[transactions.Transaction] {
+	return func() (transactions.Transaction, error) {
+		for {
+			trx, err := r()
+			if err != nil {
+				return trx, err
+			}
+			if _, ok := s.State.IngestedTransactions[transactionKey(trx)]; !ok {
+				return trx, nil
+			}
+		}
+	}
+}
+
+// IngestRetention is how long the TrxIDs of the ingested transactions are
+// kept once they're no longer open, counted back from the start date of
+// the run. Re-reading a period older than that ingests its transactions
+// again.
+const IngestRetention = 90 * 24 * time.Hour
+
+// ingest records the transactions of the result as ingested by the run,
+// pruning the ones past [IngestRetention]
+func (s *Store) ingest(run Run, result reconciliation.Result) {
+	if s.State.IngestedTransactions == nil {
+		s.State.IngestedTransactions = make(map[string]string)
+	}
+
+	add := func(trx transactions.Transaction) {
+		if _, ok := s.State.IngestedTransactions[transactionKey(trx)]; !ok {
+			s.State.IngestedTransactions[transactionKey(trx)] = run.ID
+			s.journal = append(s.journal, journalEntry{TrxID: transactionKey(trx), RunID: run.ID})
+		}
+	}
+
+	for _, m := range result.Matched {
+		add(m.Transaction)
+	}
+	for _, p := range result.Netted {
+		add(p.Original)
+		add(p.Reversal)
+	}
+	for _, t := range result.Unmatched.Transactions {
+		add(t)
+	}
+	for _, t := range result.WrittenOff.Transactions {
+		add(t)
+	}
+
+	s.pruneIngested(run.ID, run.StartDate.Add(-IngestRetention), result.Unmatched.Transactions)
+}
+
+// pruneIngested drops the ingested transactions of the runs ending before
+// the cutoff, as their transactions are before it too, except the ones
+// still open. The drops are journaled as the run's.
+func (s *Store) pruneIngested(runID string, cutoff time.Time, open []transactions.Transaction) {
+	expired := make(map[string]bool)
+	for _, run := range s.State.Runs {
+		if run.EndDate.Before(cutoff) {
+			expired[run.ID] = true
+		}
+	}
+	if len(expired) == 0 {
+		return
+	}
+
+	stillOpen := make(map[string]bool, len(open))
+	for _, t := range open {
+		stillOpen[transactionKey(t)] = true
+	}
+
+	for trxID, runID := range s.State.IngestedTransactions {
+		if expired[runID] && !stillOpen[trxID] {
+			delete(s.State.IngestedTransactions, trxID)
+			s.journal = append(s.journal, journalEntry{TrxID: trxID, RunID: runID, Dropped: true})
+		}
+	}
+}
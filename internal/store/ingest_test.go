@@ -0,0 +1,212 @@
+package store_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/rickyson96/amartha-reconciliation-service/internal/parsers/statements"
+	"github.com/rickyson96/amartha-reconciliation-service/internal/parsers/transactions"
+	"github.com/rickyson96/amartha-reconciliation-service/internal/processes/reconciliation"
+	"github.com/rickyson96/amartha-reconciliation-service/internal/store"
+	"github.com/rickyson96/amartha-reconciliation-service/internal/testutils"
+)
+
+func TestChecksum(t *testing.T) {
+	dir := t.TempDir()
+	for name, content := range map[string]string{"a.csv": "1,2,3", "b.csv": "1,2,3", "c.csv": "1,2,4"} {
+		if err := writeFile(filepath.Join(dir, name), content); err != nil {
+			t.Fatalf("failed writing file: %v", err)
+		}
+	}
+
+	checksum := func(name string) string {
+		c, err := store.Checksum(filepath.Join(dir, name))
+		if err != nil {
+			t.Fatalf("Checksum failed: %v", err)
+		}
+		return c
+	}
+
+	if checksum("a.csv") != checksum("b.csv") {
+		t.Errorf("same content should have the same checksum")
+	}
+	if checksum("a.csv") == checksum("c.csv") {
+		t.Errorf("different content should have different checksum")
+	}
+	if _, err := store.Checksum(filepath.Join(dir, "missing.csv")); err == nil {
+		t.Errorf("Checksum should fail on missing file")
+	}
+}
+
+func TestStoreIngested(t *testing.T) {
+	s, err := store.Open(filepath.Join(t.TempDir(), "store.json"))
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+
+	date := time.Date(2025, 03, 31, 0, 0, 0, 0, time.UTC)
+	trx := func(id string) transactions.Transaction {
+		return transactions.Transaction{
+			TrxID:           id,
+			Amount:          testutils.NewDecimal(t, 10, 0),
+			Type:            transactions.TransactionTypeCredit,
+			TransactionTime: date,
+		}
+	}
+	stmt := statements.Statement{
+		UniqueIdentifier: "a",
+		Amount:           testutils.NewDecimal(t, 10, 0),
+		Date:             date,
+		Type:             transactions.TransactionTypeCredit,
+	}
+
+	s.Record(store.Run{ID: "morning", Checksums: map[string]string{"bank1.csv": "abc"}}, reconciliation.Process(
+		[]transactions.Transaction{trx("1"), trx("2")},
+		map[string][]statements.Statement{"bank1.csv": {stmt}},
+		reconciliation.Options{}))
+
+	if run, ok := s.IngestedBy("abc"); !ok || run.ID != "morning" {
+		t.Errorf("IngestedBy should find the morning run, got %v, %v", run, ok)
+	}
+	if _, ok := s.IngestedBy("def"); ok {
+		t.Errorf("IngestedBy shouldn't find unknown checksum")
+	}
+
+	// transaction 2 is still open, and carried forward instead
+	all := []transactions.Transaction{trx("1"), trx("2"), trx("3")}
+	want := []transactions.Transaction{trx("3")}
+	if diff := cmp.Diff(want, s.NewTransactions(all)); diff != "" {
+		t.Errorf("NewTransactions mismatch, (-want,+got):\n%s", diff)
+	}
+
+	var got []transactions.Transaction
+	read := s.NewTransactionsReader(testutils.NewReader(all).Read)
+	for trx, err := read(); err == nil; trx, err = read() {
+		got = append(got, trx)
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("NewTransactionsReader mismatch, (-want,+got):\n%s", diff)
+	}
+}
+
+func TestStoreIngestedPruned(t *testing.T) {
+	s, err := store.Open(filepath.Join(t.TempDir(), "store.json"))
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+
+	march1, march31 := time.Date(2025, 03, 1, 0, 0, 0, 0, time.UTC), time.Date(2025, 03, 31, 0, 0, 0, 0, time.UTC)
+	trx := func(id string, amount int64) transactions.Transaction {
+		return transactions.Transaction{
+			TrxID:           id,
+			Amount:          testutils.NewDecimal(t, amount, 0),
+			Type:            transactions.TransactionTypeCredit,
+			TransactionTime: march31,
+		}
+	}
+	stmt := statements.Statement{UniqueIdentifier: "a", Amount: testutils.NewDecimal(t, 10, 0), Date: march31, Type: transactions.TransactionTypeCredit}
+
+	// transaction 1 is matched, while transaction 2 stays open
+	s.Record(store.Run{ID: "march", StartDate: march1, EndDate: march31}, reconciliation.Process(
+		[]transactions.Transaction{trx("1", 10), trx("2", 20)},
+		map[string][]statements.Statement{"bank1.csv": {stmt}},
+		reconciliation.Options{}))
+
+	// within the retention, both are kept
+	july1 := march31.Add(store.IngestRetention)
+	trxs, carried := s.CarryForward([]transactions.Transaction{trx("3", 30)}, nil)
+	s.Record(store.Run{ID: "june", StartDate: july1.AddDate(0, -1, 0), EndDate: july1}, reconciliation.Process(trxs, nil, reconciliation.Options{Carried: carried}))
+	if len(s.State.IngestedTransactions) != 3 {
+		t.Errorf("should keep the transactions within the retention, got %v", s.State.IngestedTransactions)
+	}
+
+	trxs, carried = s.CarryForward(nil, nil)
+	s.Record(store.Run{ID: "july", StartDate: july1.AddDate(0, 0, 1), EndDate: july1.AddDate(0, 1, 0)}, reconciliation.Process(trxs, nil, reconciliation.Options{Carried: carried}))
+	want := map[string]string{"2": "march", "3": "june"}
+	if diff := cmp.Diff(want, s.State.IngestedTransactions); diff != "" {
+		t.Errorf("IngestedTransactions mismatch, (-want,+got):\n%s", diff)
+	}
+}
+
+func TestStoreIngestJournal(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "store.json")
+	journal := path + ".ingested"
+
+	date := time.Date(2025, 03, 31, 0, 0, 0, 0, time.UTC)
+	trx := func(id string) transactions.Transaction {
+		return transactions.Transaction{
+			TrxID:           id,
+			Amount:          testutils.NewDecimal(t, 10, 0),
+			Type:            transactions.TransactionTypeCredit,
+			TransactionTime: date,
+		}
+	}
+	run := func(id string, trxs ...transactions.Transaction) {
+		s, err := store.Open(path)
+		if err != nil {
+			t.Fatalf("Open failed: %v", err)
+		}
+		trxs, carried := s.CarryForward(trxs, nil)
+		s.Record(store.Run{ID: id, StartDate: date, EndDate: date}, reconciliation.Process(trxs, nil, reconciliation.Options{Carried: carried}))
+		if err := s.Save(); err != nil {
+			t.Fatalf("Save failed: %v", err)
+		}
+	}
+	ingested := func() map[string]string {
+		s, err := store.Open(path)
+		if err != nil {
+			t.Fatalf("Open failed: %v", err)
+		}
+		return s.State.IngestedTransactions
+	}
+
+	// stores written before the journal keep them inline
+	if err := writeFile(path, `{"runs":[{"id":"legacy","endDate":"2025-03-30T00:00:00Z"}],"ingestedTransactions":{"0":"legacy"}}`); err != nil {
+		t.Fatalf("failed writing store: %v", err)
+	}
+	run("morning", trx("1"), trx("2"))
+	run("evening", trx("3"))
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed reading store: %v", err)
+	}
+	if strings.Contains(string(data), "ingestedTransactions") {
+		t.Errorf("store file shouldn't hold the ingested transactions:\n%s", data)
+	}
+
+	// each run appends only its own transactions
+	data, err = os.ReadFile(journal)
+	if err != nil {
+		t.Fatalf("failed reading journal: %v", err)
+	}
+	if lines := strings.Count(string(data), "\n"); lines != 4 {
+		t.Errorf("want 4 journal lines, got %d:\n%s", lines, data)
+	}
+
+	// a run failing before the store is saved leaves lines of an unknown
+	// run, along with a partial line
+	f, err := os.OpenFile(journal, os.O_APPEND|os.O_WRONLY, 0)
+	if err != nil {
+		t.Fatalf("failed opening journal: %v", err)
+	}
+	if _, err := f.WriteString(`{"trxID":"4","runID":"failed"}` + "\n" + `{"trxID":"5","ru`); err != nil {
+		t.Fatalf("failed writing journal: %v", err)
+	}
+	f.Close()
+
+	want := map[string]string{"0": "legacy", "1": "morning", "2": "morning", "3": "evening"}
+	if diff := cmp.Diff(want, ingested()); diff != "" {
+		t.Errorf("IngestedTransactions mismatch, (-want,+got):\n%s", diff)
+	}
+
+	run("retry", trx("4"))
+	want["4"] = "retry"
+	if diff := cmp.Diff(want, ingested()); diff != "" {
+		t.Errorf("IngestedTransactions after retry mismatch, (-want,+got):\n%s", diff)
+	}
+}
@@ -0,0 +1,172 @@
+package store
+
+import (
+	"bufio"
+	"cmp"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+)
+
+// journalSlack is how many dropped lines the ingest journal keeps before
+// it's compacted, on top of one per ingested transaction
+const journalSlack = 1024
+
+// journalEntry is a line of the ingest journal, recording the TrxID as
+// ingested by the run, or dropped by it once past [IngestRetention]
+type journalEntry struct {
+	TrxID   string `json:"trxID"`
+	RunID   string `json:"runID"`
+	Dropped bool   `json:"dropped,omitempty"`
+}
+
+// journalPath is the ingest journal next to the store file. The ingested
+// transactions grow with every run, so they're appended there instead of
+// being rewritten along with the store on every [Store.Save].
+func journalPath(path string) string {
+	return path + ".ingested"
+}
+
+// loadJournal replays the ingest journal into
+// [State.IngestedTransactions]. Lines of runs missing from the store are
+// skipped, as their run failed before the store got saved.
+func (s *Store) loadJournal() error {
+	// stores written before the journal have the ingested transactions
+	// inline, moved into the journal on the next save
+	for trxID, runID := range s.State.IngestedTransactions {
+		s.journal = append(s.journal, journalEntry{TrxID: trxID, RunID: runID})
+	}
+
+	f, err := os.Open(journalPath(s.path))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	runs := make(map[string]bool, len(s.State.Runs))
+	for _, run := range s.State.Runs {
+		runs[run.ID] = true
+	}
+
+	r := bufio.NewReader(f)
+	for {
+		line, err := r.ReadBytes('\n')
+		if errors.Is(err, io.EOF) {
+			// a partial line is a failed append, dropped on compaction
+			s.compact = len(line) > 0
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		s.journalLines++
+
+		var entry journalEntry
+		if err := json.Unmarshal(line, &entry); err != nil {
+			return fmt.Errorf("%s: line %d: %w", journalPath(s.path), s.journalLines, err)
+		}
+		if !runs[entry.RunID] {
+			continue
+		}
+
+		if s.State.IngestedTransactions == nil {
+			s.State.IngestedTransactions = make(map[string]string)
+		}
+		if entry.Dropped {
+			delete(s.State.IngestedTransactions, entry.TrxID)
+		} else {
+			s.State.IngestedTransactions[entry.TrxID] = entry.RunID
+		}
+	}
+}
+
+// saveJournal appends the entries since the last save into the journal,
+// or rewrites it with only the ingested transactions once the dropped
+// lines pile up
+func (s *Store) saveJournal() error {
+	if len(s.journal) == 0 && !s.compact {
+		return nil
+	}
+
+	var err error
+	if s.compact || s.journalLines+len(s.journal) > 2*len(s.State.IngestedTransactions)+journalSlack {
+		err = s.rewriteJournal()
+	} else {
+		err = s.appendJournal()
+	}
+	if err != nil {
+		return err
+	}
+
+	s.journal = nil
+	s.compact = false
+	return nil
+}
+
+func (s *Store) appendJournal() error {
+	f, err := os.OpenFile(journalPath(s.path), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+
+	if err := writeJournal(f, s.journal); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+
+	s.journalLines += len(s.journal)
+	return nil
+}
+
+func (s *Store) rewriteJournal() error {
+	entries := make([]journalEntry, 0, len(s.State.IngestedTransactions))
+	for trxID, runID := range s.State.IngestedTransactions {
+		entries = append(entries, journalEntry{TrxID: trxID, RunID: runID})
+	}
+	slices.SortFunc(entries, func(a, b journalEntry) int {
+		return cmp.Or(cmp.Compare(a.RunID, b.RunID), cmp.Compare(a.TrxID, b.TrxID))
+	})
+
+	path := journalPath(s.path)
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if err := writeJournal(tmp, entries); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return err
+	}
+
+	s.journalLines = len(entries)
+	return nil
+}
+
+func writeJournal(w io.Writer, entries []journalEntry) error {
+	bw := bufio.NewWriter(w)
+	enc := json.NewEncoder(bw)
+	for _, entry := range entries {
+		if err := enc.Encode(entry); err != nil {
+			return err
+		}
+	}
+
+	return bw.Flush()
+}
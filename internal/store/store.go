@@ -0,0 +1,296 @@
+package store
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/rickyson96/amartha-reconciliation-service/internal/parsers/statements"
+	"github.com/rickyson96/amartha-reconciliation-service/internal/parsers/transactions"
+	"github.com/rickyson96/amartha-reconciliation-service/internal/processes/reconciliation"
+)
+
+// Store persists the reconciliation runs along with the open items into
+// a local json file, so that unmatched items are carried forward into
+// the next runs.
+type Store struct {
+	path  string
+	State State
+
+	// journal is the ingest journal entries since the last save, see
+	// [journalPath]
+	journal      []journalEntry
+	journalLines int
+	compact      bool
+}
+
+// State is the persisted content of the store
+type State struct {
+	Runs             []Run             `json:"runs"`
+	OpenTransactions []OpenTransaction `json:"openTransactions"`
+	OpenStatements   []OpenStatement   `json:"openStatements"`
+	Decisions        []Decision        `json:"decisions"`
+	// IngestedTransactions maps the TrxID of the ingested transactions
+	// to the run that ingested them, until [IngestRetention] is past.
+	// They're kept in the ingest journal instead of the store file.
+	IngestedTransactions map[string]string `json:"ingestedTransactions,omitempty"`
+}
+
+// Run is the record of a single reconciliation run
+type Run struct {
+	ID              string    `json:"id"`
+	At              time.Time `json:"at"`
+	StartDate       time.Time `json:"startDate"`
+	EndDate         time.Time `json:"endDate"`
+	TransactionFile string    `json:"transactionFile"`
+	StatementFiles  []string  `json:"statementFiles"`
+	Processed       int       `json:"processed"`
+	Match           int       `json:"match"`
+	Unmatched       int       `json:"unmatched"`
+	// Carried is the open items of previous runs still open after this run
+	Carried int `json:"carried"`
+	// Cleared is the carried items that got matched in this run
+	Cleared int `json:"cleared"`
+	// WrittenOff is the items written off by the decisions
+	WrittenOff int `json:"writtenOff"`
+	// Checksums is the checksum of the statement files, keyed by the file
+	Checksums map[string]string `json:"checksums,omitempty"`
+}
+
+// OpenTransaction is the unmatched transaction carried forward
+type OpenTransaction struct {
+	Transaction transactions.Transaction `json:"transaction"`
+	// RunID is the run that first reported the item as unmatched
+	RunID string `json:"runID"`
+}
+
+// OpenStatement is the unmatched statement carried forward
+type OpenStatement struct {
+	Source    string               `json:"source"`
+	Statement statements.Statement `json:"statement"`
+	// RunID is the run that first reported the item as unmatched
+	RunID string `json:"runID"`
+}
+
+// Open loads the store out of the json file. Missing file means an
+// empty store, which is created on [Store.Save].
+func Open(path string) (*Store, error) {
+	s := &Store{path: path}
+
+	data, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+
+	if err == nil {
+		if err := json.Unmarshal(data, &s.State); err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+	}
+
+	if err := s.loadJournal(); err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+// Save writes the store into its file atomically. The ingest journal is
+// written first, so that its entries only count once the run is saved.
+func (s *Store) Save() error {
+	if err := s.saveJournal(); err != nil {
+		return err
+	}
+
+	state := s.State
+	state.IngestedTransactions = nil
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), filepath.Base(s.path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), s.path)
+}
+
+// NewRunID generates the run id out of the run time
+func NewRunID(at time.Time) string {
+	return at.UTC().Format("20060102T150405.000000000")
+}
+
+// transactionKey and statementKey identify the items across runs
+func transactionKey(trx transactions.Transaction) string {
+	return trx.TrxID
+}
+
+func statementKey(source string, stmt statements.Statement) string {
+	return statementRef(source, stmt.UniqueIdentifier)
+}
+
+func statementRef(source, uniqueIdentifier string) string {
+	return source + "\x00" + uniqueIdentifier
+}
+
+// CarryForward adds the open transactions into the data to be
+// reconciled, and returns the open statements to be reconciled as
+// [reconciliation.Options.Carried], skipping items that are already part
+// of the data, e.g. when the same period is reconciled again.
+func (s *Store) CarryForward(trxs []transactions.Transaction, stmtFiles map[string][]statements.Statement) ([]transactions.Transaction, map[string][]statements.Statement) {
+	seenTrx := make(map[string]bool, len(trxs))
+	for _, t := range trxs {
+		seenTrx[transactionKey(t)] = true
+	}
+
+	seenStmt := make(map[string]bool)
+	for source, stmts := range stmtFiles {
+		for _, st := range stmts {
+			seenStmt[statementKey(source, st)] = true
+		}
+	}
+
+	for _, open := range s.State.OpenTransactions {
+		if !seenTrx[transactionKey(open.Transaction)] {
+			trxs = append(trxs, open.Transaction)
+		}
+	}
+
+	var carried map[string][]statements.Statement
+	for _, open := range s.State.OpenStatements {
+		if seenStmt[statementKey(open.Source, open.Statement)] {
+			continue
+		}
+		if carried == nil {
+			carried = make(map[string][]statements.Statement)
+		}
+		carried[open.Source] = append(carried[open.Source], open.Statement)
+	}
+
+	return trxs, carried
+}
+
+// CarryForwardReaders wraps the readers to stream the open items not
+// found on the readers once they're done.
+func (s *Store) CarryForwardReaders(trx reconciliation.Reader[transactions.Transaction], stmt reconciliation.Reader[reconciliation.StatementFilePair]) (reconciliation.Reader[transactions.Transaction], reconciliation.Reader[reconciliation.StatementFilePair]) {
+	var openTrxs []transactions.Transaction
+	for _, open := range s.State.OpenTransactions {
+		openTrxs = append(openTrxs, open.Transaction)
+	}
+
+	var openStmts []reconciliation.StatementFilePair
+	for _, open := range s.State.OpenStatements {
+		openStmts = append(openStmts, reconciliation.StatementFilePair{Name: open.Source, Statement: open.Statement, Carried: true})
+	}
+
+	trxReader := carryReader(trx, openTrxs, transactionKey)
+	stmtReader := carryReader(stmt, openStmts, func(pair reconciliation.StatementFilePair) string {
+		return statementKey(pair.Name, pair.Statement)
+	})
+
+	return trxReader, stmtReader
+}
+
+func carryReader[T any](r reconciliation.Reader[T], open []T, key func(T) string) reconciliation.Reader[T] {
+	seen := make(map[string]bool)
+	done := false
+	return func() (T, error) {
+		if !done {
+			data, err := r()
+			if err == nil {
+				seen[key(data)] = true
+				return data, nil
+			}
+			if !errors.Is(err, io.EOF) {
+				return data, err
+			}
+			done = true
+		}
+
+		for len(open) > 0 {
+			data := open[0]
+			open = open[1:]
+			if !seen[key(data)] {
+				return data, nil
+			}
+		}
+
+		return *new(T), io.EOF
+	}
+}
+
+// Record stores the run along with its unmatched items as the open
+// items, replacing the previous ones. Items that stay unmatched keep the
+// run that first reported them.
+func (s *Store) Record(run Run, result reconciliation.Result) Run {
+	firstTrx := make(map[string]string, len(s.State.OpenTransactions))
+	for _, open := range s.State.OpenTransactions {
+		firstTrx[transactionKey(open.Transaction)] = open.RunID
+	}
+
+	firstStmt := make(map[string]string, len(s.State.OpenStatements))
+	for _, open := range s.State.OpenStatements {
+		firstStmt[statementKey(open.Source, open.Statement)] = open.RunID
+	}
+
+	for _, m := range result.Matched {
+		if _, ok := firstTrx[transactionKey(m.Transaction)]; ok {
+			run.Cleared++
+			delete(firstTrx, transactionKey(m.Transaction))
+		}
+		if _, ok := firstStmt[statementKey(m.Statement.Name, m.Statement.Statement)]; ok {
+			run.Cleared++
+			delete(firstStmt, statementKey(m.Statement.Name, m.Statement.Statement))
+		}
+	}
+
+	var openTrxs []OpenTransaction
+	for _, t := range result.Unmatched.Transactions {
+		runID, ok := firstTrx[transactionKey(t)]
+		if ok {
+			run.Carried++
+		} else {
+			runID = run.ID
+		}
+		openTrxs = append(openTrxs, OpenTransaction{Transaction: t, RunID: runID})
+	}
+
+	var openStmts []OpenStatement
+	for source, stmts := range result.Unmatched.Statements {
+		for _, st := range stmts {
+			runID, ok := firstStmt[statementKey(source, st)]
+			if ok {
+				run.Carried++
+			} else {
+				runID = run.ID
+			}
+			openStmts = append(openStmts, OpenStatement{Source: source, Statement: st, RunID: runID})
+		}
+	}
+
+	run.Processed = result.Processed
+	run.Match = result.Match
+	run.Unmatched = result.UnmatchedCount()
+	run.WrittenOff = result.WrittenOffCount()
+
+	s.ingest(run, result)
+	s.State.Runs = append(s.State.Runs, run)
+	s.State.OpenTransactions = openTrxs
+	s.State.OpenStatements = openStmts
+	return run
+}
@@ -0,0 +1,146 @@
+package store_test
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/rickyson96/amartha-reconciliation-service/internal/parsers/statements"
+	"github.com/rickyson96/amartha-reconciliation-service/internal/parsers/transactions"
+	"github.com/rickyson96/amartha-reconciliation-service/internal/processes/reconciliation"
+	"github.com/rickyson96/amartha-reconciliation-service/internal/store"
+	"github.com/rickyson96/amartha-reconciliation-service/internal/testutils"
+)
+
+func TestStoreCarryForward(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "store.json")
+	march31 := time.Date(2025, 03, 31, 0, 0, 0, 0, time.UTC)
+	april2 := time.Date(2025, 04, 2, 0, 0, 0, 0, time.UTC)
+
+	pending := transactions.Transaction{
+		TrxID:           "1",
+		Amount:          testutils.NewDecimal(t, 10, 0),
+		Type:            transactions.TransactionTypeCredit,
+		TransactionTime: march31.Add(10 * time.Hour),
+	}
+	orphan := transactions.Transaction{
+		TrxID:           "2",
+		Amount:          testutils.NewDecimal(t, 20, 0),
+		Type:            transactions.TransactionTypeDebit,
+		TransactionTime: march31.Add(11 * time.Hour),
+	}
+	clearing := statements.Statement{
+		UniqueIdentifier: "a",
+		Amount:           testutils.NewDecimal(t, 10, 0),
+		Date:             april2,
+		Type:             transactions.TransactionTypeCredit,
+	}
+
+	// March run, both transactions are unmatched
+	s, err := store.Open(path)
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	trxs, carried := s.CarryForward([]transactions.Transaction{pending, orphan}, nil)
+	march := s.Record(store.Run{ID: "march"}, reconciliation.Process(trxs, nil, reconciliation.Options{Carried: carried}))
+	if err := s.Save(); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	if march.Unmatched != 2 || march.Carried != 0 {
+		t.Errorf("march run mismatch: %+v", march)
+	}
+
+	// April run, the pending transaction clears on April 2nd
+	s, err = store.Open(path)
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	stmts := map[string][]statements.Statement{"bank1.csv": {clearing}}
+	trxs, carried = s.CarryForward(nil, stmts)
+	if len(trxs) != 2 {
+		t.Fatalf("open transactions should be carried, got %d", len(trxs))
+	}
+	// the bank books it 2 days later
+	opts := reconciliation.Options{Matchers: []reconciliation.Matcher{
+		reconciliation.NewKeyMatcher(),
+		reconciliation.NewDateWindowMatcher(3),
+	}, Carried: carried}
+	result := reconciliation.Process(trxs, stmts, opts)
+	april := s.Record(store.Run{ID: "april"}, result)
+	if err := s.Save(); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+
+	if result.Match != 2 || result.UnmatchedCount() != 1 {
+		t.Errorf("april result mismatch, match %d, unmatched %d", result.Match, result.UnmatchedCount())
+	}
+	if april.Carried != 1 || april.Cleared != 1 {
+		t.Errorf("april run mismatch: %+v", april)
+	}
+
+	s, err = store.Open(path)
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	wantOpen := []store.OpenTransaction{{Transaction: orphan, RunID: "march"}}
+	if diff := cmp.Diff(wantOpen, s.State.OpenTransactions); diff != "" {
+		t.Errorf("open transactions mismatch, (-want,+got):\n%s", diff)
+	}
+	if len(s.State.OpenStatements) != 0 {
+		t.Errorf("open statements should be empty, got %v", s.State.OpenStatements)
+	}
+	if len(s.State.Runs) != 2 {
+		t.Errorf("should record 2 runs, got %d", len(s.State.Runs))
+	}
+}
+
+func TestStoreCarryForwardSkipsKnownItems(t *testing.T) {
+	s, err := store.Open(filepath.Join(t.TempDir(), "store.json"))
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+
+	date := time.Date(2025, 03, 31, 0, 0, 0, 0, time.UTC)
+	trx := transactions.Transaction{TrxID: "1", Amount: testutils.NewDecimal(t, 10, 0), TransactionTime: date}
+	stmt := statements.Statement{UniqueIdentifier: "a", Amount: testutils.NewDecimal(t, 20, 0), Date: date}
+	s.Record(store.Run{ID: "march"}, reconciliation.Process(
+		[]transactions.Transaction{trx},
+		map[string][]statements.Statement{"bank1.csv": {stmt}},
+		reconciliation.Options{}))
+
+	// re-running march reads the same items again
+	trxs, carried := s.CarryForward([]transactions.Transaction{trx}, map[string][]statements.Statement{"bank1.csv": {stmt}})
+	if len(trxs) != 1 || len(carried) != 0 {
+		t.Errorf("known items shouldn't be carried, got %v and %v", trxs, carried)
+	}
+
+	trxReader, stmtReader := s.CarryForwardReaders(
+		testutils.NewReader([]transactions.Transaction{trx}).Read,
+		testutils.NewReader([]reconciliation.StatementFilePair{}).Read)
+	result, err := reconciliation.ProcessConcurrent(trxReader, stmtReader, reconciliation.Options{})
+	if err != nil {
+		t.Fatalf("ProcessConcurrent failed: %v", err)
+	}
+	if result.Processed != 2 {
+		t.Errorf("should process the read transaction and the carried statement, got %d", result.Processed)
+	}
+}
+
+func TestOpenInvalidStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "store.json")
+	s, err := store.Open(path)
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	if err := s.Save(); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+
+	if err := writeFile(path, "{"); err != nil {
+		t.Fatalf("failed writing store: %v", err)
+	}
+	if _, err := store.Open(path); err == nil {
+		t.Errorf("Open should fail on invalid store")
+	}
+}
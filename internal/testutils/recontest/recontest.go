@@ -0,0 +1,15 @@
+// Package recontest provides the test helpers on the reconciliation
+// results, apart from testutils which the parsers under test import.
+package recontest
+
+import "github.com/rickyson96/amartha-reconciliation-service/internal/processes/reconciliation"
+
+// MatchedRules maps the TrxID of the matched transactions to the rule
+// that matched them
+func MatchedRules(result reconciliation.Result) map[string]string {
+	rules := make(map[string]string)
+	for _, m := range result.Matched {
+		rules[m.Transaction.TrxID] = m.Rule
+	}
+	return rules
+}
@@ -1,6 +1,7 @@
 package testutils
 
 import (
+	"io"
 	"testing"
 
 	"github.com/govalues/decimal"
@@ -14,3 +15,23 @@ func NewDecimal(t testing.TB, value int64, scale int) decimal.Decimal {
 	}
 	return d
 }
+
+// Reader streams the data one at a time, returning [io.EOF] at the end.
+// It follows the reader contract of the processes.
+type Reader[T any] struct {
+	data   []T
+	offset int
+}
+
+func NewReader[S ~[]T, T any](data S) *Reader[T] {
+	return &Reader[T]{data: data}
+}
+
+func (r *Reader[T]) Read() (T, error) {
+	if r.offset >= len(r.data) {
+		return *new(T), io.EOF
+	}
+	data := r.data[r.offset]
+	r.offset++
+	return data, nil
+}
@@ -0,0 +1,248 @@
+package validation
+
+import (
+	"bytes"
+	"cmp"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	csvparser "github.com/rickyson96/amartha-reconciliation-service/internal/csv_parser"
+	"github.com/rickyson96/amartha-reconciliation-service/internal/duplicates"
+	"github.com/rickyson96/amartha-reconciliation-service/internal/parsers/statements"
+	"github.com/rickyson96/amartha-reconciliation-service/internal/parsers/transactions"
+)
+
+const (
+	// SeverityError is the blocking issue, the file can't be reconciled
+	// reliably until it's fixed
+	SeverityError = "error"
+	// SeverityWarning is the issue worth checking before reconciling
+	SeverityWarning = "warning"
+)
+
+var (
+	utf8BOM    = []byte{0xEF, 0xBB, 0xBF}
+	utf16LEBOM = []byte{0xFF, 0xFE}
+	utf16BEBOM = []byte{0xFE, 0xFF}
+
+	// minDate and maxDate read the rows regardless of their date, the
+	// requested range is checked by the validation instead
+	minDate = time.Time{}
+	maxDate = time.Date(9999, 12, 30, 0, 0, 0, 0, time.UTC)
+)
+
+// Issue is a problem found on the file. Line is zero for problems of the
+// whole file.
+type Issue struct {
+	Line     int    `json:"line,omitempty"`
+	Severity string `json:"severity"`
+	Message  string `json:"message"`
+}
+
+// Report is the validation result of a file
+type Report struct {
+	File string `json:"file"`
+	Rows int    `json:"rows"`
+	// First and Last is the date range present on the file
+	First time.Time `json:"first"`
+	Last  time.Time `json:"last"`
+	// OutOfRange is the rows outside of the requested date range
+	OutOfRange int     `json:"outOfRange"`
+	Issues     []Issue `json:"issues"`
+}
+
+// Blocking reports whether the file has any blocking issue
+func (r Report) Blocking() bool {
+	for _, issue := range r.Issues {
+		if issue.Severity == SeverityError {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *Report) addIssue(line int, severity, format string, args ...any) {
+	r.Issues = append(r.Issues, Issue{Line: line, Severity: severity, Message: fmt.Sprintf(format, args...)})
+}
+
+func (r *Report) sortIssues() {
+	slices.SortStableFunc(r.Issues, func(a, b Issue) int {
+		return cmp.Compare(a.Line, b.Line)
+	})
+}
+
+func (r *Report) addDate(date time.Time) {
+	if r.First.IsZero() || date.Before(r.First) {
+		r.First = date
+	}
+	if r.Last.IsZero() || date.After(r.Last) {
+		r.Last = date
+	}
+}
+
+// Transactions validates the transaction file within the date range
+func Transactions(name string, file io.Reader, format transactions.Format, startDate, endDate time.Time) (Report, error) {
+	report := Report{File: name}
+	data, err := checkEncoding(&report, file, format.Charset)
+	if err != nil || report.Blocking() {
+		return report, err
+	}
+
+	parser, err := transactions.NewReader(bytes.NewReader(data), minDate, maxDate, format)
+	if err != nil {
+		report.addIssue(0, SeverityError, "can't be read: %v", err)
+		return report, nil
+	}
+	detector := duplicates.NewDetector(func(trx transactions.Transaction) string { return trx.TrxID })
+	err = readRows(&report, parser.ReadRow, func(row csvparser.Row[transactions.Transaction]) {
+		trx := row.Data
+		detector.Add(row)
+		report.addDate(trx.TransactionTime)
+
+		// same range as the transaction parser filter
+		if !trx.InRange(startDate, endDate) {
+			report.OutOfRange++
+		}
+		// reversal of a credit carries a negative amount
+		if trx.Amount.IsNeg() && trx.Type != transactions.TransactionTypeReversal {
+			report.addIssue(row.Line, SeverityError, "negative amount %v, transaction amount should be signed by its type", trx.Amount)
+		}
+		if trx.Amount.IsZero() {
+			report.addIssue(row.Line, SeverityWarning, "zero amount")
+		}
+	})
+	if err != nil {
+		return report, err
+	}
+
+	addDuplicates(&report, "TrxID", detector.Duplicates())
+	report.sortIssues()
+	return report, nil
+}
+
+// Statements validates the statement file within the date range
+func Statements(name string, file io.Reader, format statements.Format, startDate, endDate time.Time) (Report, error) {
+	report := Report{File: name}
+
+	// encoding only applies to csv, spreadsheets are read as is
+	if format.FileType(name) == statements.FileTypeCSV {
+		data, err := checkEncoding(&report, file, format.Charset)
+		if err != nil || report.Blocking() {
+			return report, err
+		}
+		file = bytes.NewReader(data)
+	}
+
+	parser, err := statements.NewReader(name, file, minDate, maxDate, format)
+	if err != nil {
+		report.addIssue(0, SeverityError, "can't be read: %v", err)
+		return report, nil
+	}
+	detector := duplicates.NewDetector(func(stmt statements.Statement) string { return stmt.UniqueIdentifier })
+	err = readRows(&report, parser.ReadRow, func(row csvparser.Row[statements.Statement]) {
+		stmt := row.Data
+		detector.Add(row)
+		report.addDate(stmt.Date)
+
+		// same range as the statement parser filter
+		if stmt.Date.Before(startDate) || stmt.Date.After(endDate) {
+			report.OutOfRange++
+		}
+		if stmt.Amount.IsZero() {
+			report.addIssue(row.Line, SeverityWarning, "zero amount")
+		}
+	})
+	if err != nil {
+		return report, err
+	}
+
+	addDuplicates(&report, "UniqueIdentifier", detector.Duplicates())
+	report.sortIssues()
+	return report, nil
+}
+
+// checkEncoding reads the file content and reports the encoding issues.
+// Files in UTF-16 can't be parsed at all, hence blocking. Invalid UTF-8
+// is expected when the format declares other charset.
+func checkEncoding(report *Report, file io.Reader, charset string) ([]byte, error) {
+	data, err := io.ReadAll(file)
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case bytes.HasPrefix(data, utf16LEBOM), bytes.HasPrefix(data, utf16BEBOM):
+		report.addIssue(0, SeverityError, "file is encoded in UTF-16, export it as UTF-8")
+		return data, nil
+	case bytes.HasPrefix(data, utf8BOM):
+		report.addIssue(0, SeverityWarning, "file starts with UTF-8 BOM")
+		data = data[len(utf8BOM):]
+	}
+
+	if !utf8.Valid(data) && (charset == "" || strings.EqualFold(charset, csvparser.CharsetUTF8)) {
+		line := bytes.Count(data[:invalidUTF8(data)], []byte("\n")) + 1
+		report.addIssue(line, SeverityWarning, "invalid UTF-8, the file may be encoded in Windows-1252")
+	}
+
+	return data, nil
+}
+
+// invalidUTF8 returns the offset of the first invalid UTF-8 sequence
+func invalidUTF8(data []byte) int {
+	for i := 0; i < len(data); {
+		r, size := utf8.DecodeRune(data[i:])
+		if r == utf8.RuneError && size == 1 {
+			return i
+		}
+		i += size
+	}
+	return len(data)
+}
+
+// readRows reads through the rows, reporting the rows that failed to be
+// parsed and continuing with the next ones
+func readRows[T any](report *Report, read func() (csvparser.Row[T], error), check func(row csvparser.Row[T])) error {
+	for {
+		row, err := read()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+
+		var rowErr *csvparser.RowError
+		var csvErr *csv.ParseError
+		switch {
+		case errors.As(err, &rowErr):
+			report.addIssue(rowErr.Line, SeverityError, "parse error: %v", rowErr.Err)
+			continue
+		case errors.As(err, &csvErr):
+			report.addIssue(csvErr.Line, SeverityError, "parse error: %v", csvErr.Err)
+			continue
+		case err != nil:
+			return err
+		}
+
+		report.Rows++
+		check(row)
+	}
+}
+
+func addDuplicates(report *Report, idName string, dups []duplicates.Duplicate) {
+	for _, d := range dups {
+		lines := make([]string, len(d.Lines))
+		for i, line := range d.Lines {
+			lines[i] = fmt.Sprint(line)
+		}
+
+		kind := "conflicting"
+		if d.Exact {
+			kind = "exact"
+		}
+		report.addIssue(d.Lines[1], SeverityError, "duplicate %s %q (%s rows on lines %s)", idName, d.ID, kind, strings.Join(lines, ", "))
+	}
+}
@@ -0,0 +1,128 @@
+package validation_test
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/rickyson96/amartha-reconciliation-service/internal/parsers/statements"
+	"github.com/rickyson96/amartha-reconciliation-service/internal/parsers/transactions"
+	"github.com/rickyson96/amartha-reconciliation-service/internal/validation"
+)
+
+var (
+	march1  = time.Date(2025, 03, 1, 0, 0, 0, 0, time.UTC)
+	march31 = time.Date(2025, 03, 31, 0, 0, 0, 0, time.UTC)
+)
+
+func TestTransactions(t *testing.T) {
+	tests := []struct {
+		name         string
+		input        string
+		want         validation.Report
+		wantBlocking bool
+	}{
+		{
+			name: "valid",
+			input: "trxID,amount,type,transactionTime\n" +
+				"1,10.00,CREDIT,2025-03-14 10:00:00\n" +
+				"2,20.00,DEBIT,2025-04-01 10:00:00\n",
+			want: validation.Report{
+				File:       "trx.csv",
+				Rows:       2,
+				First:      time.Date(2025, 03, 14, 10, 0, 0, 0, time.UTC),
+				Last:       time.Date(2025, 04, 1, 10, 0, 0, 0, time.UTC),
+				OutOfRange: 1,
+			},
+		},
+		{
+			name: "row issues",
+			input: "\xEF\xBB\xBFtrxID,amount,type,transactionTime\n" +
+				"1,10.00,CREDIT,2025-03-14 10:00:00\n" +
+				"2,-20.00,DEBIT,2025-03-14 10:00:00\n" +
+				"3,0,DEBIT,2025-03-14 10:00:00\n" +
+				"4,abc,DEBIT,2025-03-14 10:00:00\n" +
+				"5,10.00,CREDIT\n" +
+				"1,10.00,CREDIT,2025-03-14 10:00:00\n",
+			want: validation.Report{
+				File:  "trx.csv",
+				Rows:  4,
+				First: time.Date(2025, 03, 14, 10, 0, 0, 0, time.UTC),
+				Last:  time.Date(2025, 03, 14, 10, 0, 0, 0, time.UTC),
+				Issues: []validation.Issue{
+					{Severity: validation.SeverityWarning, Message: "file starts with UTF-8 BOM"},
+					{Line: 3, Severity: validation.SeverityError, Message: "negative amount -20.00, transaction amount should be signed by its type"},
+					{Line: 4, Severity: validation.SeverityWarning, Message: "zero amount"},
+					{Line: 5, Severity: validation.SeverityError, Message: "parse error: parsing decimal: invalid decimal: unexpected character 'a'"},
+					{Line: 6, Severity: validation.SeverityError, Message: "parse error: wrong number of fields"},
+					{Line: 7, Severity: validation.SeverityError, Message: `duplicate TrxID "1" (exact rows on lines 2, 7)`},
+				},
+			},
+			wantBlocking: true,
+		},
+		{
+			name:  "utf-16",
+			input: "\xFF\xFEt\x00r\x00x\x00",
+			want: validation.Report{
+				File: "trx.csv",
+				Issues: []validation.Issue{
+					{Severity: validation.SeverityError, Message: "file is encoded in UTF-16, export it as UTF-8"},
+				},
+			},
+			wantBlocking: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := validation.Transactions("trx.csv", bytes.NewBufferString(test.input), transactions.DefaultFormat, march1, march31)
+			if err != nil {
+				t.Fatalf("unwanted error: %v", err)
+			}
+			if diff := cmp.Diff(test.want, got); diff != "" {
+				t.Errorf("Transactions mismatch, (-want,+got):\n%s", diff)
+			}
+			if got.Blocking() != test.wantBlocking {
+				t.Errorf("Blocking mismatch, want %t, got %t", test.wantBlocking, got.Blocking())
+			}
+		})
+	}
+}
+
+func TestStatements(t *testing.T) {
+	input := "uniqueIdentifier,amount,date,description\n" +
+		"a,-10.00,2025-02-28,caf\xE9\n" +
+		"b,0,2025-03-14,\n" +
+		"a,-11.00,2025-03-14,\n"
+
+	got, err := validation.Statements("bank1.csv", bytes.NewBufferString(input), statements.Format{
+		UniqueIdentifierColumn: 0,
+		AmountColumn:           1,
+		DateColumn:             2,
+		DescriptionColumn:      3,
+		FieldPerRow:            4,
+		TypeColumn:             -1,
+		DebitAmountColumn:      -1,
+		CreditAmountColumn:     -1,
+	}, march1, march31)
+	if err != nil {
+		t.Fatalf("unwanted error: %v", err)
+	}
+
+	want := validation.Report{
+		File:       "bank1.csv",
+		Rows:       3,
+		First:      time.Date(2025, 02, 28, 0, 0, 0, 0, time.UTC),
+		Last:       time.Date(2025, 03, 14, 0, 0, 0, 0, time.UTC),
+		OutOfRange: 1,
+		Issues: []validation.Issue{
+			{Line: 2, Severity: validation.SeverityWarning, Message: "invalid UTF-8, the file may be encoded in Windows-1252"},
+			{Line: 3, Severity: validation.SeverityWarning, Message: "zero amount"},
+			{Line: 4, Severity: validation.SeverityError, Message: `duplicate UniqueIdentifier "a" (conflicting rows on lines 2, 4)`},
+		},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Statements mismatch, (-want,+got):\n%s", diff)
+	}
+}
@@ -0,0 +1,144 @@
+package watcher
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// stamp identifies the file version by its size and modification time,
+// as file events are not available on network mounts
+type stamp struct {
+	size    int64
+	modTime time.Time
+}
+
+// Watcher polls the directories for files. Files are ready once they're
+// settled, i.e. they haven't changed since the previous poll, so that
+// files still being written are left for the next poll.
+type Watcher struct {
+	dirs []string
+	seen map[string]stamp
+}
+
+func New(dirs ...string) *Watcher {
+	return &Watcher{dirs: dirs, seen: make(map[string]stamp)}
+}
+
+// Poll lists the settled files matching any of the patterns, sorted by
+// their path
+func (w *Watcher) Poll(patterns ...string) ([]string, error) {
+	var ready []string
+	for _, dir := range w.dirs {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, entry := range entries {
+			if !entry.Type().IsRegular() || !matchAny(patterns, entry.Name()) {
+				continue
+			}
+
+			info, err := entry.Info()
+			if errors.Is(err, os.ErrNotExist) {
+				continue
+			}
+			if err != nil {
+				return nil, err
+			}
+
+			path := filepath.Join(dir, entry.Name())
+			current := stamp{size: info.Size(), modTime: info.ModTime()}
+			if last, ok := w.seen[path]; ok && last == current {
+				ready = append(ready, path)
+			}
+			w.seen[path] = current
+		}
+	}
+
+	slices.Sort(ready)
+	return ready, nil
+}
+
+func matchAny(patterns []string, name string) bool {
+	for _, pattern := range patterns {
+		if ok, _ := filepath.Match(pattern, name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// Archive moves the files into the directory, creating it when needed.
+// Files are copied when they can't be renamed, e.g. across mounts. Files
+// of the same name, e.g. from different directories, are numbered
+// instead of overwriting each other, see [archiveName].
+func (w *Watcher) Archive(dir string, files ...string) error {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+
+	for _, file := range files {
+		dst, err := archiveName(dir, filepath.Base(file))
+		if err != nil {
+			return err
+		}
+		if err := move(file, dst); err != nil {
+			return err
+		}
+		delete(w.seen, file)
+	}
+
+	return nil
+}
+
+// archiveName returns the path of the name within the directory, which
+// is numbered when it's taken, e.g. bank1.1.csv
+func archiveName(dir, name string) (string, error) {
+	ext := filepath.Ext(name)
+	stem := strings.TrimSuffix(name, ext)
+	path := filepath.Join(dir, name)
+	for i := 1; ; i++ {
+		_, err := os.Lstat(path)
+		if errors.Is(err, os.ErrNotExist) {
+			return path, nil
+		}
+		if err != nil {
+			return "", err
+		}
+		path = filepath.Join(dir, stem+"."+strconv.Itoa(i)+ext)
+	}
+}
+
+func move(src, dst string) error {
+	if err := os.Rename(src, dst); err == nil {
+		return nil
+	}
+
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	if err := out.Close(); err != nil {
+		return err
+	}
+
+	return os.Remove(src)
+}
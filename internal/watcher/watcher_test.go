@@ -0,0 +1,94 @@
+package watcher_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/rickyson96/amartha-reconciliation-service/internal/watcher"
+)
+
+func TestWatcher(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		t.Helper()
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatalf("failed writing file: %v", err)
+		}
+		return path
+	}
+	poll := func(w *watcher.Watcher, want []string) {
+		t.Helper()
+		got, err := w.Poll("bank*.csv", "transactions*.csv")
+		if err != nil {
+			t.Fatalf("Poll failed: %v", err)
+		}
+		if diff := cmp.Diff(want, got); diff != "" {
+			t.Errorf("Poll mismatch, (-want,+got):\n%s", diff)
+		}
+	}
+
+	w := watcher.New(dir)
+	bank1 := write("bank1.csv", "a")
+	trx := write("transactions.csv", "1")
+	write("notes.txt", "ignored")
+
+	// new files are not settled yet
+	poll(w, nil)
+	poll(w, []string{bank1, trx})
+
+	// bank1 is still being written
+	write("bank1.csv", "ab")
+	poll(w, []string{trx})
+	poll(w, []string{bank1, trx})
+
+	archive := filepath.Join(dir, "archive", "run")
+	if err := w.Archive(archive, bank1, trx); err != nil {
+		t.Fatalf("Archive failed: %v", err)
+	}
+	poll(w, nil)
+
+	for _, name := range []string{"bank1.csv", "transactions.csv"} {
+		if _, err := os.Stat(filepath.Join(archive, name)); err != nil {
+			t.Errorf("%s should be archived: %v", name, err)
+		}
+	}
+}
+
+func TestWatcherArchiveSameName(t *testing.T) {
+	dirs := []string{t.TempDir(), t.TempDir()}
+	var files []string
+	for i, dir := range dirs {
+		path := filepath.Join(dir, "bank1.csv")
+		if err := os.WriteFile(path, []byte{'a' + byte(i)}, 0o600); err != nil {
+			t.Fatalf("failed writing file: %v", err)
+		}
+		files = append(files, path)
+	}
+
+	archive := filepath.Join(t.TempDir(), "run")
+	if err := watcher.New(dirs...).Archive(archive, files...); err != nil {
+		t.Fatalf("Archive failed: %v", err)
+	}
+
+	got := make(map[string]string)
+	for _, name := range []string{"bank1.csv", "bank1.1.csv"} {
+		data, err := os.ReadFile(filepath.Join(archive, name))
+		if err != nil {
+			t.Fatalf("%s should be archived: %v", name, err)
+		}
+		got[name] = string(data)
+	}
+	if diff := cmp.Diff(map[string]string{"bank1.csv": "a", "bank1.1.csv": "b"}, got); diff != "" {
+		t.Errorf("archived files mismatch, (-want,+got):\n%s", diff)
+	}
+}
+
+func TestWatcherMissingDir(t *testing.T) {
+	w := watcher.New(filepath.Join(t.TempDir(), "missing"))
+	if _, err := w.Poll("*.csv"); err == nil {
+		t.Errorf("Poll should fail on missing directory")
+	}
+}
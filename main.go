@@ -1,27 +1,46 @@
 package main
 
 import (
+	"cmp"
 	"flag"
 	"fmt"
+	"io"
 	"log"
 	"maps"
 	"os"
+	"slices"
+	"strconv"
 	"strings"
 	"text/tabwriter"
 	"time"
 
+	"github.com/govalues/decimal"
+	"github.com/rickyson96/amartha-reconciliation-service/internal/config"
+	"github.com/rickyson96/amartha-reconciliation-service/internal/duplicates"
+	"github.com/rickyson96/amartha-reconciliation-service/internal/input"
 	"github.com/rickyson96/amartha-reconciliation-service/internal/parsers/statements"
+	"github.com/rickyson96/amartha-reconciliation-service/internal/parsers/transactions"
+	"github.com/rickyson96/amartha-reconciliation-service/internal/processes/balance"
 	"github.com/rickyson96/amartha-reconciliation-service/internal/processes/reconciliation"
+	"github.com/rickyson96/amartha-reconciliation-service/internal/sink"
+	"github.com/rickyson96/amartha-reconciliation-service/internal/store"
 )
 
 func usage() {
 	w := flag.CommandLine.Output()
 	fmt.Fprintf(w, "Usage of %s [options] {transaction file} {statement files} {start date} {end date}\n", os.Args[0])
+	fmt.Fprintf(w, "   or %s {match|resolve|watch|validate|inspect} [options] {args}\n", os.Args[0])
 	fmt.Fprintf(w, "Args:\n")
-	fmt.Fprintf(w, "  transaction file: The system's transaction csv file to be reconciled\n")
+	fmt.Fprintf(w, "  transaction file: The system's transaction csv file to be reconciled, - to read it from stdin, db to query the transactionDatabase of the profile\n")
 	fmt.Fprintf(w, "  statement files: The bank's statement csv files to be reconciled, accept comma separated value. e.g.: bank1.csv,bank2.csv\n")
 	fmt.Fprintf(w, "  start date: The reconciliate start date. e.g.: 2025-01-02\n")
 	fmt.Fprintf(w, "  end date: The reconciliate end date. e.g.: 2025-12-31\n")
+	fmt.Fprintf(w, "Commands:\n")
+	fmt.Fprintf(w, "  match: Force-match a transaction to a statement on the store\n")
+	fmt.Fprintf(w, "  resolve: Mark a transaction or a statement on the store as written off or being investigated\n")
+	fmt.Fprintf(w, "  watch: Poll directories and reconcile the files dropped into them\n")
+	fmt.Fprintf(w, "  validate: Lint the input files without reconciling them\n")
+	fmt.Fprintf(w, "  inspect: Print statistics of the input files\n")
 	fmt.Fprintf(w, "Options:\n")
 	flag.PrintDefaults()
 }
@@ -33,8 +52,36 @@ func fatalWithUsage(format string, msg ...any) {
 }
 
 func main() {
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "match":
+			runMatch(os.Args[2:])
+			return
+		case "resolve":
+			runResolve(os.Args[2:])
+			return
+		case "watch":
+			runWatch(os.Args[2:])
+			return
+		case "validate":
+			runValidate(os.Args[2:])
+			return
+		case "inspect":
+			runInspect(os.Args[2:])
+			return
+		}
+	}
+
 	flag.Usage = usage
 	help := flag.Bool("h", false, "show help")
+	profilePath := flag.String("profile", "", "reconciliation profile json file, configuring statement formats and matching rules")
+	showMatched := flag.Bool("show-matched", false, "show matched pairs along with the rule that matched them")
+	reportMode := flag.String("report", "reconciliation", "report mode, either reconciliation or balance")
+	openingBalanceArg := flag.String("opening-balance", "0", "opening balance of the balance report")
+	storePath := flag.String("store", "", "store json file persisting runs and open items, which are carried forward to the next runs")
+	dedupe := flag.Bool("dedupe", false, "drop rows exactly the same as a previous row of the same identifier within the same file, keeping the first one")
+	incremental := flag.Bool("incremental", false, "only ingest transactions and statement files not ingested by the previous runs, requires -store")
+	runID := flag.String("run-id", "", "run id on the store and the result database, replacing the results of the same run id on the result database. Generated when empty")
 	flag.Parse()
 
 	if *help {
@@ -42,15 +89,21 @@ func main() {
 		return
 	}
 
-	if len(os.Args) != 5 {
+	args := flag.Args()
+	if len(args) != 4 {
 		fatalWithUsage("ERROR: Need exactly 4 arguments!")
 	}
 
-	transactionFile := os.Args[1]
-	statementFileArg := os.Args[2]
-	statementFiles := strings.Split(statementFileArg, ",")
-	startDateArg := os.Args[3]
-	endDateArg := os.Args[4]
+	transactionFile := args[0]
+	statementFileArg := args[1]
+	startDateArg := args[2]
+	endDateArg := args[3]
+
+	// zip archives are reconciled as their members
+	statementFiles, err := input.Expand(strings.Split(statementFileArg, ",")...)
+	if err != nil {
+		fatalWithUsage("ERROR: %v", err)
+	}
 
 	startDate, err := time.Parse(time.DateOnly, startDateArg)
 	if err != nil {
@@ -62,28 +115,355 @@ func main() {
 		fatalWithUsage("ERROR: end date wrong format: %v", err)
 	}
 
-	result, err := process(transactionFile, statementFiles, startDate, endDate)
-	// result, err := processConcurrent(transactionFile, statementFiles, startDate, endDate)
+	profile, err := config.Load(*profilePath)
 	if err != nil {
-		log.Fatalf("ERROR: process: %v", err)
+		fatalWithUsage("ERROR: load profile: %v", err)
+	}
+
+	switch *reportMode {
+	case "reconciliation":
+	case "balance":
+		openingBalance, err := decimal.Parse(*openingBalanceArg)
+		if err != nil {
+			fatalWithUsage("ERROR: opening balance wrong format: %v", err)
+		}
+
+		report, err := processBalance(profile, transactionFile, statementFiles, openingBalance, startDate, endDate, *dedupe)
+		if err != nil {
+			log.Fatalf("ERROR: process: %v", err)
+		}
+
+		printBalance(os.Stdout, report)
+		return
+	default:
+		fatalWithUsage("ERROR: unknown report mode %q", *reportMode)
+	}
+
+	if *incremental && *storePath == "" {
+		fatalWithUsage("ERROR: -incremental requires -store")
 	}
 
-	printReconciliation(result)
+	opts := reconcileOptions{
+		profile:     profile,
+		storePath:   *storePath,
+		incremental: *incremental,
+		showMatched: *showMatched,
+		dedupe:      *dedupe,
+		runID:       *runID,
+	}
+	if err := reconcile(os.Stdout, opts, transactionFile, statementFiles, startDate, endDate); err != nil {
+		log.Fatalf("ERROR: %v", err)
+	}
+}
+
+// reconcileOptions configures the reconciliation runs
+type reconcileOptions struct {
+	profile     config.Profile
+	storePath   string
+	incremental bool
+	showMatched bool
+	// dedupe drops the rows exactly the same as a previous row of the
+	// same identifier within the same file
+	dedupe bool
+	// runID identifies the run on the store and the result database,
+	// generated when empty
+	runID string
 }
 
-func printReconciliation(result reconciliation.Result) {
-	unmatchedCount := result.Processed - result.Match
+// reconcile runs the reconciliation, recording the run on the store when
+// it's set, and writes the report into out
+func reconcile(out io.Writer, opts reconcileOptions, transactionFile string, statementFiles []string, startDate, endDate time.Time) error {
+	var st *store.Store
+	var checksums map[string]string
+	if opts.storePath != "" {
+		var err error
+		st, err = store.Open(opts.storePath)
+		if err != nil {
+			return fmt.Errorf("open store: %w", err)
+		}
 
-	fmt.Printf("Processed Transactions: %d\n", result.Processed)
-	fmt.Printf("Matched Transactions: %d\n", result.Match)
-	fmt.Printf("Unmatched Transactions: %d\n", result.Processed-result.Match)
+		var skipped map[string]string
+		statementFiles, checksums, skipped, err = ingestStatementFiles(st, statementFiles, opts.incremental)
+		if err != nil {
+			return fmt.Errorf("ingest statement files: %w", err)
+		}
+		for _, fileName := range slices.Sorted(maps.Keys(skipped)) {
+			fmt.Fprintf(out, "Skipped %s: already ingested by run %s\n", fileName, skipped[fileName])
+		}
+	}
+
+	result, dups, err := process(opts, st, transactionFile, statementFiles, startDate, endDate)
+	// result, dups, err := processConcurrent(opts, st, transactionFile, statementFiles, startDate, endDate)
+	if err != nil {
+		return fmt.Errorf("process: %w", err)
+	}
+
+	now := time.Now()
+	runID := opts.runID
+	if runID == "" {
+		runID = store.NewRunID(now)
+	}
+
+	// the store is saved before the result database, since the rows of the
+	// result database are replaced when the same run id is written again
+	var trail []store.Decision
+	if st != nil {
+		run := st.Record(store.Run{
+			ID:              runID,
+			At:              now,
+			StartDate:       startDate,
+			EndDate:         endDate,
+			TransactionFile: transactionFile,
+			StatementFiles:  statementFiles,
+			Checksums:       checksums,
+		}, result)
+		if err := st.Save(); err != nil {
+			return fmt.Errorf("save store: %w", err)
+		}
+
+		printRun(out, run)
+		trail = st.AuditTrail(result)
+	}
+
+	if db := opts.profile.ResultDatabase; db != nil {
+		err := writeResult(*db, sink.Run{
+			ID:                runID,
+			At:                now,
+			StartDate:         startDate,
+			EndDate:           endDate,
+			TransactionSource: transactionFile,
+			StatementSources:  statementFiles,
+		}, result)
+		if err != nil {
+			return fmt.Errorf("write result of run %s: %w", runID, err)
+		}
+	}
+
+	printReconciliation(out, result, endDate, trail)
+	printDuplicates(out, dups, opts.dedupe)
+	printOverlaps(out, result)
+	printPending(out, result)
+	if opts.showMatched {
+		printMatched(out, result)
+	}
+
+	return nil
+}
+
+func printRun(out io.Writer, run store.Run) {
+	fmt.Fprintf(out, "Run: %s\n", run.ID)
+	fmt.Fprintf(out, "Carried Forward Items: %d\n", run.Carried)
+	fmt.Fprintf(out, "Cleared Items: %d\n", run.Cleared)
+}
+
+func printDuplicates(out io.Writer, report duplicates.Report, dedupe bool) {
+	if len(report) == 0 {
+		return
+	}
+
+	action := "kept"
+	if dedupe {
+		// conflicting rows are kept, so they end up matched or unmatched
+		action = fmt.Sprintf("%d exact copies dropped, conflicting rows kept", report.CopyCount())
+	}
+
+	fmt.Fprintln(out, "------------------")
+	fmt.Fprintln(out, "Duplicates:")
+
+	w := tabwriter.NewWriter(out, 4, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "\nDuplicate Rows: %d (%s)\n\n", report.Count(), action)
+	fmt.Fprintln(w, "\tFile\tID\tKind\tLines")
+	for _, fileName := range slices.Sorted(maps.Keys(report)) {
+		for _, d := range report[fileName] {
+			kind := "conflicting rows"
+			if d.Exact {
+				kind = "exact rows"
+			}
+
+			lines := make([]string, len(d.Lines))
+			for i, line := range d.Lines {
+				lines[i] = strconv.Itoa(line)
+			}
+			fmt.Fprintf(w, "\t%v\t%v\t%v\t%v\n", fileName, d.ID, kind, strings.Join(lines, ", "))
+		}
+	}
+	w.Flush()
+}
+
+func printOverlaps(out io.Writer, result reconciliation.Result) {
+	if len(result.Overlaps) == 0 {
+		return
+	}
+
+	fmt.Fprintln(out, "------------------")
+	fmt.Fprintln(out, "Overlapping Statements:")
+
+	w := tabwriter.NewWriter(out, 4, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "\nOverlapping Lines: %d\n\n", len(result.Overlaps))
+	fmt.Fprintln(w, "\tAccount\tUniqueIdentifier\tKept From\tDropped From")
+	for _, o := range result.Overlaps {
+		fmt.Fprintf(w, "\t%v\t%v\t%v\t%v\n", o.Account, o.UniqueIdentifier, o.Source, strings.Join(o.Dropped, ", "))
+	}
+	w.Flush()
+}
+
+func printPending(out io.Writer, result reconciliation.Result) {
+	if len(result.Pending) == 0 {
+		return
+	}
+
+	count := 0
+	for _, stmts := range result.Pending {
+		count += len(stmts)
+	}
+
+	fmt.Fprintln(out, "------------------")
+	fmt.Fprintln(out, "Pending Statements:")
+
+	w := tabwriter.NewWriter(out, 4, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "\nPending Lines: %d (not matched until booked)\n\n", count)
+	fmt.Fprintln(w, "\tFile\tUniqueIdentifier\tType\tAmount\tDate")
+	for _, fileName := range slices.Sorted(maps.Keys(result.Pending)) {
+		for _, s := range result.Pending[fileName] {
+			fmt.Fprintf(w, "\t%v\t%v\t%v\t%v\t%v\n", fileName, s.UniqueIdentifier, s.BookingType(), s.Amount, s.Date.Format(time.DateOnly))
+		}
+	}
+	w.Flush()
+}
+
+func printBalance(out io.Writer, report balance.Report) {
+	fmt.Fprintf(out, "Opening Balance: %v\n", report.Opening)
+	if report.Diverged() {
+		fmt.Fprintf(out, "First Divergence: %v\n", report.FirstDivergence.Format(time.DateOnly))
+	} else {
+		fmt.Fprintln(out, "First Divergence: -")
+	}
+
+	w := tabwriter.NewWriter(out, 4, 0, 2, ' ', 0)
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "\tDate\tSystem Movement\tSystem Balance\tBank Movement\tBank Balance\tDifference")
+	for _, d := range report.Days {
+		fmt.Fprintf(w, "\t%v\t%v\t%v\t%v\t%v\t%v\n", d.Date.Format(time.DateOnly), d.SystemMovement, d.SystemBalance, d.BankMovement, d.BankBalance, d.Difference)
+	}
+	w.Flush()
+}
+
+func printMatched(out io.Writer, result reconciliation.Result) {
+	if len(result.Matched) == 0 {
+		return
+	}
+
+	fmt.Fprintln(out, "------------------")
+	fmt.Fprintln(out, "Matched Details:")
+
+	w := tabwriter.NewWriter(out, 4, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "\nMatched Pairs: %d\n\n", len(result.Matched))
+	fmt.Fprintln(w, "\tRule\tTrxID\tFile\tUniqueIdentifier\tAmount\tDate")
+	for _, m := range result.Matched {
+		fmt.Fprintf(w, "\t%v\t%v\t%v\t%v\t%v\t%v\n", m.Rule, m.Transaction.TrxID, m.Statement.Name, m.Statement.Statement.UniqueIdentifier, m.Statement.Statement.Amount, m.Statement.Statement.Date)
+	}
+	w.Flush()
+}
+
+// itemStatus returns the last decision kind of the items on the trail,
+// keyed by TrxID for transactions, and by source and UniqueIdentifier for
+// statements
+func itemStatus(trail []store.Decision) (map[string]string, map[[2]string]string) {
+	trxStatus := make(map[string]string)
+	stmtStatus := make(map[[2]string]string)
+	for _, d := range trail {
+		if d.TrxID != "" {
+			trxStatus[d.TrxID] = d.Kind
+		}
+		if d.Source != "" {
+			stmtStatus[[2]string{d.Source, d.UniqueIdentifier}] = d.Kind
+		}
+	}
+	return trxStatus, stmtStatus
+}
+
+func printReconciliation(out io.Writer, result reconciliation.Result, asOf time.Time, trail []store.Decision) {
+	unmatchedCount := result.UnmatchedCount()
+
+	fmt.Fprintf(out, "Processed Transactions: %d\n", result.Processed)
+	fmt.Fprintf(out, "Matched Transactions: %d\n", result.Match)
+	for _, rule := range slices.Sorted(maps.Keys(result.RuleMatches)) {
+		fmt.Fprintf(out, "  by %s: %d\n", rule, result.RuleMatches[rule])
+	}
+	if len(result.Netted) > 0 {
+		fmt.Fprintf(out, "Netted Transactions: %d\n", 2*len(result.Netted))
+	}
+	if count := result.WrittenOffCount(); count > 0 {
+		fmt.Fprintf(out, "Written Off Items: %d\n", count)
+	}
+	fmt.Fprintf(out, "Unmatched Transactions: %d\n", unmatchedCount)
+
+	byType := result.ByType()
+	if len(byType) > 0 {
+		w := tabwriter.NewWriter(out, 4, 0, 2, ' ', 0)
+		fmt.Fprintf(w, "\nSummary by Type:\n\n")
+		fmt.Fprintln(w, "\tType\tMatched Transactions\tUnmatched Transactions\tUnmatched Statements")
+		for _, trxType := range slices.Sorted(maps.Keys(byType)) {
+			s := byType[trxType]
+			fmt.Fprintf(w, "\t%v\t%d\t%d\t%d\n", trxType, s.MatchedTransactions, s.UnmatchedTransactions, s.UnmatchedStatements)
+		}
+		w.Flush()
+	}
+
+	if len(result.BalanceChecks) > 0 {
+		fmt.Fprintln(out, "------------------")
+		fmt.Fprintln(out, "Balance Checks:")
+
+		w := tabwriter.NewWriter(out, 4, 0, 2, ' ', 0)
+		fmt.Fprintln(w)
+		fmt.Fprintln(w, "\tFile\tOpening\tLines Total\tClosing\tDifference\tStatus")
+		for _, fileName := range slices.Sorted(maps.Keys(result.BalanceChecks)) {
+			c := result.BalanceChecks[fileName]
+			status := "OK"
+			if c.Err != nil {
+				status = fmt.Sprintf("ERROR: %v", c.Err)
+			} else if c.IsBreak() {
+				status = "BREAK"
+			}
+			fmt.Fprintf(w, "\t%v\t%v\t%v\t%v\t%v\t%v\n", fileName, c.Opening, c.Total, c.Closing, c.Difference, status)
+		}
+		w.Flush()
+	}
+
+	if len(result.Netted) > 0 {
+		fmt.Fprintln(out, "------------------")
+		fmt.Fprintln(out, "Netted Details:")
+
+		w := tabwriter.NewWriter(out, 4, 0, 2, ' ', 0)
+		fmt.Fprintf(w, "\nNetted Pairs: %d\n\n", len(result.Netted))
+		fmt.Fprintln(w, "\tRule\tOriginal TrxID\tReversal TrxID\tAmount\tOriginal Time\tReversal Time")
+		for _, p := range result.Netted {
+			fmt.Fprintf(w, "\t%v\t%v\t%v\t%v\t%v\t%v\n", p.Rule, p.Original.TrxID, p.Reversal.TrxID, p.Original.Amount, p.Original.TransactionTime, p.Reversal.TransactionTime)
+		}
+		w.Flush()
+	}
+
+	if len(trail) > 0 {
+		fmt.Fprintln(out, "------------------")
+		fmt.Fprintln(out, "Audit Trail:")
+
+		w := tabwriter.NewWriter(out, 4, 0, 2, ' ', 0)
+		fmt.Fprintln(w)
+		fmt.Fprintln(w, "\tAt\tDecision\tTrxID\tFile\tUniqueIdentifier\tUser\tComment")
+		for _, d := range trail {
+			fmt.Fprintf(w, "\t%v\t%v\t%v\t%v\t%v\t%v\t%v\n", d.At.Format(time.DateTime), d.Kind, d.TrxID, d.Source, d.UniqueIdentifier, d.User, d.Comment)
+		}
+		w.Flush()
+	}
 
 	if unmatchedCount == 0 {
 		return
 	}
 
-	fmt.Println("------------------")
-	fmt.Println("Unmatched Details:")
+	trxStatus, stmtStatus := itemStatus(trail)
+
+	fmt.Fprintln(out, "------------------")
+	fmt.Fprintln(out, "Unmatched Details:")
 
 	trxCount := len(result.Unmatched.Transactions)
 
@@ -95,25 +475,27 @@ func printReconciliation(result reconciliation.Result) {
 	})
 
 	if trxCount > 0 {
-		w := tabwriter.NewWriter(os.Stdout, 4, 0, 2, ' ', 0)
+		w := tabwriter.NewWriter(out, 4, 0, 2, ' ', 0)
 		fmt.Fprintf(w, "\nUnmatched Transactions: %d\n\n", trxCount)
-		fmt.Fprintln(w, "\tTrxID\tType\tAmount\tTransactionTime")
-		for _, t := range result.Unmatched.Transactions {
-			fmt.Fprintf(w, "\t%v\t%v\t%v\t%v\n", t.TrxID, t.Type, t.Amount, t.TransactionTime)
+		fmt.Fprintln(w, "\tTrxID\tType\tAmount\tTransactionTime\tAge (days)\tStatus")
+		trxs := slices.SortedStableFunc(slices.Values(result.Unmatched.Transactions), func(a, b transactions.Transaction) int {
+			return cmp.Compare(a.Type, b.Type)
+		})
+		for _, t := range trxs {
+			fmt.Fprintf(w, "\t%v\t%v\t%v\t%v\t%d\t%v\n", t.TrxID, t.Type, t.Amount, t.TransactionTime, reconciliation.DaysBetween(t.TransactionTime, asOf), trxStatus[t.TrxID])
 		}
 		w.Flush()
 	}
 
 	if stmtCount > 0 {
-		w := tabwriter.NewWriter(os.Stdout, 4, 0, 2, ' ', 0)
+		w := tabwriter.NewWriter(out, 4, 0, 2, ' ', 0)
 		fmt.Fprintf(w, "\nUnmatched Statements: %d\n\n", stmtCount)
-		fmt.Fprintln(w, "\tUniqueIdentifier\tAmount\tDate")
-		stmtIterator(func(stmts []statements.Statement) bool {
-			for _, s := range stmts {
-				fmt.Fprintf(w, "\t%v\t%v\t%v\n", s.UniqueIdentifier, s.Amount, s.Date)
+		fmt.Fprintln(w, "\tFile\tUniqueIdentifier\tType\tAmount\tDate\tAge (days)\tStatus")
+		for _, fileName := range slices.Sorted(maps.Keys(result.Unmatched.Statements)) {
+			for _, s := range result.Unmatched.Statements[fileName] {
+				fmt.Fprintf(w, "\t%v\t%v\t%v\t%v\t%v\t%d\t%v\n", fileName, s.UniqueIdentifier, s.BookingType(), s.Amount, s.Date, reconciliation.DaysBetween(s.Date, asOf), stmtStatus[[2]string{fileName, s.UniqueIdentifier}])
 			}
-			return true
-		})
+		}
 		w.Flush()
 	}
 }
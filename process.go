@@ -1,65 +1,151 @@
 package main
 
 import (
-	"os"
-	"path/filepath"
+	"context"
+	"errors"
+	"io"
+	"strings"
 	"time"
 
-	"github.com/rickyson96/amartha-reconciliation-service/internal/parsers/statements"
-	"github.com/rickyson96/amartha-reconciliation-service/internal/parsers/transactions"
+	"github.com/rickyson96/amartha-reconciliation-service/internal/config"
+	"github.com/rickyson96/amartha-reconciliation-service/internal/duplicates"
+	"github.com/rickyson96/amartha-reconciliation-service/internal/input"
 	"github.com/rickyson96/amartha-reconciliation-service/internal/processes/reconciliation"
+	"github.com/rickyson96/amartha-reconciliation-service/internal/sink"
+	"github.com/rickyson96/amartha-reconciliation-service/internal/store"
+	"github.com/rickyson96/amartha-reconciliation-service/service"
 )
 
-func parseTransactions(fileName string, startDate, endDate time.Time) ([]transactions.Transaction, error) {
-	filePath, err := filepath.Abs(fileName)
-	if err != nil {
-		return nil, err
-	}
-	file, err := os.Open(filePath)
-	if err != nil {
-		return nil, err
-	}
+// inputFiles are the opened input files, keyed by the file name
+type inputFiles struct {
+	service.Inputs
+	closers []io.Closer
+}
 
-	transactionParser := transactions.NewCSVParser(file, startDate, endDate)
+// transactionDatabase is the transaction file argument querying the
+// transaction database of the profile
+const transactionDatabase = "db"
 
-	return transactionParser.Parse()
-}
+var errMissingTransactionDatabase = errors.New("db requires transactionDatabase of the profile")
+
+// noTransactionFile reconciles the statement files against the open
+// transactions carried by the store only, see [watchCycle]
+const noTransactionFile = ""
+
+// openInputs opens the input files, see [input.Open]. The transaction
+// database of the profile is opened instead of the transaction file on
+// [transactionDatabase], while nothing is opened on [noTransactionFile].
+func openInputs(profile config.Profile, transactionFile string, statementFiles []string) (*inputFiles, error) {
+	files := &inputFiles{Inputs: service.Inputs{
+		TransactionSource: transactionFile,
+		Statements:        make(map[string]io.Reader),
+	}}
 
-func parseStatements(files []string, startDate, endDate time.Time) (map[string][]statements.Statement, error) {
-	statementsMap := make(map[string][]statements.Statement)
-	for _, fileName := range files {
-		filePath, err := filepath.Abs(fileName)
+	switch transactionFile {
+	case noTransactionFile:
+		files.Transactions = strings.NewReader("")
+	case transactionDatabase:
+		if profile.TransactionDatabase == nil {
+			return nil, errMissingTransactionDatabase
+		}
+		db, err := profile.TransactionDatabase.Open()
 		if err != nil {
 			return nil, err
 		}
-		file, err := os.Open(filePath)
+		files.TransactionDatabase = db
+		files.closers = append(files.closers, db)
+	default:
+		trxFile, err := input.Open(transactionFile)
 		if err != nil {
 			return nil, err
 		}
+		files.Transactions = trxFile
+		files.closers = append(files.closers, trxFile)
+	}
 
-		statementParser := statements.NewCSVParser(file, startDate, endDate)
-
-		stmts, err := statementParser.Parse()
+	for _, fileName := range statementFiles {
+		file, err := input.Open(fileName)
 		if err != nil {
+			files.Close()
 			return nil, err
 		}
+		files.Statements[fileName] = file
+		files.closers = append(files.closers, file)
+	}
 
-		statementsMap[fileName] = stmts
+	return files, nil
+}
+
+func (f *inputFiles) Close() error {
+	var errs []error
+	for _, c := range f.closers {
+		errs = append(errs, c.Close())
 	}
+	return errors.Join(errs...)
+}
 
-	return statementsMap, nil
+// ingestStatementFiles computes the checksum of the statement files. On
+// incremental mode, files already ingested by the previous runs are
+// skipped, keyed by the run that ingested them.
+func ingestStatementFiles(st *store.Store, statementFiles []string, incremental bool) ([]string, map[string]string, map[string]string, error) {
+	var files []string
+	checksums := make(map[string]string)
+	skipped := make(map[string]string)
+	for _, fileName := range statementFiles {
+		checksum, err := store.Checksum(fileName)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+
+		if run, ok := st.IngestedBy(checksum); ok && incremental {
+			skipped[fileName] = run.ID
+			continue
+		}
+
+		files = append(files, fileName)
+		checksums[fileName] = checksum
+	}
+
+	return files, checksums, skipped, nil
 }
 
-func process(transactionFile string, statementFiles []string, startDate, endDate time.Time) (reconciliation.Result, error) {
-	trxs, err := parseTransactions(transactionFile, startDate, endDate)
+// writeResult writes the result of the run into the result database
+func writeResult(database config.Database, run sink.Run, result reconciliation.Result) error {
+	db, err := database.Open()
 	if err != nil {
-		return reconciliation.Result{}, err
+		return err
 	}
+	defer db.Close()
+
+	ctx := context.Background()
+	s, err := sink.New(ctx, db, database.Driver)
+	if err != nil {
+		return err
+	}
+	return s.Write(ctx, run, result)
+}
+
+// serviceOptions builds the options of the service
+func (o reconcileOptions) serviceOptions(st *store.Store, startDate, endDate time.Time) service.Options {
+	return service.Options{
+		Profile:     o.profile,
+		StartDate:   startDate,
+		EndDate:     endDate,
+		Dedupe:      o.dedupe,
+		Store:       st,
+		Incremental: o.incremental,
+	}
+}
 
-	stmts, err := parseStatements(statementFiles, startDate, endDate)
+// process reconciles the files, carrying forward the open items and
+// applying the decisions of the store when it's given. On incremental
+// mode, only transactions not ingested by the previous runs are read.
+func process(opts reconcileOptions, st *store.Store, transactionFile string, statementFiles []string, startDate, endDate time.Time) (reconciliation.Result, duplicates.Report, error) {
+	files, err := openInputs(opts.profile, transactionFile, statementFiles)
 	if err != nil {
-		return reconciliation.Result{}, err
+		return reconciliation.Result{}, nil, err
 	}
+	defer files.Close()
 
-	return reconciliation.Process(trxs, stmts), nil
+	return service.Reconcile(opts.serviceOptions(st, startDate, endDate), files.Inputs)
 }
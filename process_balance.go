@@ -0,0 +1,35 @@
+package main
+
+import (
+	"time"
+
+	"github.com/govalues/decimal"
+	"github.com/rickyson96/amartha-reconciliation-service/internal/config"
+	"github.com/rickyson96/amartha-reconciliation-service/internal/processes/balance"
+	"github.com/rickyson96/amartha-reconciliation-service/internal/processes/reconciliation"
+	"github.com/rickyson96/amartha-reconciliation-service/service"
+)
+
+// processBalance computes the running balances of the files, where the
+// statement lines are deduplicated the same way as the reconciliation,
+// so the overlapping files of an account don't count the lines twice
+func processBalance(profile config.Profile, transactionFile string, statementFiles []string, opening decimal.Decimal, startDate, endDate time.Time, dedupe bool) (balance.Report, error) {
+	files, err := openInputs(profile, transactionFile, statementFiles)
+	if err != nil {
+		return balance.Report{}, err
+	}
+	defer files.Close()
+
+	transactionParser, err := service.NewTransactionReader(profile, files.Inputs, startDate, endDate)
+	if err != nil {
+		return balance.Report{}, err
+	}
+	defer transactionParser.Close()
+	statementParser, err := service.NewStatementReader(profile, files.Statements, startDate, endDate, dedupe)
+	if err != nil {
+		return balance.Report{}, err
+	}
+	stmtReader := reconciliation.OverlapReader(statementParser.Read, profile.StatementAccounts(statementFiles))
+
+	return balance.Process(transactionParser.Read, stmtReader, opening, startDate, endDate)
+}
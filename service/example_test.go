@@ -0,0 +1,55 @@
+package service_test
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/govalues/decimal"
+	"github.com/rickyson96/amartha-reconciliation-service/service"
+)
+
+// The profile is built in code, without the profile file
+func ExampleReconcile() {
+	bank := service.NewStatementFormat("bank1", "bank1*")
+	bank.TypeColumn = 3
+	bank.FieldPerRow = 4
+
+	profile := service.DefaultProfile()
+	profile.StatementFormats = []service.StatementFormat{bank}
+	profile.Matchers = []service.Matcher{
+		{Strategy: "key"},
+		{Strategy: "tolerance", Tolerance: decimal.MustNew(50, 2)},
+	}
+	profile.Netting = &service.Netting{ByReference: true}
+
+	opts := service.Options{
+		Profile:   profile,
+		StartDate: time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC),
+		EndDate:   time.Date(2025, 3, 31, 0, 0, 0, 0, time.UTC),
+	}
+	inputs := service.Inputs{
+		TransactionSource: "ledger",
+		Transactions: strings.NewReader("trxID,amount,type,transactionTime\n" +
+			"1,100.00,CREDIT,2025-03-10 10:00:00\n" +
+			"2,50.00,DEBIT,2025-03-11 10:00:00\n"),
+		Statements: map[string]io.Reader{
+			"bank1.csv": strings.NewReader("uniqueIdentifier,amount,date,type\n" +
+				"a1,100.00,2025-03-10,CR\n" +
+				"a2,50.30,2025-03-11,DB\n"),
+		},
+	}
+
+	result, _, err := service.Reconcile(opts, inputs)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	for _, m := range result.Matched {
+		fmt.Println(m.Transaction.TrxID, m.Statement.Statement.UniqueIdentifier, m.Rule)
+	}
+	// Output:
+	// 1 a1 key
+	// 2 a2 tolerance
+}
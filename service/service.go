@@ -0,0 +1,257 @@
+// Package service reconciles the inputs given as readers, so that the
+// reconciliation can be embedded, e.g. in pipelines, regardless of where
+// the inputs come from.
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"time"
+
+	"github.com/rickyson96/amartha-reconciliation-service/internal/config"
+	csvparser "github.com/rickyson96/amartha-reconciliation-service/internal/csv_parser"
+	"github.com/rickyson96/amartha-reconciliation-service/internal/duplicates"
+	"github.com/rickyson96/amartha-reconciliation-service/internal/parsers/statements"
+	"github.com/rickyson96/amartha-reconciliation-service/internal/parsers/transactions"
+	"github.com/rickyson96/amartha-reconciliation-service/internal/processes/reconciliation"
+	"github.com/rickyson96/amartha-reconciliation-service/internal/store"
+)
+
+// The types of the reconciliation are aliased, as they live in the
+// internal packages, so that the embedding modules can name them. The
+// parts of the profile are aliased too, so the profile can be built in
+// code besides being loaded, see [DefaultProfile].
+type (
+	Profile             = config.Profile
+	TransactionFormat   = config.TransactionFormat
+	StatementFormat     = config.StatementFormat
+	Matcher             = config.Matcher
+	Netting             = config.Netting
+	Balance             = statements.Balance
+	Database            = config.Database
+	TransactionDatabase = config.TransactionDatabase
+	Store               = store.Store
+	Result              = reconciliation.Result
+	Report              = duplicates.Report
+)
+
+// LoadProfile loads the reconciliation profile json file, see
+// [config.Load]
+func LoadProfile(path string) (Profile, error) {
+	return config.Load(path)
+}
+
+// ParseProfile parses the profile json, as written on the profile file
+func ParseProfile(data []byte) (Profile, error) {
+	return config.Parse(data)
+}
+
+// DefaultProfile returns the profile used without a profile file, which
+// reads the transactions and statements of the default layout and
+// matches them by their key
+func DefaultProfile() Profile {
+	return config.Default()
+}
+
+// NewStatementFormat returns the format of the statement files whose base
+// name matches the pattern, starting from the default layout
+func NewStatementFormat(name, pattern string) StatementFormat {
+	return StatementFormat{Name: name, Pattern: pattern, Format: statements.DefaultFormat}
+}
+
+// OpenStore opens the store json file, see [store.Open]
+func OpenStore(path string) (*Store, error) {
+	return store.Open(path)
+}
+
+// Options configures the reconciliation
+type Options struct {
+	Profile   Profile
+	StartDate time.Time
+	EndDate   time.Time
+	// Dedupe drops the rows exactly the same as a previous row of the
+	// same identifier within the same source, conflicting rows are kept
+	Dedupe bool
+	// Store carries forward the open items and applies the decisions,
+	// when it's set
+	Store *Store
+	// Incremental only reads the transactions not ingested by the
+	// previous runs of the store
+	Incremental bool
+}
+
+// Inputs are the csv to reconcile, keyed by their source name. The name
+// is used for the statement format, balance and account of the profile.
+// Statements are read following the name order.
+type Inputs struct {
+	TransactionSource string
+	Transactions      io.Reader
+	// TransactionDatabase is queried with the transaction database query
+	// of the profile instead of reading Transactions, when it's set
+	TransactionDatabase *sql.DB
+	Statements          map[string]io.Reader
+}
+
+var ErrMissingTransactionQuery = errors.New("transaction database requires transactionDatabase query of the profile")
+
+// Reconcile reads all the inputs into the memory and reconciles them.
+func Reconcile(opts Options, in Inputs) (Result, Report, error) {
+	reconOpts, err := reconciliationOptions(opts.Profile, slices.Collect(maps.Keys(in.Statements)))
+	if err != nil {
+		return reconciliation.Result{}, nil, err
+	}
+
+	trxs, trxDups, err := parseTransactions(opts, in)
+	if err != nil {
+		return reconciliation.Result{}, nil, err
+	}
+
+	stmts, dups, balances, err := parseStatements(opts.Profile, in.Statements, opts.StartDate, opts.EndDate, opts.Dedupe)
+	if err != nil {
+		return reconciliation.Result{}, nil, err
+	}
+	reconOpts.FileBalances = func() map[string]statements.Balance { return balances }
+	if len(trxDups) > 0 {
+		dups[in.TransactionSource] = trxDups
+	}
+
+	if st := opts.Store; st != nil {
+		if opts.Incremental {
+			trxs = st.NewTransactions(trxs)
+		}
+		trxs, reconOpts.Carried = st.CarryForward(trxs, stmts)
+		reconOpts = st.Apply(reconOpts)
+	}
+
+	sources := slices.Collect(maps.Keys(stmts))
+	sources = slices.AppendSeq(sources, maps.Keys(reconOpts.Carried))
+	reconOpts.Accounts = opts.Profile.StatementAccounts(sources)
+
+	return reconciliation.Process(trxs, stmts, reconOpts), dups, nil
+}
+
+// NewTransactionReader reads the transactions out of the transaction
+// database when it's given, or out of the transaction file otherwise.
+// The reader should be closed to release the database rows.
+func NewTransactionReader(profile Profile, in Inputs, startDate, endDate time.Time) (*csvparser.CSVParser[transactions.Transaction], error) {
+	format := profile.TransactionFormat.Format
+	if in.TransactionDatabase == nil {
+		return transactions.NewReader(in.Transactions, startDate, endDate, format)
+	}
+
+	if profile.TransactionDatabase == nil || profile.TransactionDatabase.Query == "" {
+		return nil, ErrMissingTransactionQuery
+	}
+	return transactions.NewDatabaseReader(context.Background(), in.TransactionDatabase, profile.TransactionDatabase.Driver, profile.TransactionDatabase.Query, startDate, endDate, format)
+}
+
+func parseTransactions(opts Options, in Inputs) ([]transactions.Transaction, []duplicates.Duplicate, error) {
+	transactionParser, err := NewTransactionReader(opts.Profile, in, opts.StartDate, opts.EndDate)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer transactionParser.Close()
+	detector := duplicates.NewDetector(transactionID)
+
+	trxs, err := readAll(detector.Reader(transactionParser.ReadRow, opts.Dedupe))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return trxs, detector.Duplicates(), nil
+}
+
+// parseStatements reads the statement sources, along with the balance
+// carried by the files themselves
+func parseStatements(profile config.Profile, files map[string]io.Reader, startDate, endDate time.Time, dedupe bool) (map[string][]statements.Statement, duplicates.Report, map[string]statements.Balance, error) {
+	statementsMap := make(map[string][]statements.Statement)
+	report := make(duplicates.Report)
+	balances := make(map[string]statements.Balance)
+	for _, source := range slices.Sorted(maps.Keys(files)) {
+		statementParser, err := statements.NewReader(source, files[source], startDate, endDate, profile.StatementFormat(source))
+		if err != nil {
+			return nil, nil, nil, fmt.Errorf("%s: %w", source, err)
+		}
+		detector := duplicates.NewDetector(statementID)
+
+		stmts, err := readAll(detector.Reader(statementParser.ReadRow, dedupe))
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		if balance, ok := fileBalance(statementParser); ok {
+			balances[source] = balance
+		}
+
+		statementsMap[source] = stmts
+		if dups := detector.Duplicates(); len(dups) > 0 {
+			report[source] = dups
+		}
+	}
+
+	return statementsMap, report, balances, nil
+}
+
+// fileBalance returns the balance carried by the file, see
+// [statements.BalanceReader]
+func fileBalance(reader statements.Reader) (statements.Balance, bool) {
+	if r, ok := reader.(statements.BalanceReader); ok {
+		return r.Balance()
+	}
+	return statements.Balance{}, false
+}
+
+// transactionID and statementID identify the rows within a file for
+// duplicate detection
+func transactionID(trx transactions.Transaction) string {
+	return trx.TrxID
+}
+
+func statementID(stmt statements.Statement) string {
+	return stmt.UniqueIdentifier
+}
+
+// readAll reads the reader until [io.EOF]
+func readAll[T any](read func() (T, error)) ([]T, error) {
+	var result []T
+	for {
+		data, err := read()
+		if errors.Is(err, io.EOF) {
+			return result, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, data)
+	}
+}
+
+// reconciliationOptions builds the options out of the profile, along
+// with the statement sources declared balance
+func reconciliationOptions(profile config.Profile, sources []string) (reconciliation.Options, error) {
+	opts, err := profile.ReconciliationOptions(sources)
+	if err != nil {
+		return reconciliation.Options{}, err
+	}
+
+	for _, source := range sources {
+		balance, ok, err := profile.StatementBalance(source)
+		if err != nil {
+			return reconciliation.Options{}, err
+		}
+		if !ok {
+			continue
+		}
+
+		if opts.Balances == nil {
+			opts.Balances = make(map[string]statements.Balance)
+		}
+		opts.Balances[source] = balance
+	}
+
+	return opts, nil
+}
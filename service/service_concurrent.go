@@ -0,0 +1,143 @@
+package service
+
+import (
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"time"
+
+	"github.com/rickyson96/amartha-reconciliation-service/internal/duplicates"
+	"github.com/rickyson96/amartha-reconciliation-service/internal/parsers/statements"
+	"github.com/rickyson96/amartha-reconciliation-service/internal/parsers/transactions"
+	"github.com/rickyson96/amartha-reconciliation-service/internal/processes/reconciliation"
+)
+
+// StatementReader reads through the statement sources one after another,
+// following the name order
+type StatementReader struct {
+	filesWithReader map[string]func() (statements.Statement, error)
+	detectors       map[string]*duplicates.Detector[statements.Statement]
+	parsers         map[string]statements.Reader
+	files           []string
+	readCount       int
+}
+
+func NewStatementReader(profile Profile, files map[string]io.Reader, startDate, endDate time.Time, dedupe bool) (*StatementReader, error) {
+	reader := StatementReader{
+		filesWithReader: map[string]func() (statements.Statement, error){},
+		detectors:       map[string]*duplicates.Detector[statements.Statement]{},
+		parsers:         map[string]statements.Reader{},
+		files:           slices.Sorted(maps.Keys(files)),
+		readCount:       0,
+	}
+
+	for _, stmtFile := range reader.files {
+		stmtParser, err := statements.NewReader(stmtFile, files[stmtFile], startDate, endDate, profile.StatementFormat(stmtFile))
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", stmtFile, err)
+		}
+		detector := duplicates.NewDetector(statementID)
+		reader.filesWithReader[stmtFile] = detector.Reader(stmtParser.ReadRow, dedupe)
+		reader.detectors[stmtFile] = detector
+		reader.parsers[stmtFile] = stmtParser
+	}
+
+	return &reader, nil
+}
+
+func (r *StatementReader) Read() (reconciliation.StatementFilePair, error) {
+	if len(r.files) == r.readCount {
+		return reconciliation.StatementFilePair{}, io.EOF
+	}
+
+	currentFile := r.files[r.readCount]
+	currentReader := r.filesWithReader[currentFile]
+
+	statement, err := currentReader()
+	if err == io.EOF {
+		r.readCount++
+		return r.Read()
+	}
+	if err != nil {
+		return reconciliation.StatementFilePair{}, fmt.Errorf("%s: %w", currentFile, err)
+	}
+
+	return reconciliation.StatementFilePair{
+		Name:      currentFile,
+		Statement: statement,
+	}, nil
+}
+
+// Balances returns the balance carried by the files, which is complete
+// once the files are read, see [statements.BalanceReader]
+func (r *StatementReader) Balances() map[string]Balance {
+	balances := make(map[string]statements.Balance)
+	for file, parser := range r.parsers {
+		if balance, ok := fileBalance(parser); ok {
+			balances[file] = balance
+		}
+	}
+	return balances
+}
+
+// Duplicates returns the duplicates found on the sources read so far
+func (r *StatementReader) Duplicates() Report {
+	report := make(duplicates.Report)
+	for file, detector := range r.detectors {
+		if dups := detector.Duplicates(); len(dups) > 0 {
+			report[file] = dups
+		}
+	}
+	return report
+}
+
+// ReconcileConcurrent is [Reconcile] that streams the inputs, see
+// [reconciliation.ProcessConcurrent].
+func ReconcileConcurrent(opts Options, in Inputs) (Result, Report, error) {
+	sources := slices.Collect(maps.Keys(in.Statements))
+	reconOpts, err := reconciliationOptions(opts.Profile, sources)
+	if err != nil {
+		return reconciliation.Result{}, nil, err
+	}
+
+	transactionParser, err := NewTransactionReader(opts.Profile, in, opts.StartDate, opts.EndDate)
+	if err != nil {
+		return reconciliation.Result{}, nil, err
+	}
+	defer transactionParser.Close()
+	trxDetector := duplicates.NewDetector(transactionID)
+	statementParser, err := NewStatementReader(opts.Profile, in.Statements, opts.StartDate, opts.EndDate, opts.Dedupe)
+	if err != nil {
+		return reconciliation.Result{}, nil, err
+	}
+	reconOpts.FileBalances = statementParser.Balances
+
+	trxReader := reconciliation.Reader[transactions.Transaction](trxDetector.Reader(transactionParser.ReadRow, opts.Dedupe))
+	stmtReader := reconciliation.Reader[reconciliation.StatementFilePair](statementParser.Read)
+	if st := opts.Store; st != nil {
+		for _, open := range st.State.OpenStatements {
+			sources = append(sources, open.Source)
+		}
+
+		if opts.Incremental {
+			trxReader = st.NewTransactionsReader(trxReader)
+		}
+		trxReader, stmtReader = st.CarryForwardReaders(trxReader, stmtReader)
+		reconOpts = st.Apply(reconOpts)
+	}
+
+	reconOpts.Accounts = opts.Profile.StatementAccounts(sources)
+
+	result, err := reconciliation.ProcessConcurrent(trxReader, stmtReader, reconOpts)
+	if err != nil {
+		return reconciliation.Result{}, nil, err
+	}
+
+	dups := statementParser.Duplicates()
+	if trxDups := trxDetector.Duplicates(); len(trxDups) > 0 {
+		dups[in.TransactionSource] = trxDups
+	}
+
+	return result, dups, nil
+}
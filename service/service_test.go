@@ -0,0 +1,230 @@
+package service_test
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	csvparser "github.com/rickyson96/amartha-reconciliation-service/internal/csv_parser"
+	"github.com/rickyson96/amartha-reconciliation-service/internal/duplicates"
+	"github.com/rickyson96/amartha-reconciliation-service/service"
+)
+
+func TestReconcile(t *testing.T) {
+	inputs := func() service.Inputs {
+		return service.Inputs{
+			TransactionSource: "trx",
+			Transactions: strings.NewReader("trxID,amount,type,transactionTime\n" +
+				"1,100.00,CREDIT,2025-03-10 10:00:00\n" +
+				"2,50.00,DEBIT,2025-03-11 10:00:00\n" +
+				"2,50.00,DEBIT,2025-03-11 10:00:00\n" +
+				"3,70.00,DEBIT,2025-04-01 10:00:00\n"),
+			Statements: map[string]io.Reader{
+				"bank2": strings.NewReader("uniqueIdentifier,amount,date\nb1,-50.00,2025-03-11\n"),
+				"bank1": strings.NewReader("uniqueIdentifier,amount,date\na1,100.00,2025-03-10\na2,20.00,2025-03-12\n"),
+			},
+		}
+	}
+	opts := service.Options{
+		Profile:   service.DefaultProfile(),
+		StartDate: time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC),
+		EndDate:   time.Date(2025, 3, 31, 0, 0, 0, 0, time.UTC),
+		Dedupe:    true,
+	}
+
+	reconcilers := map[string]func(service.Options, service.Inputs) (service.Result, service.Report, error){
+		"sync":       service.Reconcile,
+		"concurrent": service.ReconcileConcurrent,
+	}
+	for name, reconcile := range reconcilers {
+		t.Run(name, func(t *testing.T) {
+			result, dups, err := reconcile(opts, inputs())
+			if err != nil {
+				t.Fatalf("unwanted error: %v", err)
+			}
+
+			// the april transaction is filtered out, and the duplicate dropped
+			if result.Processed != 5 || result.Match != 4 {
+				t.Errorf("want 5 processed and 4 matched, got %d and %d", result.Processed, result.Match)
+			}
+
+			var unmatched []string
+			for _, stmts := range result.Unmatched.Statements {
+				for _, stmt := range stmts {
+					unmatched = append(unmatched, stmt.UniqueIdentifier)
+				}
+			}
+			if diff := cmp.Diff([]string{"a2"}, unmatched); diff != "" {
+				t.Errorf("unmatched statements mismatch, (-want,+got):\n%s", diff)
+			}
+
+			want := duplicates.Report{"trx": {{ID: "2", Lines: []int{3, 4}, Exact: true, Copies: []int{4}}}}
+			if diff := cmp.Diff(want, dups); diff != "" {
+				t.Errorf("duplicates mismatch, (-want,+got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestReconcileInvalidStatement(t *testing.T) {
+	inputs := func() service.Inputs {
+		return service.Inputs{
+			TransactionSource: "trx",
+			Transactions:      strings.NewReader("trxID,amount,type,transactionTime\n1,100.00,CREDIT,2025-03-10 10:00:00\n"),
+			Statements: map[string]io.Reader{
+				"bank": strings.NewReader("uniqueIdentifier,amount,date\na1,oops,2025-03-10\na2,-400.00,2025-03-11\n"),
+			},
+		}
+	}
+	opts := service.Options{
+		Profile:   service.DefaultProfile(),
+		StartDate: time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC),
+		EndDate:   time.Date(2025, 3, 31, 0, 0, 0, 0, time.UTC),
+	}
+
+	reconcilers := map[string]func(service.Options, service.Inputs) (service.Result, service.Report, error){
+		"sync":       service.Reconcile,
+		"concurrent": service.ReconcileConcurrent,
+	}
+	for name, reconcile := range reconcilers {
+		t.Run(name, func(t *testing.T) {
+			var rowErr *csvparser.RowError
+			if _, _, err := reconcile(opts, inputs()); !errors.As(err, &rowErr) {
+				t.Fatalf("want row error, got %v", err)
+			}
+			if rowErr.Line != 2 {
+				t.Errorf("want error on line 2, got %d", rowErr.Line)
+			}
+		})
+	}
+}
+
+func TestReconcileFileBalances(t *testing.T) {
+	// the closing balance of the last statement follows the entries of
+	// the first one
+	camt := `<Document><BkToCstmrStmt>
+  <Stmt>
+    <Bal><Tp><CdOrPrtry><Cd>OPBD</Cd></CdOrPrtry></Tp><Amt>1000.00</Amt><CdtDbtInd>CRDT</CdtDbtInd></Bal>
+    <Bal><Tp><CdOrPrtry><Cd>CLBD</Cd></CdOrPrtry></Tp><Amt>1100.00</Amt><CdtDbtInd>CRDT</CdtDbtInd></Bal>
+    <Ntry><NtryRef>a1</NtryRef><Amt>100.00</Amt><CdtDbtInd>CRDT</CdtDbtInd><Sts>BOOK</Sts><BookgDt><Dt>2025-03-10</Dt></BookgDt></Ntry>
+  </Stmt>
+  <Stmt>
+    <Bal><Tp><CdOrPrtry><Cd>OPBD</Cd></CdOrPrtry></Tp><Amt>1100.00</Amt><CdtDbtInd>CRDT</CdtDbtInd></Bal>
+    <Bal><Tp><CdOrPrtry><Cd>CLBD</Cd></CdOrPrtry></Tp><Amt>1050.00</Amt><CdtDbtInd>CRDT</CdtDbtInd></Bal>
+    <Ntry><NtryRef>a2</NtryRef><Amt>50.00</Amt><CdtDbtInd>DBIT</CdtDbtInd><Sts>BOOK</Sts><BookgDt><Dt>2025-03-11</Dt></BookgDt></Ntry>
+  </Stmt>
+</BkToCstmrStmt></Document>`
+	inputs := func() service.Inputs {
+		return service.Inputs{
+			TransactionSource: "trx",
+			Transactions:      strings.NewReader("trxID,amount,type,transactionTime\n"),
+			Statements:        map[string]io.Reader{"bank.xml": strings.NewReader(camt)},
+		}
+	}
+	opts := service.Options{
+		Profile:   service.DefaultProfile(),
+		StartDate: time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC),
+		EndDate:   time.Date(2025, 3, 31, 0, 0, 0, 0, time.UTC),
+	}
+
+	reconcilers := map[string]func(service.Options, service.Inputs) (service.Result, service.Report, error){
+		"sync":       service.Reconcile,
+		"concurrent": service.ReconcileConcurrent,
+	}
+	for name, reconcile := range reconcilers {
+		t.Run(name, func(t *testing.T) {
+			result, _, err := reconcile(opts, inputs())
+			if err != nil {
+				t.Fatalf("unwanted error: %v", err)
+			}
+
+			check, ok := result.BalanceChecks["bank.xml"]
+			if !ok {
+				t.Fatalf("want the balance of the file checked")
+			}
+			if check.IsBreak() {
+				t.Errorf("want no balance break, got %+v", check)
+			}
+		})
+	}
+}
+
+func TestReconcilePendingEntries(t *testing.T) {
+	// the pending entry isn't part of the booked closing balance
+	camt := `<Document><BkToCstmrStmt><Stmt>
+  <Bal><Tp><CdOrPrtry><Cd>OPBD</Cd></CdOrPrtry></Tp><Amt>1000.00</Amt><CdtDbtInd>CRDT</CdtDbtInd></Bal>
+  <Bal><Tp><CdOrPrtry><Cd>CLBD</Cd></CdOrPrtry></Tp><Amt>1100.00</Amt><CdtDbtInd>CRDT</CdtDbtInd></Bal>
+  <Ntry><NtryRef>a1</NtryRef><Amt>100.00</Amt><CdtDbtInd>CRDT</CdtDbtInd><Sts>BOOK</Sts><BookgDt><Dt>2025-03-10</Dt></BookgDt></Ntry>
+  <Ntry><NtryRef>p1</NtryRef><Amt>40.00</Amt><CdtDbtInd>CRDT</CdtDbtInd><Sts><Cd>PDNG</Cd></Sts><BookgDt><Dt>2025-03-11</Dt></BookgDt></Ntry>
+</Stmt></BkToCstmrStmt></Document>`
+	inputs := func() service.Inputs {
+		return service.Inputs{
+			TransactionSource: "trx",
+			Transactions: strings.NewReader("trxID,amount,type,transactionTime\n" +
+				"1,100.00,CREDIT,2025-03-10 10:00:00\n" +
+				"2,40.00,CREDIT,2025-03-11 10:00:00\n"),
+			Statements: map[string]io.Reader{"bank.xml": strings.NewReader(camt)},
+		}
+	}
+
+	withPending := service.NewStatementFormat("bank", "bank*.xml")
+	withPending.Camt.Pending = true
+	profiles := map[string]struct {
+		formats     []service.StatementFormat
+		wantPending []string
+	}{
+		"booked only":  {nil, nil},
+		"with pending": {[]service.StatementFormat{withPending}, []string{"p1"}},
+	}
+
+	reconcilers := map[string]func(service.Options, service.Inputs) (service.Result, service.Report, error){
+		"sync":       service.Reconcile,
+		"concurrent": service.ReconcileConcurrent,
+	}
+	for profileName, p := range profiles {
+		for name, reconcile := range reconcilers {
+			t.Run(profileName+"/"+name, func(t *testing.T) {
+				opts := service.Options{
+					Profile:   service.DefaultProfile(),
+					StartDate: time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC),
+					EndDate:   time.Date(2025, 3, 31, 0, 0, 0, 0, time.UTC),
+				}
+				opts.Profile.StatementFormats = p.formats
+
+				result, _, err := reconcile(opts, inputs())
+				if err != nil {
+					t.Fatalf("unwanted error: %v", err)
+				}
+
+				var pending []string
+				for _, stmt := range result.Pending["bank.xml"] {
+					pending = append(pending, stmt.UniqueIdentifier)
+				}
+				if diff := cmp.Diff(p.wantPending, pending); diff != "" {
+					t.Errorf("pending mismatch, (-want,+got):\n%s", diff)
+				}
+
+				// the transaction of the pending entry waits for its booking
+				var matched, unmatched []string
+				for _, m := range result.Matched {
+					matched = append(matched, m.Transaction.TrxID)
+				}
+				for _, trx := range result.Unmatched.Transactions {
+					unmatched = append(unmatched, trx.TrxID)
+				}
+				if diff := cmp.Diff([]string{"1"}, matched); diff != "" {
+					t.Errorf("matched mismatch, (-want,+got):\n%s", diff)
+				}
+				if diff := cmp.Diff([]string{"2"}, unmatched); diff != "" {
+					t.Errorf("unmatched mismatch, (-want,+got):\n%s", diff)
+				}
+				if check := result.BalanceChecks["bank.xml"]; check.IsBreak() {
+					t.Errorf("want no balance break, got %+v", check)
+				}
+			})
+		}
+	}
+}
@@ -0,0 +1,157 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/rickyson96/amartha-reconciliation-service/internal/config"
+	"github.com/rickyson96/amartha-reconciliation-service/internal/input"
+	"github.com/rickyson96/amartha-reconciliation-service/internal/validation"
+)
+
+// runValidate lints the input files without reconciling them, and exits
+// non-zero when blocking issues are found
+func runValidate(args []string) {
+	set := flag.NewFlagSet("validate", flag.ExitOnError)
+	set.Usage = func() {
+		fmt.Fprintf(set.Output(), "Usage of %s validate [options] {transaction file} {statement files} {start date} {end date}\n", os.Args[0])
+		fmt.Fprintf(set.Output(), "Options:\n")
+		set.PrintDefaults()
+	}
+	profilePath := set.String("profile", "", "reconciliation profile json file, configuring the file formats")
+	set.Parse(args)
+
+	fatal := func(format string, msg ...any) {
+		fmt.Printf(format+"\n\n", msg...)
+		set.Usage()
+		os.Exit(1)
+	}
+
+	if set.NArg() != 4 {
+		fatal("ERROR: Need exactly 4 arguments!")
+	}
+
+	startDate, err := time.Parse(time.DateOnly, set.Arg(2))
+	if err != nil {
+		fatal("ERROR: start date wrong format: %v", err)
+	}
+
+	endDate, err := time.Parse(time.DateOnly, set.Arg(3))
+	if err != nil {
+		fatal("ERROR: end date wrong format: %v", err)
+	}
+
+	profile, err := config.Load(*profilePath)
+	if err != nil {
+		fatal("ERROR: load profile: %v", err)
+	}
+
+	reports, err := validate(profile, set.Arg(0), strings.Split(set.Arg(1), ","), startDate, endDate)
+	if err != nil {
+		log.Fatalf("ERROR: validate: %v", err)
+	}
+
+	blocking := printValidation(os.Stdout, reports)
+	if blocking {
+		os.Exit(1)
+	}
+}
+
+func validate(profile config.Profile, transactionFile string, statementFiles []string, startDate, endDate time.Time) ([]validation.Report, error) {
+	statementFiles, err := input.Expand(statementFiles...)
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := input.Open(transactionFile)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	report, err := validation.Transactions(transactionFile, file, profile.TransactionFormat.Format, startDate, endDate)
+	if err != nil {
+		return nil, err
+	}
+	reports := []validation.Report{report}
+
+	for _, fileName := range statementFiles {
+		file, err := input.Open(fileName)
+		if err != nil {
+			return nil, err
+		}
+
+		report, err := validation.Statements(fileName, file, profile.StatementFormat(fileName), startDate, endDate)
+		file.Close()
+		if err != nil {
+			return nil, err
+		}
+		reports = append(reports, report)
+	}
+
+	return reports, nil
+}
+
+// printValidation prints the reports, and returns whether any of them
+// has blocking issues
+func printValidation(out io.Writer, reports []validation.Report) bool {
+	blocking := false
+
+	w := tabwriter.NewWriter(out, 4, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "\tFile\tRows\tFirst Date\tLast Date\tOut of Range\tErrors\tWarnings\tStatus")
+	for _, r := range reports {
+		errCount, warnCount := 0, 0
+		for _, issue := range r.Issues {
+			if issue.Severity == validation.SeverityError {
+				errCount++
+			} else {
+				warnCount++
+			}
+		}
+
+		status := "OK"
+		if r.Blocking() {
+			status = "BLOCKED"
+			blocking = true
+		}
+
+		fmt.Fprintf(w, "\t%v\t%d\t%v\t%v\t%d\t%d\t%d\t%v\n", r.File, r.Rows, formatDate(r.First), formatDate(r.Last), r.OutOfRange, errCount, warnCount, status)
+	}
+	w.Flush()
+
+	for _, r := range reports {
+		if len(r.Issues) == 0 {
+			continue
+		}
+
+		fmt.Fprintln(out, "------------------")
+		fmt.Fprintf(out, "%s:\n", r.File)
+
+		w := tabwriter.NewWriter(out, 4, 0, 2, ' ', 0)
+		fmt.Fprintln(w)
+		fmt.Fprintln(w, "\tLine\tSeverity\tIssue")
+		for _, issue := range r.Issues {
+			line := "-"
+			if issue.Line > 0 {
+				line = fmt.Sprint(issue.Line)
+			}
+			fmt.Fprintf(w, "\t%v\t%v\t%v\n", line, issue.Severity, issue.Message)
+		}
+		w.Flush()
+	}
+
+	return blocking
+}
+
+func formatDate(date time.Time) string {
+	if date.IsZero() {
+		return "-"
+	}
+	return date.Format(time.DateOnly)
+}
@@ -0,0 +1,282 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/rickyson96/amartha-reconciliation-service/internal/config"
+	"github.com/rickyson96/amartha-reconciliation-service/internal/input"
+	"github.com/rickyson96/amartha-reconciliation-service/internal/store"
+	"github.com/rickyson96/amartha-reconciliation-service/internal/watcher"
+)
+
+// watchOptions configures the watch cycles
+type watchOptions struct {
+	reconcileOptions
+	transactionPatterns []string
+	statementPatterns   []string
+	outputDir           string
+	archiveDir          string
+	errorDir            string
+	startDate, endDate  string
+}
+
+// runWatch polls the directories and reconciles the files dropped into
+// them on every cycle
+func runWatch(args []string) {
+	set := flag.NewFlagSet("watch", flag.ExitOnError)
+	set.Usage = func() {
+		fmt.Fprintf(set.Output(), "Usage of %s watch [options]\n", os.Args[0])
+		fmt.Fprintf(set.Output(), "Options:\n")
+		set.PrintDefaults()
+	}
+
+	dirs := set.String("dirs", ".", "comma separated directories to watch")
+	transactionPatterns := set.String("transactions", "transactions*.csv", "comma separated patterns of the transaction files")
+	statementPatterns := set.String("statements", "bank*.csv", "comma separated patterns of the statement files")
+	profilePath := set.String("profile", "", "reconciliation profile json file, configuring statement formats and matching rules")
+	storePath := set.String("store", "", "store json file persisting runs and open items, which are carried forward to the next runs")
+	outputDir := set.String("output", "reports", "directory of the reports")
+	archiveDir := set.String("archive", "archive", "directory where the processed files are moved into")
+	errorDir := set.String("errors", "errors", "directory where the files failing the reconciliation are moved into")
+	interval := set.Duration("interval", time.Minute, "polling interval")
+	startDate := set.String("start", "", "reconciliation start date, defaults to the first day of the month")
+	endDate := set.String("end", "", "reconciliation end date, defaults to today")
+	dedupe := set.Bool("dedupe", false, "drop rows exactly the same as a previous row of the same identifier within the same file")
+	once := set.Bool("once", false, "run a single cycle and exit")
+	set.Parse(args)
+
+	profile, err := config.Load(*profilePath)
+	if err != nil {
+		log.Fatalf("ERROR: load profile: %v", err)
+	}
+
+	opts := watchOptions{
+		reconcileOptions: reconcileOptions{
+			profile:     profile,
+			storePath:   *storePath,
+			incremental: *storePath != "",
+			showMatched: true,
+			dedupe:      *dedupe,
+		},
+		transactionPatterns: strings.Split(*transactionPatterns, ","),
+		statementPatterns:   strings.Split(*statementPatterns, ","),
+		outputDir:           *outputDir,
+		archiveDir:          *archiveDir,
+		errorDir:            *errorDir,
+		startDate:           *startDate,
+		endDate:             *endDate,
+	}
+
+	w := watcher.New(strings.Split(*dirs, ",")...)
+	if *once {
+		// files need to settle for an interval before being ready
+		if _, err := w.Poll(append(opts.transactionPatterns, opts.statementPatterns...)...); err != nil {
+			log.Fatalf("ERROR: poll: %v", err)
+		}
+		time.Sleep(*interval)
+
+		if err := watchCycle(w, opts); err != nil {
+			log.Fatalf("ERROR: %v", err)
+		}
+		return
+	}
+
+	log.Printf("watching %s every %v", *dirs, *interval)
+	for {
+		if err := watchCycle(w, opts); err != nil {
+			log.Printf("ERROR: %v", err)
+		}
+		time.Sleep(*interval)
+	}
+}
+
+// watchCycle reconciles the ready files, writing the report into the
+// output directory and archiving the files afterwards. Files failing the
+// reconciliation are moved into the error directory instead, so they're
+// not retried on every cycle.
+func watchCycle(w *watcher.Watcher, opts watchOptions) error {
+	trxFiles, err := w.Poll(opts.transactionPatterns...)
+	if err != nil {
+		return fmt.Errorf("poll transaction files: %w", err)
+	}
+
+	stmtFiles, err := w.Poll(opts.statementPatterns...)
+	if err != nil {
+		return fmt.Errorf("poll statement files: %w", err)
+	}
+
+	switch {
+	case len(trxFiles) == 0 && len(stmtFiles) == 0:
+		log.Printf("no new files")
+		return nil
+	case len(trxFiles) == 0:
+		// the statements of the later drops of the day are reconciled
+		// against the transactions still open on the store
+		ready, err := watchStoreReady(opts.storePath)
+		if err != nil {
+			return err
+		}
+		if !ready {
+			log.Printf("%d statement files waiting for the transaction file", len(stmtFiles))
+			return nil
+		}
+	case len(stmtFiles) == 0:
+		log.Printf("%d transaction files waiting for the statement files", len(trxFiles))
+		return nil
+	}
+
+	startDate, endDate, err := watchDateRange(opts.startDate, opts.endDate, time.Now())
+	if err != nil {
+		return err
+	}
+
+	// every transaction export is reconciled in the order it's dropped,
+	// e.g. the deltas of the day. The statements are archived along with
+	// the last run, while the later runs skip the statement files ingested
+	// by the earlier runs of the store.
+	if err := sortByModTime(trxFiles); err != nil {
+		return fmt.Errorf("stat transaction files: %w", err)
+	}
+	transactionFiles := trxFiles
+	if len(transactionFiles) == 0 {
+		transactionFiles = []string{noTransactionFile}
+	}
+	for i, transactionFile := range transactionFiles {
+		var files []string
+		if transactionFile != noTransactionFile {
+			files = append(files, transactionFile)
+		}
+		if i == len(transactionFiles)-1 {
+			files = append(files, stmtFiles...)
+		}
+		if err := watchRun(w, opts, transactionFile, stmtFiles, files, startDate, endDate); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// watchRun reconciles the transaction file against the statement files,
+// writing the report into the output directory and archiving the files
+// of the run afterwards
+func watchRun(w *watcher.Watcher, opts watchOptions, transactionFile string, stmtFiles, files []string, startDate, endDate time.Time) error {
+	runID := store.NewRunID(time.Now())
+	opts.runID = runID
+
+	// the report is kept in memory, so failed runs don't leave partial
+	// reports behind
+	var report bytes.Buffer
+	if err := watchReconcile(&report, opts, transactionFile, stmtFiles, startDate, endDate); err != nil {
+		errorDir := filepath.Join(opts.errorDir, runID)
+		if moveErr := w.Archive(errorDir, files...); moveErr != nil {
+			return errors.Join(err, fmt.Errorf("move failed files: %w", moveErr))
+		}
+		if writeErr := os.WriteFile(filepath.Join(errorDir, "error.txt"), []byte(err.Error()+"\n"), 0o644); writeErr != nil {
+			return errors.Join(err, writeErr)
+		}
+		return fmt.Errorf("%w, files moved to %s", err, errorDir)
+	}
+
+	if err := os.MkdirAll(opts.outputDir, 0o755); err != nil {
+		return err
+	}
+	reportFile := filepath.Join(opts.outputDir, runID+".txt")
+	if err := os.WriteFile(reportFile, report.Bytes(), 0o644); err != nil {
+		return err
+	}
+
+	archiveDir := filepath.Join(opts.archiveDir, runID)
+	if err := w.Archive(archiveDir, files...); err != nil {
+		return fmt.Errorf("archive: %w", err)
+	}
+
+	log.Printf("report written to %s, files archived to %s", reportFile, archiveDir)
+	return nil
+}
+
+// watchReconcile reconciles the transaction file against the statement
+// files, where zip archives are reconciled as their members
+func watchReconcile(out io.Writer, opts watchOptions, transactionFile string, stmtFiles []string, startDate, endDate time.Time) error {
+	sources, err := input.Expand(stmtFiles...)
+	if err != nil {
+		return err
+	}
+
+	if transactionFile == noTransactionFile {
+		log.Printf("reconciling the open transactions of the store against %v", sources)
+	} else {
+		log.Printf("reconciling %s against %v", transactionFile, sources)
+	}
+	if err := reconcile(out, opts.reconcileOptions, transactionFile, sources, startDate, endDate); err != nil {
+		return fmt.Errorf("reconcile: %w", err)
+	}
+	return nil
+}
+
+// sortByModTime sorts the files by their modification time, the older
+// first
+func sortByModTime(files []string) error {
+	modTimes := make(map[string]time.Time, len(files))
+	for _, file := range files {
+		info, err := os.Stat(file)
+		if err != nil {
+			return err
+		}
+		modTimes[file] = info.ModTime()
+	}
+
+	slices.SortStableFunc(files, func(a, b string) int {
+		return modTimes[a].Compare(modTimes[b])
+	})
+	return nil
+}
+
+// watchStoreReady reports whether the store has transactions ingested by
+// the previous runs, so the statement files can be reconciled without a
+// new transaction file
+func watchStoreReady(storePath string) (bool, error) {
+	if storePath == "" {
+		return false, nil
+	}
+
+	st, err := store.Open(storePath)
+	if err != nil {
+		return false, fmt.Errorf("open store: %w", err)
+	}
+	return len(st.State.OpenTransactions) > 0 || len(st.State.IngestedTransactions) > 0, nil
+}
+
+// watchDateRange parses the date range, defaulting to the month to date
+func watchDateRange(start, end string, now time.Time) (time.Time, time.Time, error) {
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+
+	startDate := time.Date(today.Year(), today.Month(), 1, 0, 0, 0, 0, time.UTC)
+	if start != "" {
+		var err error
+		startDate, err = time.Parse(time.DateOnly, start)
+		if err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("start date wrong format: %w", err)
+		}
+	}
+
+	endDate := today
+	if end != "" {
+		var err error
+		endDate, err = time.Parse(time.DateOnly, end)
+		if err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("end date wrong format: %w", err)
+		}
+	}
+
+	return startDate, endDate, nil
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/rickyson96/amartha-reconciliation-service/internal/config"
+	"github.com/rickyson96/amartha-reconciliation-service/internal/store"
+	"github.com/rickyson96/amartha-reconciliation-service/internal/watcher"
+)
+
+func TestWatchCycleTransactionFiles(t *testing.T) {
+	dir := t.TempDir()
+	in := filepath.Join(dir, "in")
+	if err := os.Mkdir(in, 0o755); err != nil {
+		t.Fatalf("failed creating input dir: %v", err)
+	}
+
+	files := map[string]string{
+		"transactions-a.csv": "trxID,amount,type,transactionTime\n1,1000.00,CREDIT,2025-03-12 18:02:07\n",
+		"transactions-b.csv": "trxID,amount,type,transactionTime\n2,2000.00,CREDIT,2025-03-10 18:02:07\n",
+		"bank1.csv":          "uniqueIdentifier,amount,date\n1,1000.00,2025-03-12\n2,2000.00,2025-03-10\n",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(in, name), []byte(content), 0o600); err != nil {
+			t.Fatalf("failed writing %s: %v", name, err)
+		}
+	}
+	// the delta b is dropped before a
+	now := time.Now()
+	if err := os.Chtimes(filepath.Join(in, "transactions-b.csv"), now, now.Add(-time.Hour)); err != nil {
+		t.Fatalf("failed setting the modification time: %v", err)
+	}
+
+	profile, err := config.Load("")
+	if err != nil {
+		t.Fatalf("failed loading the default profile: %v", err)
+	}
+	storePath := filepath.Join(dir, "store.json")
+	opts := watchOptions{
+		reconcileOptions: reconcileOptions{
+			profile:     profile,
+			storePath:   storePath,
+			incremental: true,
+		},
+		transactionPatterns: []string{"transactions*.csv"},
+		statementPatterns:   []string{"bank*.csv"},
+		outputDir:           filepath.Join(dir, "reports"),
+		archiveDir:          filepath.Join(dir, "archive"),
+		errorDir:            filepath.Join(dir, "errors"),
+		startDate:           "2025-03-01",
+		endDate:             "2025-03-31",
+	}
+
+	w := watcher.New(in)
+	// files are ready on the second poll
+	if _, err := w.Poll("*.csv"); err != nil {
+		t.Fatalf("Poll failed: %v", err)
+	}
+	if err := watchCycle(w, opts); err != nil {
+		t.Fatalf("watchCycle failed: %v", err)
+	}
+
+	st, err := store.Open(storePath)
+	if err != nil {
+		t.Fatalf("failed opening store: %v", err)
+	}
+	var runs []string
+	for _, run := range st.State.Runs {
+		runs = append(runs, filepath.Base(run.TransactionFile))
+	}
+	if diff := cmp.Diff([]string{"transactions-b.csv", "transactions-a.csv"}, runs); diff != "" {
+		t.Errorf("runs mismatch, (-want,+got):\n%s", diff)
+	}
+	// the statement of the later run is carried from the earlier one
+	if len(st.State.OpenTransactions) != 0 || len(st.State.OpenStatements) != 0 {
+		t.Errorf("open items left, transactions %v, statements %v", st.State.OpenTransactions, st.State.OpenStatements)
+	}
+
+	reports, err := os.ReadDir(opts.outputDir)
+	if err != nil {
+		t.Fatalf("failed reading reports: %v", err)
+	}
+	if len(reports) != 2 {
+		t.Errorf("want a report for each run, got %d", len(reports))
+	}
+	left, err := os.ReadDir(in)
+	if err != nil {
+		t.Fatalf("failed reading input dir: %v", err)
+	}
+	if len(left) != 0 {
+		t.Errorf("want every file archived, got %d left", len(left))
+	}
+}